@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gnemet/SlideForge/internal/database"
+	"github.com/gnemet/SlideForge/internal/pdf"
+	"github.com/gnemet/SlideForge/internal/pptx"
+)
+
+// exportRequest is the payload of POST /export/pdf.
+type exportRequest struct {
+	SlideIDs []int  `json:"slide_ids"`
+	Filename string `json:"filename"`
+	Layout   string `json:"layout"`
+	PerPage  int    `json:"per_page"`
+}
+
+// handleDeckPDF exports a deck, or a page range of it, as a PDF. A whole deck
+// in the slides layout is served from the LibreOffice PDF cache when that
+// engine is active (vector text); everything else is built from the rendered
+// slide images. Hidden slides are left out of whole-deck exports. version
+// exports an earlier version of the deck.
+// GET /pptx/{id}/pdf?pages=3-5&layout=handout&per_page=3&version=2
+func handleDeckPDF(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid file id", http.StatusBadRequest)
+		return
+	}
+	first, last, err := parsePageRange(r.URL.Query().Get("pages"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	opts, err := exportOptions(r.URL.Query().Get("layout"), r.URL.Query().Get("per_page"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	file, err := database.GetPPTXByID(sqlDB, id)
+	if err == sql.ErrNoRows {
+		http.NotFound(w, r)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	slides, version, err := deckSlides(file.ID, r.URL.Query().Get("version"))
+	if err == sql.ErrNoRows {
+		http.NotFound(w, r)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	opts.Title = file.Title
+	if opts.Title == "" {
+		opts.Title = file.Filename
+	}
+	source := file.OriginalFilePath
+	base := strings.TrimSuffix(file.Filename, filepath.Ext(file.Filename))
+	if version != nil {
+		source = version.OriginalFilePath
+		base = fmt.Sprintf("%s_v%d", base, version.Version)
+	}
+	name := base
+	if first > 0 {
+		name = fmt.Sprintf("%s_%d-%d", base, first, last)
+	}
+
+	var pages []pdf.Slide
+	hidden := false
+	for _, s := range slides {
+		page := pptx.ThumbnailPage(s.PNGPath)
+		if first > 0 && (page < first || page > last) {
+			continue
+		}
+		if first == 0 && s.Hidden {
+			hidden = true
+			continue
+		}
+		pages = append(pages, pdf.Slide{
+			Image: slideImageFile(s.PNGPath),
+			Label: fmt.Sprintf("%s - %d", file.Filename, page),
+			Notes: s.Notes,
+		})
+	}
+
+	if first == 0 && !hidden && opts.Layout == pdf.LayoutSlides && pptx.DefaultRenderer().Name() == pptx.EngineLibreOffice {
+		cached, err := pptx.ConvertToPDF(r.Context(), source, pptx.RenderOptions{CacheDir: cfg.Application.Storage.PDFCache})
+		if err == nil {
+			data, err := os.ReadFile(cached)
+			if err == nil {
+				writePDFDownload(w, outputFilename(name, "slideforge", ".pdf"), data)
+				return
+			}
+		}
+		log.Printf("PDF export of %s falls back to slide images: %v", file.Filename, err)
+	}
+
+	if len(pages) == 0 {
+		http.Error(w, "No slides in range", http.StatusNotFound)
+		return
+	}
+	var buf bytes.Buffer
+	if err := pdf.Write(&buf, pages, opts); err != nil {
+		log.Printf("PDF export failed: %v", err)
+		http.Error(w, "PDF export failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writePDFDownload(w, outputFilename(name, "slideforge", ".pdf"), buf.Bytes())
+}
+
+// handleExportPDF exports the given slides, in order, as a PDF; the generator
+// sends its collection or selection here.
+// POST /export/pdf
+func handleExportPDF(w http.ResponseWriter, r *http.Request) {
+	var req exportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(req.SlideIDs) == 0 {
+		http.Error(w, "No slides selected", http.StatusBadRequest)
+		return
+	}
+	opts, err := exportOptions(req.Layout, strconv.Itoa(req.PerPage))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	slides, err := database.GetSlidesByIDs(sqlDB, req.SlideIDs)
+	if errors.Is(err, database.ErrSlideNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	sources, err := database.GetSlideSources(sqlDB, req.SlideIDs)
+	if errors.Is(err, database.ErrSlideNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	filename := outputFilename(req.Filename, "slideforge", ".pdf")
+	opts.Title = strings.TrimSuffix(filename, ".pdf")
+	pages := make([]pdf.Slide, len(slides))
+	for i, s := range slides {
+		pages[i] = pdf.Slide{
+			Image: slideImageFile(s.PNGPath),
+			Label: fmt.Sprintf("%s - %d", sources[i].Filename, sources[i].SlideNum),
+			Notes: s.Notes,
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Write(&buf, pages, opts); err != nil {
+		log.Printf("PDF export failed: %v", err)
+		http.Error(w, "PDF export failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writePDFDownload(w, filename, buf.Bytes())
+}
+
+// exportOptions parses the layout and handout slides per page of an export;
+// empty values take the defaults.
+func exportOptions(layout, perPage string) (pdf.Options, error) {
+	opts := pdf.Options{Layout: layout}
+	if layout != "" && layout != pdf.LayoutSlides && layout != pdf.LayoutHandout {
+		return opts, fmt.Errorf("layout must be %s or %s", pdf.LayoutSlides, pdf.LayoutHandout)
+	}
+	if perPage != "" && perPage != "0" {
+		n, err := strconv.Atoi(perPage)
+		if err != nil || n < 1 || n > pdf.MaxPerPage {
+			return opts, fmt.Errorf("per_page must be between 1 and %d", pdf.MaxPerPage)
+		}
+		opts.PerPage = n
+	}
+	return opts, nil
+}
+
+// slideImageFile resolves a slide's image URL to a file, preferring its
+// preview rendition (smaller than the full-size PNG, sharp enough to print).
+func slideImageFile(pngPath string) string {
+	file := strings.TrimPrefix(pngPath, "/")
+	if rel, ok := strings.CutPrefix(pngPath, "/thumbnails/"); ok {
+		if f := filepath.Join(cfg.Application.Storage.Thumbnails, filepath.FromSlash(rel)); fileExists(f) {
+			file = f
+		}
+	}
+	if page := pptx.ThumbnailPage(file); page > 0 {
+		preview := pptx.RenditionPath(filepath.Dir(file), page, "preview", "jpeg")
+		if fileExists(preview) {
+			return preview
+		}
+	}
+	return file
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+func writePDFDownload(w http.ResponseWriter, filename string, data []byte) {
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", len(data)))
+	w.Write(data)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gnemet/SlideForge/internal/database"
+	"github.com/gnemet/SlideForge/internal/pptx"
+)
+
+const pptxContentType = "application/vnd.openxmlformats-officedocument.presentationml.presentation"
+
+// generateRequest is the payload of POST /generate.
+type generateRequest struct {
+	SlideIDs []int  `json:"slide_ids"`
+	Filename string `json:"filename"`
+}
+
+// handleGenerate stitches the collected slides, in order, into a new PPTX download.
+func handleGenerate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req generateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(req.SlideIDs) == 0 {
+		http.Error(w, "No slides selected", http.StatusBadRequest)
+		return
+	}
+
+	sources, err := database.GetSlideSources(sqlDB, req.SlideIDs)
+	if errors.Is(err, database.ErrSlideNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	refs := make([]pptx.SlideRef, 0, len(sources))
+	for _, src := range sources {
+		refs = append(refs, pptx.SlideRef{PPTXPath: src.OriginalFilePath, SlideNumber: src.SlideNum})
+	}
+
+	var buf bytes.Buffer
+	if err := pptx.StitchSlides(&buf, refs); err != nil {
+		log.Printf("Deck generation failed: %v", err)
+		http.Error(w, "Deck generation failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writePPTXDownload(w, outputFilename(req.Filename, "slideforge", ".pptx"), buf.Bytes())
+}
+
+// outputFilename sanitizes a requested download name, falling back to a timestamped default.
+func outputFilename(requested, prefix, ext string) string {
+	name := filepath.Base(strings.TrimSpace(requested))
+	if name == "" || name == "." || name == string(filepath.Separator) {
+		name = fmt.Sprintf("%s_%s", prefix, time.Now().Format("20060102_150405"))
+	}
+	if !strings.HasSuffix(strings.ToLower(name), ext) {
+		name += ext
+	}
+	return name
+}
+
+func writePPTXDownload(w http.ResponseWriter, filename string, data []byte) {
+	w.Header().Set("Content-Type", pptxContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", len(data)))
+	w.Write(data)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gnemet/SlideForge/internal/database"
+)
+
+// handleDeckLayouts lists the layouts of a presentation with the number of
+// slides using each.
+// GET /pptx/{id}/layouts
+func handleDeckLayouts(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid file id", http.StatusBadRequest)
+		return
+	}
+
+	layouts, err := database.GetLayoutsByFile(sqlDB, id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(layouts)
+}
+
+// handleLayouts finds layouts by name across the library, e.g. to report the
+// decks still using a deprecated layout.
+// GET /layouts?name=...
+func handleLayouts(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimSpace(r.URL.Query().Get("name"))
+	if name == "" {
+		http.Error(w, "Missing layout name", http.StatusBadRequest)
+		return
+	}
+
+	layouts, err := database.FindLayoutsByName(sqlDB, name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(layouts)
+}
@@ -8,13 +8,18 @@ import (
 	"io"
 	"io/fs"
 	"log"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"encoding/json"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/gnemet/SlideForge/internal/ai"
@@ -23,6 +28,7 @@ import (
 	"github.com/gnemet/SlideForge/internal/i18n"
 	"github.com/gnemet/SlideForge/internal/observer"
 	"github.com/gnemet/SlideForge/internal/pptx"
+	"github.com/gnemet/SlideForge/internal/renderer"
 	"github.com/gnemet/datagrid"
 	"github.com/russross/blackfriday/v2"
 )
@@ -38,6 +44,11 @@ var (
 	logMutex   sync.Mutex
 	sseClients = make(map[chan string]bool)
 	sseMutex   sync.Mutex
+
+	progressChan chan observer.Progress
+	jobClients   = make(map[*jobClient]bool)
+	activeJobs   = make(map[int]observer.Progress) // latest event of each unfinished job
+	jobMutex     sync.Mutex
 )
 
 func main() {
@@ -49,10 +60,29 @@ func main() {
 
 	aiClient = ai.NewClient(cfg)
 
+	// LibreOffice worker pool, used by every PPTX -> PDF conversion
+	renderer.SetDefault(renderer.NewPool(renderer.Config{
+		Workers:    cfg.Renderer.Workers,
+		Timeout:    time.Duration(cfg.Renderer.TimeoutSeconds) * time.Second,
+		Retries:    cfg.Renderer.Retries,
+		ProfileDir: cfg.Renderer.ProfileDir,
+		Binary:     cfg.Renderer.Binary,
+	}))
+	slideRenderer, err := pptx.NewRenderer(cfg.Renderer.Engine)
+	if err != nil {
+		log.Fatal(err)
+	}
+	pptx.SetDefaultRenderer(slideRenderer)
+	log.Printf("Slide renderer: %s", slideRenderer.Name())
+
 	// Initialize Log Channel
 	logChan = make(chan string, 100)
 	go processLogs()
 
+	// Initialize Progress Channel
+	progressChan = make(chan observer.Progress, 100)
+	go processProgress()
+
 	// Database Connection - Jiramntr Style
 	sqlDB, err = database.NewConnection(cfg.Database.GetConnectStr())
 	if err != nil {
@@ -76,17 +106,23 @@ func main() {
 
 	log.Println("Database connection established")
 
+	// Stop on SIGINT/SIGTERM: running ingest jobs are cancelled and resumed on
+	// the next start
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// Start Background Observer
-	obs = observer.NewObserver(cfg, sqlDB, aiClient, logChan)
+	obs = observer.NewObserver(cfg, sqlDB, aiClient, logChan, progressChan)
 
+	observerDone := make(chan struct{})
 	go func() {
-		if err := obs.Start(context.Background()); err != nil {
+		defer close(observerDone)
+		if err := obs.Start(ctx); err != nil {
 			log.Printf("Observer error: %v", err)
 		}
 	}()
 
 	// Initialize Directories
-	os.MkdirAll("uploads", 0755)
 	os.MkdirAll("thumbnails", 0755)
 
 	i18n.Init()
@@ -119,12 +155,14 @@ func main() {
 	// Datagrid Handler for PPTX Files
 	dgHandler := datagrid.NewHandler(sqlDB, "pptx_files", []datagrid.UIColumn{
 		{Field: "filename", Label: "Name", Visible: true, Sortable: true},
+		{Field: "author", Label: "Author", Visible: true, Sortable: true},
+		{Field: "doc_modified_at", Label: "Modified", Visible: true, Sortable: true},
 		{Field: "created_at", Label: "Uploaded", Visible: true, Sortable: true},
 		{Field: "is_template", Label: "Template", Visible: true, Type: "boolean"},
 	}, datagrid.DatagridConfig{})
 
 	http.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.Dir("ui/static"))))
-	http.Handle("/thumbnails/", http.StripPrefix("/thumbnails/", http.FileServer(http.Dir(cfg.Application.Storage.Thumbnails))))
+	http.HandleFunc("GET /thumbnails/{path...}", handleThumbnail)
 
 	// Datagrid library assets (Embedded in library)
 	sub, _ := fs.Sub(datagrid.UIAssets, "ui/static")
@@ -134,6 +172,8 @@ func main() {
 	http.HandleFunc("/dashboard", AuthMiddleware(handleDashboard))
 	http.HandleFunc("/upload", AuthMiddleware(handleUpload))
 	http.HandleFunc("/templates", AuthMiddleware(dgHandler.ServeHTTP))
+	http.HandleFunc("POST /templates/{id}/render", AuthMiddleware(handleTemplateRender))
+	http.HandleFunc("POST /templates/{id}/validate", AuthMiddleware(handleTemplateValidate))
 	http.HandleFunc("/meta", AuthMiddleware(handleMetaPage))
 	http.HandleFunc("/resource", AuthMiddleware(handleResourcePage))
 	http.HandleFunc("/resource/list", AuthMiddleware(handleResourceList))
@@ -141,6 +181,18 @@ func main() {
 	http.HandleFunc("/collect", AuthMiddleware(handleCollect))
 	http.HandleFunc("/analyze", AuthMiddleware(handleAnalyze))
 	http.HandleFunc("/generator", AuthMiddleware(handleGenerator))
+	http.HandleFunc("/generate", AuthMiddleware(handleGenerate))
+	http.HandleFunc("POST /export/pdf", AuthMiddleware(handleExportPDF))
+	http.HandleFunc("GET /slides/{id}/tables/{n}/csv", AuthMiddleware(handleSlideTableCSV))
+	http.HandleFunc("GET /slides/{id}/outline", AuthMiddleware(handleSlideOutline))
+	http.HandleFunc("GET /pptx/{id}/outline", AuthMiddleware(handleDeckOutline))
+	http.HandleFunc("GET /media/{sha256}/slides", AuthMiddleware(handleMediaSlides))
+	http.HandleFunc("GET /pptx/{id}/layouts", AuthMiddleware(handleDeckLayouts))
+	http.HandleFunc("POST /pptx/{id}/render", AuthMiddleware(handleDeckRender))
+	http.HandleFunc("GET /pptx/{id}/pdf", AuthMiddleware(handleDeckPDF))
+	http.HandleFunc("GET /pptx/{id}/versions", AuthMiddleware(handleDeckVersions))
+	http.HandleFunc("GET /pptx/{id}/versions/{version}/slides", AuthMiddleware(handleVersionSlides))
+	http.HandleFunc("GET /layouts", AuthMiddleware(handleLayouts))
 	http.HandleFunc("/about", AuthMiddleware(handleAbout))
 	http.HandleFunc("/docs/toc", AuthMiddleware(handleDocsTOC))
 	http.HandleFunc("/docs/content", AuthMiddleware(handleDocsContent))
@@ -151,9 +203,11 @@ func main() {
 	http.HandleFunc("/set-language", handleSetLanguage)
 	http.HandleFunc("/reprocess", AuthMiddleware(handleReprocess))
 	http.HandleFunc("/reprocess-status", AuthMiddleware(handleReprocessStatus))
+	http.HandleFunc("GET /renderer/status", AuthMiddleware(handleRendererStatus))
 	http.HandleFunc("/search", AuthMiddleware(handleSearch))
 	http.HandleFunc("/search-settings", AuthMiddleware(handleSearchSettings))
 	http.HandleFunc("/events/logs", AuthMiddleware(handleEventsLogs))
+	http.HandleFunc("GET /events/jobs", AuthMiddleware(handleEventsJobs))
 
 	port := cfg.Application.Port
 	if port == 0 {
@@ -161,7 +215,25 @@ func main() {
 	}
 
 	fmt.Printf("SlideForge starting on http://localhost:%d\n", port)
-	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", port), nil))
+	// Requests share ctx, so the event streams end on shutdown
+	server := &http.Server{
+		Addr:        fmt.Sprintf(":%d", port),
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Println("Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("HTTP server shutdown: %v", err)
+	}
+	<-observerDone
 }
 
 func handleIndex(w http.ResponseWriter, r *http.Request) {
@@ -173,7 +245,20 @@ func handleIndex(w http.ResponseWriter, r *http.Request) {
 }
 
 func handleDashboard(w http.ResponseWriter, r *http.Request) {
-	files, err := database.GetAllPPTX(sqlDB)
+	// Library filter: ?author=...&folder=client/project&modified_from=2025-01-01&modified_to=2025-03-31
+	q := r.URL.Query()
+	filter := database.PPTXFilter{
+		Author: strings.TrimSpace(q.Get("author")),
+		Folder: strings.Trim(strings.TrimSpace(q.Get("folder")), "/"),
+	}
+	if t, err := time.Parse("2006-01-02", q.Get("modified_from")); err == nil {
+		filter.ModifiedFrom = &t
+	}
+	if t, err := time.Parse("2006-01-02", q.Get("modified_to")); err == nil {
+		t = t.AddDate(0, 0, 1) // inclusive
+		filter.ModifiedTo = &t
+	}
+	files, err := database.FindPPTX(sqlDB, filter)
 	if err != nil {
 		log.Printf("Failed to get files: %v", err)
 	}
@@ -181,11 +266,30 @@ func handleDashboard(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		log.Printf("Failed to get slide count: %v", err)
 	}
+	folders, err := database.GetPPTXFolders(sqlDB)
+	if err != nil {
+		log.Printf("Failed to get folders: %v", err)
+	}
+
+	// Folder tree as select options: each level indented under its parent
+	type folderOption struct{ Path, Label string }
+	folderOptions := make([]folderOption, len(folders))
+	for i, f := range folders {
+		depth := strings.Count(f, "/")
+		folderOptions[i] = folderOption{Path: f, Label: strings.Repeat("\u00a0\u00a0", depth) + path.Base(f)}
+	}
 
 	data := getBaseData(r, "Dashboard", "dashboard")
 	data["Files"] = files
+	data["Folders"] = folderOptions
+	data["FilterFolder"] = filter.Folder
+	data["FilterAuthor"] = filter.Author
+	data["FilterModifiedFrom"] = q.Get("modified_from")
+	data["FilterModifiedTo"] = q.Get("modified_to")
 	data["SlideCount"] = slideCount
 	data["IsProcessing"] = obs.IsProcessing()
+	data["Renderer"] = renderer.Default().Stats()
+	data["RendererEngine"] = pptx.DefaultRenderer().Name()
 
 	// Load settings
 	var simThreshold, wordSimThreshold float64
@@ -215,7 +319,9 @@ func handleReprocessStatus(w http.ResponseWriter, r *http.Request) {
 
 func handleSearch(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query().Get("q")
-	mode := r.URL.Query().Get("mode") // fts, similarity, word_similarity
+	mode := r.URL.Query().Get("mode") // fts, similarity, word_similarity, table, link
+	// Folder facet: limits results to a folder and its subfolders
+	folder := strings.Trim(r.URL.Query().Get("folder"), "/")
 	lang := i18n.GetLang(r)
 
 	if query == "" {
@@ -233,6 +339,9 @@ func handleSearch(w http.ResponseWriter, r *http.Request) {
 
 	var rows *sql.Rows
 	var err error
+	folderArgs := []interface{}{folder, database.FolderPattern(folder)}
+	// Slides of the current version of each deck, in the folder facet
+	const inLibrary = "s.version_id = f.current_version_id AND ($2 = '' OR f.folder = $2 OR f.folder LIKE $3)"
 
 	sqlDB.Exec("SET search_path TO slideforge, public")
 
@@ -243,17 +352,39 @@ func handleSearch(w http.ResponseWriter, r *http.Request) {
 			SELECT s.id, s.pptx_file_id, s.slide_number, s.png_path, s.content, f.filename, s.content as snippet, s.title
 			FROM collected_slides s
 			JOIN pptx_files f ON s.pptx_file_id = f.id
-			WHERE s.content % $1
+			WHERE s.content % $1 AND `+inLibrary+`
 			ORDER BY similarity(s.content, $1) DESC
-			LIMIT 20`, query)
+			LIMIT 20`, append([]interface{}{query}, folderArgs...)...)
 	case "word_similarity":
 		rows, err = sqlDB.Query(`
 			SELECT s.id, s.pptx_file_id, s.slide_number, s.png_path, s.content, f.filename, s.content as snippet, s.title
 			FROM collected_slides s
 			JOIN pptx_files f ON s.pptx_file_id = f.id
-			WHERE s.content %> $1
+			WHERE s.content %> $1 AND `+inLibrary+`
 			ORDER BY s.content <<-> $1
-			LIMIT 20`, query)
+			LIMIT 20`, append([]interface{}{query}, folderArgs...)...)
+	case "table":
+		// Slides with a table whose header row contains the query
+		rows, err = sqlDB.Query(`
+			SELECT DISTINCT ON (s.id) s.id, s.pptx_file_id, s.slide_number, s.png_path, s.content, f.filename,
+			       (SELECT string_agg(h, ' | ') FROM jsonb_array_elements_text(t->'header') h) as snippet, s.title
+			FROM collected_slides s
+			JOIN pptx_files f ON s.pptx_file_id = f.id
+			CROSS JOIN LATERAL jsonb_array_elements(COALESCE(s.style_info->'tables', '[]'::jsonb)) t
+			WHERE EXISTS (SELECT 1 FROM jsonb_array_elements_text(t->'header') h WHERE h ILIKE '%' || $1 || '%') AND `+inLibrary+`
+			ORDER BY s.id DESC
+			LIMIT 20`, append([]interface{}{query}, folderArgs...)...)
+	case "link":
+		// Slides with a hyperlink whose URL contains the query
+		rows, err = sqlDB.Query(`
+			SELECT DISTINCT ON (s.id) s.id, s.pptx_file_id, s.slide_number, s.png_path, s.content, f.filename,
+			       l->>'url' as snippet, s.title
+			FROM collected_slides s
+			JOIN pptx_files f ON s.pptx_file_id = f.id
+			CROSS JOIN LATERAL jsonb_array_elements(COALESCE(s.style_info->'links', '[]'::jsonb)) l
+			WHERE l->>'url' ILIKE '%' || $1 || '%' AND `+inLibrary+`
+			ORDER BY s.id DESC
+			LIMIT 20`, append([]interface{}{query}, folderArgs...)...)
 	default: // FTS
 		ftsCol := "fts_combined"
 		config := "english"
@@ -267,13 +398,13 @@ func handleSearch(w http.ResponseWriter, r *http.Request) {
 
 		rows, err = sqlDB.Query(fmt.Sprintf(`
 			SELECT s.id, s.pptx_file_id, s.slide_number, s.png_path, s.content, f.filename,
-			       ts_headline('%s', s.content, websearch_to_tsquery('%s', $1), 'StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15') as snippet, s.title
+			       ts_headline('%s', concat_ws(' ', s.content, s.notes), websearch_to_tsquery('%s', $1), 'StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15') as snippet, s.title
 			FROM collected_slides s
 			JOIN pptx_files f ON s.pptx_file_id = f.id
-			WHERE s.%s @@ websearch_to_tsquery('%s', $1) OR f.%s @@ websearch_to_tsquery('%s', $1)
+			WHERE (s.%s @@ websearch_to_tsquery('%s', $1) OR f.%s @@ websearch_to_tsquery('%s', $1)) AND `+inLibrary+`
 			ORDER BY ts_rank_cd(s.%s, websearch_to_tsquery('%s', $1)) * 0.4 + 
 			         ts_rank_cd(f.%s, websearch_to_tsquery('%s', $1)) * 0.6 DESC
-			LIMIT 20`, config, config, ftsCol, config, ftsCol, config, ftsCol, config, ftsCol, config), query)
+			LIMIT 20`, config, config, ftsCol, config, ftsCol, config, ftsCol, config, ftsCol, config), append([]interface{}{query}, folderArgs...)...)
 	}
 
 	if err != nil {
@@ -288,6 +419,9 @@ func handleSearch(w http.ResponseWriter, r *http.Request) {
 		var id, fileID, slideNum int
 		var pngPath, content, filename, snippet, title string
 		rows.Scan(&id, &fileID, &slideNum, &pngPath, &content, &filename, &snippet, &title)
+		if mode == "table" || mode == "link" {
+			snippet = template.HTMLEscapeString(snippet)
+		}
 		results = append(results, map[string]interface{}{
 			"ID":          id,
 			"FileID":      fileID,
@@ -310,7 +444,7 @@ func handleSearch(w http.ResponseWriter, r *http.Request) {
 	<div class='search-results-grid'>
 		{{range .Results}}
 		<div class='search-result-card' onclick="window.location='/selection?fileID={{.FileID}}'">
-			<img src='{{.PNGPath}}' loading='lazy'>
+			<img src='{{.PNGPath}}?size=thumb' loading='lazy'>
 			<div class='result-info'>
 				<strong>{{stripExt .Filename}}</strong> - {{ if .Title }}{{ .Title }}{{ else }}Slide {{.SlideNumber}}{{ end }}
 				<p class='content-snippet'>{{.Snippet}}</p>
@@ -353,6 +487,8 @@ func renderTemplate(w http.ResponseWriter, name string, data interface{}) {
 	}
 }
 
+// handleUpload saves an uploaded deck into the stage directory and queues it;
+// the observer ingests it like any other staged file.
 func handleUpload(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -366,109 +502,50 @@ func handleUpload(w http.ResponseWriter, r *http.Request) {
 	}
 	defer file.Close()
 
-	destPath := filepath.Join("uploads", header.Filename)
-	dest, err := os.Create(destPath)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	filename := filepath.Base(header.Filename)
+	if !strings.EqualFold(filepath.Ext(filename), ".pptx") {
+		http.Error(w, "Only .pptx files can be uploaded", http.StatusBadRequest)
 		return
 	}
-	defer dest.Close()
-	io.Copy(dest, file)
-
-	// Process PPTX to PNGs
-	thumbDir := filepath.Join("thumbnails", header.Filename)
-	pngFiles, err := pptx.ExtractSlidesToPNG(destPath, thumbDir)
-	if err != nil {
-		log.Printf("PNG extraction failed: %v", err)
+	stageDir := cfg.Application.Storage.Stage
+	if stageDir == "" {
+		http.Error(w, "Stage storage directory not configured", http.StatusInternalServerError)
+		return
 	}
-
-	// Extract Slide Content (Text & Styles)
-	slideDataMap, err := pptx.ExtractSlideContent(destPath)
-	if err != nil {
-		log.Printf("Failed to extract slide content: %v", err)
+	destPath := filepath.Join(stageDir, filename)
+	if _, err := os.Stat(destPath); err == nil {
+		http.Error(w, filename+" is already staged", http.StatusConflict)
+		return
 	}
 
-	// Insert File into DB
-	fileID, err := database.SavePPTXMetadata(sqlDB, &database.PPTXFile{
-		Filename:         header.Filename,
-		OriginalFilePath: destPath,
-		ThumbnailDirPath: thumbDir,
-		Metadata:         []byte("{}"),
-		AISummary:        "", // Will be updated later
-	})
+	// Write under a name the watcher ignores, then move it in place, so the
+	// deck is never seen half-written
+	tmp, err := os.CreateTemp(stageDir, ".upload-*")
 	if err != nil {
-		log.Printf("DB insert failed: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
-
-	var slideSummaries []string
-	ctx := r.Context()
-
-	// Save individual slides
-	for i, png := range pngFiles {
-		slideNum := i + 1
-		content := ""
-		styleJSON := []byte("{}")
-		slideSummary := ""
-		slideTitle := fmt.Sprintf("Slide %d", slideNum) // Default
-
-		if data, ok := slideDataMap[slideNum]; ok {
-			content = data.Text
-			if sj, err := json.Marshal(data.Styles); err == nil {
-				styleJSON = sj
-			}
-
-			// Generate slide summary & title
-			if content != "" {
-				// Summary
-				summary, err := aiClient.SummarizeText(ctx, content)
-				if err == nil {
-					slideSummary = summary
-					slideSummaries = append(slideSummaries, summary)
-				}
-
-				// Title
-				rawTitle, err := aiClient.ExtractSlideTitle(ctx, content)
-				if err == nil && rawTitle != "" {
-					slideTitle = fmt.Sprintf("%d. %s", slideNum, rawTitle)
-				}
-			}
-		}
-
-		err = database.SaveSlide(sqlDB, &database.Slide{
-			PPTXFileID: fileID,
-			SlideNum:   slideNum,
-			PNGPath:    "/" + png, // Web accessible path
-			Content:    content,
-			StyleInfo:  styleJSON,
-			AIAnalysis: []byte("{}"),
-			AISummary:  slideSummary,
-			Title:      slideTitle,
-		})
-		if err != nil {
-			log.Printf("Failed to save slide %d: %v", slideNum, err)
-		}
+	_, err = io.Copy(tmp, file)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), destPath)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		log.Printf("Failed to stage upload %s: %v", filename, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
 
-	// Generate overall summary & title
-	if len(slideSummaries) > 0 {
-		// Summary
-		fullTextForSummary := strings.Join(slideSummaries, "\n")
-		overallSummary, err := aiClient.SummarizeText(ctx, "Provide a concise summary of this presentation based on its slide summaries: \n"+fullTextForSummary)
-		if err == nil {
-			database.UpdatePPTXSummary(sqlDB, fileID, overallSummary)
-		}
-
-		// Title (from first slide)
-		if data, ok := slideDataMap[1]; ok && data.Text != "" {
-			title, err := aiClient.ExtractTitle(ctx, data.Text)
-			if err == nil && title != "" {
-				database.UpdatePPTXTitle(sqlDB, fileID, title)
-			}
-		}
+	if _, err := obs.Enqueue(destPath); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to queue %s: %v", filename, err), http.StatusInternalServerError)
+		return
 	}
 
-	// Redirect to selection view for this file
-	http.Redirect(w, r, fmt.Sprintf("/selection?fileID=%d", fileID), http.StatusSeeOther)
+	// The dashboard shows the job's progress
+	http.Redirect(w, r, "/dashboard", http.StatusSeeOther)
 }
 
 func handleSelection(w http.ResponseWriter, r *http.Request) {
@@ -476,15 +553,25 @@ func handleSelection(w http.ResponseWriter, r *http.Request) {
 	var fileID int
 	fmt.Sscanf(fileIDStr, "%d", &fileID)
 
-	slides, err := database.GetSlidesByFile(sqlDB, fileID)
-	if err != nil {
+	// ?version=N shows an earlier version of the deck
+	slides, version, err := deckSlides(fileID, r.URL.Query().Get("version"))
+	if err == sql.ErrNoRows {
+		http.NotFound(w, r)
+		return
+	} else if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	versions, err := database.GetPPTXVersions(sqlDB, fileID)
+	if err != nil {
+		log.Printf("Failed to get versions: %v", err)
+	}
 
 	data := getBaseData(r, "Slide Selection", "dashboard")
 	data["slides"] = slides
 	data["FileID"] = fileID
+	data["Versions"] = versions
+	data["Version"] = version
 
 	// Fetch PPTX summary
 	var pptxSummary string
@@ -516,7 +603,7 @@ func handleAnalyze(w http.ResponseWriter, r *http.Request) {
 	var fileID int
 	fmt.Sscanf(fileIDStr, "%d", &fileID)
 
-	slides, err := database.GetSlidesByFile(sqlDB, fileID)
+	slides, _, err := deckSlides(fileID, r.FormValue("version"))
 	if err != nil {
 		w.Write([]byte(fmt.Sprintf("<p class='text-danger'>Error fetching slides: %v</p>", err)))
 		return
@@ -825,3 +912,85 @@ func handleEventsLogs(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 }
+
+// jobClient is a /events/jobs stream. gone is closed when the client is
+// dropped for falling behind.
+type jobClient struct {
+	events chan observer.Progress
+	gone   chan struct{}
+}
+
+func processProgress() {
+	for p := range progressChan {
+		// Remember the state of unfinished jobs for new clients
+		jobMutex.Lock()
+		if p.Type == observer.EventDone || p.Type == observer.EventFailed {
+			delete(activeJobs, p.JobID)
+		} else if p.Type == observer.EventProgress {
+			activeJobs[p.JobID] = p
+		}
+
+		// Broadcast to SSE clients. A client that fell behind is
+		// disconnected rather than skipped, so that it never misses the end
+		// of a job: it reconnects and gets the unfinished jobs again.
+		for c := range jobClients {
+			select {
+			case c.events <- p:
+			default:
+				delete(jobClients, c)
+				close(c.gone)
+			}
+		}
+		jobMutex.Unlock()
+	}
+}
+
+// handleEventsJobs streams ingest progress as typed SSE events (progress,
+// warning, done, failed) with an observer.Progress JSON payload. A new
+// client first gets the latest progress of every unfinished job.
+// GET /events/jobs
+func handleEventsJobs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	client := &jobClient{events: make(chan observer.Progress, 50), gone: make(chan struct{})}
+
+	jobMutex.Lock()
+	jobClients[client] = true
+	pending := make([]observer.Progress, 0, len(activeJobs))
+	for _, p := range activeJobs {
+		pending = append(pending, p)
+	}
+	jobMutex.Unlock()
+
+	defer func() {
+		jobMutex.Lock()
+		delete(jobClients, client)
+		jobMutex.Unlock()
+	}()
+
+	sort.Slice(pending, func(i, j int) bool { return pending[i].JobID < pending[j].JobID })
+	for _, p := range pending {
+		writeProgressEvent(w, p)
+	}
+	w.(http.Flusher).Flush()
+
+	ctx := r.Context()
+	for {
+		select {
+		case p := <-client.events:
+			writeProgressEvent(w, p)
+			w.(http.Flusher).Flush()
+		case <-client.gone:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func writeProgressEvent(w http.ResponseWriter, p observer.Progress) {
+	data, _ := json.Marshal(p)
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", p.Type, data)
+}
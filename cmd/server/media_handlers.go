@@ -0,0 +1,32 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gnemet/SlideForge/internal/database"
+)
+
+var sha256Regex = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// handleMediaSlides lists the slides of all decks that use the media part with
+// the given SHA-256, e.g. to find every deck still using an old logo.
+// GET /media/{sha256}/slides
+func handleMediaSlides(w http.ResponseWriter, r *http.Request) {
+	hash := strings.ToLower(r.PathValue("sha256"))
+	if !sha256Regex.MatchString(hash) {
+		http.Error(w, "Invalid SHA-256", http.StatusBadRequest)
+		return
+	}
+
+	usages, err := database.FindMediaUsages(sqlDB, hash)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(usages)
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gnemet/SlideForge/internal/database"
+	"github.com/gnemet/SlideForge/internal/i18n"
+	"github.com/gnemet/SlideForge/internal/pptx"
+	"github.com/gnemet/SlideForge/internal/renderer"
+)
+
+// maxRenderDPI bounds the resolution of on-demand renders.
+const maxRenderDPI = 600
+
+// handleDeckRender re-renders the thumbnails and renditions of a slide or
+// page range of a deck with the default renderer (LibreOffice reuses the
+// cached PDF of the file). Page N is slide N.
+// POST /pptx/{id}/render?pages=3-5&dpi=300
+func handleDeckRender(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid file id", http.StatusBadRequest)
+		return
+	}
+	first, last, err := parsePageRange(r.URL.Query().Get("pages"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	dpi := pptx.DefaultDPI
+	if v := r.URL.Query().Get("dpi"); v != "" {
+		dpi, err = strconv.Atoi(v)
+		if err != nil || dpi < 1 || dpi > maxRenderDPI {
+			http.Error(w, fmt.Sprintf("dpi must be between 1 and %d", maxRenderDPI), http.StatusBadRequest)
+			return
+		}
+	}
+
+	file, err := database.GetPPTXByID(sqlDB, id)
+	if err == sql.ErrNoRows {
+		http.NotFound(w, r)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	opts := pptx.RenderOptions{CacheDir: cfg.Application.Storage.PDFCache, FirstPage: first, LastPage: last, DPI: dpi}
+	files, err := pptx.DefaultRenderer().RenderSlides(r.Context(), file.OriginalFilePath, deckThumbnailDir(file), opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	urls := make([]string, len(files))
+	for i, f := range files {
+		urls[i] = thumbnailURL(f)
+	}
+
+	// Renditions of the same pages; their records are updated in place.
+	var renditionSpecs []pptx.Rendition
+	for _, rc := range cfg.Renditions {
+		renditionSpecs = append(renditionSpecs, pptx.Rendition(rc))
+	}
+	rendered, err := pptx.RenderRenditions(r.Context(), file.OriginalFilePath, deckThumbnailDir(file), opts, renditionSpecs)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	renditionsByPage := make(map[int][]database.SlideRendition)
+	for _, img := range rendered {
+		renditionsByPage[img.Page] = append(renditionsByPage[img.Page], database.SlideRendition{
+			Name:      img.Name,
+			Format:    img.Format,
+			Path:      thumbnailURL(img.Path),
+			Width:     img.Width,
+			Height:    img.Height,
+			SizeBytes: img.Size,
+			SHA256:    img.SHA256,
+		})
+	}
+	slides, err := database.GetSlidesByFile(sqlDB, file.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for i := range slides {
+		page := pptx.ThumbnailPage(slides[i].PNGPath)
+		if len(renditionsByPage[page]) == 0 {
+			continue
+		}
+		if err := database.SaveSlideRenditions(sqlDB, &slides[i], renditionsByPage[page]); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"renderer": pptx.DefaultRenderer().Name(), "dpi": dpi, "files": urls, "renditions": len(rendered)})
+}
+
+// parsePageRange parses "3" or "3-5"; an empty range means all pages (0, 0).
+func parsePageRange(s string) (int, int, error) {
+	if s == "" {
+		return 0, 0, nil
+	}
+	from, to, isRange := strings.Cut(s, "-")
+	first, err := strconv.Atoi(strings.TrimSpace(from))
+	if err != nil || first < 1 {
+		return 0, 0, fmt.Errorf("invalid page range %q", s)
+	}
+	last := first
+	if isRange {
+		last, err = strconv.Atoi(strings.TrimSpace(to))
+		if err != nil || last < first {
+			return 0, 0, fmt.Errorf("invalid page range %q", s)
+		}
+	}
+	return first, last, nil
+}
+
+// deckThumbnailDir resolves the thumbnail dir of a file: the observer stores
+// it relative to the thumbnails storage, uploads relative to the working dir.
+func deckThumbnailDir(f *database.PPTXFile) string {
+	dir := filepath.Join(cfg.Application.Storage.Thumbnails, f.ThumbnailDirPath)
+	if info, err := os.Stat(dir); err == nil && info.IsDir() {
+		return dir
+	}
+	return f.ThumbnailDirPath
+}
+
+// thumbnailURL maps a file in the thumbnails storage to its /thumbnails/ URL.
+func thumbnailURL(path string) string {
+	if rel, err := filepath.Rel(cfg.Application.Storage.Thumbnails, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return "/thumbnails/" + filepath.ToSlash(rel)
+	}
+	return "/" + filepath.ToSlash(path)
+}
+
+// handleRendererStatus renders the LibreOffice pool counters for the
+// dashboard card, polled by htmx.
+func handleRendererStatus(w http.ResponseWriter, r *http.Request) {
+	lang := i18n.GetLang(r)
+	st := renderer.Default().Stats()
+	failStyle := ""
+	if st.Failed > 0 {
+		failStyle = " style='color: var(--danger-color);'"
+	}
+	fmt.Fprintf(w, "<div class='stat-value'>%d <small class='text-muted'>/ %d/%d</small></div>", st.Queued, st.Active, st.Workers)
+	fmt.Fprintf(w, "<div class='stat-label'>%s (%s)</div>", i18n.T(lang, "renderer_queue"), pptx.DefaultRenderer().Name())
+	fmt.Fprintf(w, "<small class='text-muted'%s>%s: %d (%s: %d, %s: %d)</small>", failStyle,
+		i18n.T(lang, "renderer_failures"), st.Failed, i18n.T(lang, "renderer_timeouts"), st.Timeouts, i18n.T(lang, "renderer_retries"), st.Retries)
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gnemet/SlideForge/internal/database"
+	"github.com/gnemet/SlideForge/internal/pptx"
+)
+
+// handleSlideTableCSV exports the n-th (1-based) table of a collected slide as CSV.
+// GET /slides/{id}/tables/{n}/csv
+func handleSlideTableCSV(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid slide id", http.StatusBadRequest)
+		return
+	}
+	n, err := strconv.Atoi(r.PathValue("n"))
+	if err != nil || n < 1 {
+		http.Error(w, "Invalid table number", http.StatusBadRequest)
+		return
+	}
+
+	slide, err := database.GetSlideByID(sqlDB, id)
+	if err == sql.ErrNoRows {
+		http.NotFound(w, r)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var content pptx.JSONSlide
+	if err := json.Unmarshal(slide.StyleInfo, &content); err != nil {
+		http.Error(w, "Invalid slide structure: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if n > len(content.Tables) {
+		http.Error(w, fmt.Sprintf("Slide %d has %d table(s)", slide.SlideNum, len(content.Tables)), http.StatusNotFound)
+		return
+	}
+
+	filename := fmt.Sprintf("slide%d_table%d.csv", slide.SlideNum, n)
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	content.Tables[n-1].WriteCSV(w)
+}
+
+// handleSlideOutline returns a collected slide as a Markdown outline.
+// GET /slides/{id}/outline
+func handleSlideOutline(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid slide id", http.StatusBadRequest)
+		return
+	}
+
+	slide, err := database.GetSlideByID(sqlDB, id)
+	if err == sql.ErrNoRows {
+		http.NotFound(w, r)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var content pptx.JSONSlide
+	if err := json.Unmarshal(slide.StyleInfo, &content); err != nil {
+		http.Error(w, "Invalid slide structure: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	content.Index = slide.SlideNum
+
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	w.Write([]byte(content.Markdown()))
+}
+
+// handleDeckOutline returns all collected slides of a PPTX as one Markdown outline.
+// GET /pptx/{id}/outline
+func handleDeckOutline(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid file id", http.StatusBadRequest)
+		return
+	}
+
+	file, err := database.GetPPTXByID(sqlDB, id)
+	if err == sql.ErrNoRows {
+		http.NotFound(w, r)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	slides, err := database.GetSlidesByFile(sqlDB, id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	title := file.Title
+	if title == "" {
+		title = file.Filename
+	}
+	var out strings.Builder
+	out.WriteString("# " + title + "\n\n")
+	for _, s := range slides {
+		var content pptx.JSONSlide
+		if err := json.Unmarshal(s.StyleInfo, &content); err != nil {
+			continue
+		}
+		content.Index = s.SlideNum
+		out.WriteString(content.Markdown())
+	}
+
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", strings.TrimSuffix(file.Filename, filepath.Ext(file.Filename))+".md"))
+	w.Write([]byte(out.String()))
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gnemet/SlideForge/internal/database"
+	"github.com/gnemet/SlideForge/internal/pptx"
+)
+
+// handleTemplateRender fills the {{tag}} placeholders of a stored template
+// with the JSON payload and returns the rendered PPTX.
+// POST /templates/{id}/render
+func handleTemplateRender(w http.ResponseWriter, r *http.Request) {
+	file, ok := loadTemplateFile(w, r)
+	if !ok {
+		return
+	}
+
+	var data map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		http.Error(w, "Invalid JSON payload: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := pptx.RenderTemplate(&buf, file.OriginalFilePath, data); err != nil {
+		log.Printf("Template render failed for %s: %v", file.Filename, err)
+		http.Error(w, "Template render failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	stem := strings.TrimSuffix(file.Filename, filepath.Ext(file.Filename))
+	writePPTXDownload(w, stem+"_rendered.pptx", buf.Bytes())
+}
+
+// handleTemplateValidate checks a JSON payload against the template's schema
+// and reports missing or mistyped fields. Responds 422 when the payload is invalid.
+// POST /templates/{id}/validate
+func handleTemplateValidate(w http.ResponseWriter, r *http.Request) {
+	file, ok := loadTemplateFile(w, r)
+	if !ok {
+		return
+	}
+
+	var data map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		http.Error(w, "Invalid JSON payload: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	schema, err := templateJSONSchema(file)
+	if err != nil {
+		log.Printf("Template schema unavailable for %s: %v", file.Filename, err)
+		http.Error(w, "Template schema unavailable: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	errs := schema.Validate(data)
+	w.Header().Set("Content-Type", "application/json")
+	if len(errs) > 0 {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}
+	json.NewEncoder(w).Encode(map[string]any{
+		"valid":  len(errs) == 0,
+		"errors": errs,
+	})
+}
+
+// templateJSONSchema returns the stored schema of a template, inferring it
+// from the file for rows ingested before schemas were stored.
+func templateJSONSchema(file *database.PPTXFile) (*pptx.JSONSchema, error) {
+	var schema pptx.JSONSchema
+	if len(file.TemplateSchema) > 0 {
+		if err := json.Unmarshal(file.TemplateSchema, &schema); err != nil {
+			return nil, err
+		}
+		if len(schema.Type) > 0 {
+			return &schema, nil
+		}
+	}
+
+	extracted, err := pptx.ExtractTemplateSchema(file.OriginalFilePath)
+	if err != nil {
+		return nil, err
+	}
+	return extracted.JSONSchema(), nil
+}
+
+// loadTemplateFile looks up the pptx_files row addressed by the {id} path value.
+func loadTemplateFile(w http.ResponseWriter, r *http.Request) (*database.PPTXFile, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid template id", http.StatusBadRequest)
+		return nil, false
+	}
+
+	file, err := database.GetPPTXByID(sqlDB, id)
+	if err == sql.ErrNoRows {
+		http.NotFound(w, r)
+		return nil, false
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	return file, true
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gnemet/SlideForge/internal/pptx"
+)
+
+// thumbnailETags caches the checksums of served images by path; an entry is
+// valid while the file's modification time and size are unchanged.
+var thumbnailETags sync.Map
+
+type thumbnailETag struct {
+	modTime time.Time
+	size    int64
+	etag    string
+}
+
+// handleThumbnail serves slide images from the thumbnails storage. With
+// ?size=thumb|preview|full or ?w=<pixels> it serves the matching rendition of
+// a slide-NNNN.png in the best format the client accepts (WebP when the
+// Accept header allows it), falling back to the original PNG. Responses carry
+// an ETag from the file's SHA-256, so browsers revalidate with a 304.
+// GET /thumbnails/{path...}
+func handleThumbnail(w http.ResponseWriter, r *http.Request) {
+	root := cfg.Application.Storage.Thumbnails
+	rel := path.Clean("/" + r.PathValue("path"))
+	file := filepath.Join(root, filepath.FromSlash(rel))
+
+	if spec, ok := requestedRendition(r); ok {
+		if page := pptx.ThumbnailPage(file); page > 0 {
+			for _, format := range acceptedFormats(r, spec.Formats) {
+				candidate := pptx.RenditionPath(filepath.Dir(file), page, spec.Name, format)
+				if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+					file = candidate
+					break
+				}
+			}
+		}
+		w.Header().Add("Vary", "Accept")
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+
+	if etag, err := fileETag(file, info); err == nil {
+		w.Header().Set("ETag", etag)
+	}
+	w.Header().Set("Cache-Control", "public, max-age=60, must-revalidate")
+	http.ServeContent(w, r, filepath.Base(file), info.ModTime(), f)
+}
+
+// requestedRendition picks the configured rendition for ?size=<name>, or for
+// ?w=<pixels> the smallest one at least that wide (the largest if none is).
+func requestedRendition(r *http.Request) (pptx.Rendition, bool) {
+	renditions := pptx.DefaultRenditions
+	if len(cfg.Renditions) > 0 {
+		renditions = make([]pptx.Rendition, len(cfg.Renditions))
+		for i, rc := range cfg.Renditions {
+			renditions[i] = pptx.Rendition(rc)
+		}
+	}
+
+	if name := r.URL.Query().Get("size"); name != "" {
+		for _, spec := range renditions {
+			if spec.Name == name {
+				return spec, true
+			}
+		}
+		return pptx.Rendition{}, false
+	}
+
+	want, err := strconv.Atoi(r.URL.Query().Get("w"))
+	if err != nil || want <= 0 {
+		return pptx.Rendition{}, false
+	}
+	// A rendition rendered at a DPI (width 0) is the full size, wider than
+	// any fixed width.
+	width := func(spec *pptx.Rendition) int {
+		if spec.Width == 0 {
+			return math.MaxInt
+		}
+		return spec.Width
+	}
+	var best, largest *pptx.Rendition
+	for i := range renditions {
+		spec := &renditions[i]
+		if width(spec) >= want && (best == nil || width(spec) < width(best)) {
+			best = spec
+		}
+		if largest == nil || width(spec) > width(largest) {
+			largest = spec
+		}
+	}
+	if best == nil {
+		best = largest
+	}
+	if best == nil {
+		return pptx.Rendition{}, false
+	}
+	return *best, true
+}
+
+// acceptedFormats filters a rendition's formats, in order of preference, to
+// those the client accepts. WebP is only served when asked for explicitly.
+func acceptedFormats(r *http.Request, formats []string) []string {
+	accept := r.Header.Get("Accept")
+	var out []string
+	for _, f := range formats {
+		if f == "webp" && !strings.Contains(accept, "image/webp") {
+			continue
+		}
+		out = append(out, f)
+	}
+	return out
+}
+
+// fileETag returns the quoted SHA-256 of a file, computed once per version.
+func fileETag(file string, info os.FileInfo) (string, error) {
+	if v, ok := thumbnailETags.Load(file); ok {
+		e := v.(thumbnailETag)
+		if e.modTime.Equal(info.ModTime()) && e.size == info.Size() {
+			return e.etag, nil
+		}
+	}
+	sum, err := pptx.FileChecksum(file)
+	if err != nil {
+		return "", err
+	}
+	etag := fmt.Sprintf("%q", sum)
+	thumbnailETags.Store(file, thumbnailETag{modTime: info.ModTime(), size: info.Size(), etag: etag})
+	return etag, nil
+}
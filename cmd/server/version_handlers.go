@@ -0,0 +1,72 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gnemet/SlideForge/internal/database"
+)
+
+// handleDeckVersions lists the versions of a presentation, newest first.
+// GET /pptx/{id}/versions
+func handleDeckVersions(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid file id", http.StatusBadRequest)
+		return
+	}
+
+	versions, err := database.GetPPTXVersions(sqlDB, id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(versions)
+}
+
+// handleVersionSlides lists the slides of a version of a presentation. Their
+// IDs can be generated from (/generate, /export/pdf) like current slides.
+// GET /pptx/{id}/versions/{version}/slides
+func handleVersionSlides(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid file id", http.StatusBadRequest)
+		return
+	}
+
+	slides, _, err := deckSlides(id, r.PathValue("version"))
+	if err == sql.ErrNoRows {
+		http.NotFound(w, r)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(slides)
+}
+
+// deckSlides loads the slides of a version of a file by its number, or of the
+// current version when version is empty; the version is nil then.
+func deckSlides(fileID int, version string) ([]database.Slide, *database.PPTXVersion, error) {
+	if version == "" {
+		slides, err := database.GetSlidesByFile(sqlDB, fileID)
+		return slides, nil, err
+	}
+	n, err := strconv.Atoi(version)
+	if err != nil || n < 1 {
+		return nil, nil, fmt.Errorf("invalid version %q", version)
+	}
+	v, err := database.GetPPTXVersion(sqlDB, fileID, n)
+	if err != nil {
+		return nil, nil, err
+	}
+	slides, err := database.GetSlidesByVersion(sqlDB, v.ID)
+	return slides, v, err
+}
@@ -0,0 +1,6 @@
+-- Migration to store the inferred JSON Schema of template payloads
+-- PostgreSQL 18
+SET search_path TO slideforge,
+    public;
+ALTER TABLE pptx_files
+ADD COLUMN IF NOT EXISTS template_schema JSONB DEFAULT '{}'::jsonb;
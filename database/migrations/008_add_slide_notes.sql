@@ -0,0 +1,36 @@
+-- Migration to add speaker notes to collected_slides and index them for search
+-- PostgreSQL 18
+SET search_path TO slideforge,
+    public;
+ALTER TABLE collected_slides
+ADD COLUMN IF NOT EXISTS notes TEXT DEFAULT '';
+-- Notes are searchable but rank below the slide text
+CREATE OR REPLACE FUNCTION update_slide_search_vectors() RETURNS trigger AS $$ BEGIN NEW.fts_en := setweight(
+        to_tsvector('english', coalesce(NEW.content, '')),
+        'A'
+    ) || setweight(
+        to_tsvector('english', coalesce(NEW.notes, '')),
+        'C'
+    );
+NEW.fts_hu := setweight(
+    to_tsvector('hungarian', unaccent(coalesce(NEW.content, ''))),
+    'A'
+) || setweight(
+    to_tsvector('hungarian', unaccent(coalesce(NEW.notes, ''))),
+    'C'
+);
+NEW.fts_combined := setweight(
+    to_tsvector('english', coalesce(NEW.content, '')),
+    'A'
+) || setweight(
+    to_tsvector('hungarian', unaccent(coalesce(NEW.content, ''))),
+    'A'
+) || setweight(
+    to_tsvector('english', coalesce(NEW.notes, '')),
+    'C'
+) || setweight(
+    to_tsvector('hungarian', unaccent(coalesce(NEW.notes, ''))),
+    'C'
+);
+RETURN NEW;
+END $$ LANGUAGE plpgsql;
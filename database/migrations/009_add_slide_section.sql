@@ -0,0 +1,8 @@
+-- Migration to store the section and hidden flag of collected slides
+-- PostgreSQL 18
+SET search_path TO slideforge,
+    public;
+ALTER TABLE collected_slides
+ADD COLUMN IF NOT EXISTS section TEXT DEFAULT '';
+ALTER TABLE collected_slides
+ADD COLUMN IF NOT EXISTS is_hidden BOOLEAN DEFAULT FALSE;
@@ -0,0 +1,22 @@
+-- Migration to inventory the pictures, videos and audio used by each slide
+-- PostgreSQL 18
+SET search_path TO slideforge,
+    public;
+CREATE TABLE IF NOT EXISTS slide_media (
+    id SERIAL PRIMARY KEY,
+    slide_id INTEGER NOT NULL REFERENCES collected_slides(id) ON DELETE CASCADE,
+    pptx_file_id INTEGER NOT NULL REFERENCES pptx_files(id) ON DELETE CASCADE,
+    part_name TEXT NOT NULL,
+    -- e.g. ppt/media/image3.png
+    rel_id TEXT,
+    media_type TEXT NOT NULL,
+    -- image | video | audio
+    content_type TEXT,
+    size_bytes BIGINT,
+    sha256 TEXT NOT NULL,
+    width INTEGER,
+    height INTEGER,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_slide_media_sha256 ON slide_media (sha256);
+CREATE INDEX IF NOT EXISTS idx_slide_media_slide ON slide_media (slide_id);
@@ -0,0 +1,22 @@
+-- Migration to store the document properties (docProps/core.xml, docProps/app.xml) of presentations
+-- PostgreSQL 18
+SET search_path TO slideforge,
+    public;
+ALTER TABLE pptx_files
+ADD COLUMN IF NOT EXISTS author TEXT DEFAULT '';
+ALTER TABLE pptx_files
+ADD COLUMN IF NOT EXISTS last_modified_by TEXT DEFAULT '';
+ALTER TABLE pptx_files
+ADD COLUMN IF NOT EXISTS doc_created_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE pptx_files
+ADD COLUMN IF NOT EXISTS doc_modified_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE pptx_files
+ADD COLUMN IF NOT EXISTS company TEXT DEFAULT '';
+ALTER TABLE pptx_files
+ADD COLUMN IF NOT EXISTS revision INTEGER DEFAULT 0;
+ALTER TABLE pptx_files
+ADD COLUMN IF NOT EXISTS slide_count INTEGER DEFAULT 0;
+ALTER TABLE pptx_files
+ADD COLUMN IF NOT EXISTS application TEXT DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_pptx_files_author ON pptx_files (author);
+CREATE INDEX IF NOT EXISTS idx_pptx_files_doc_modified_at ON pptx_files (doc_modified_at);
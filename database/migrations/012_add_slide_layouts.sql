@@ -0,0 +1,23 @@
+-- Migration to catalog the slide layouts and masters of every presentation
+-- PostgreSQL 18
+SET search_path TO slideforge,
+    public;
+CREATE TABLE IF NOT EXISTS slide_layouts (
+    id SERIAL PRIMARY KEY,
+    pptx_file_id INTEGER NOT NULL REFERENCES pptx_files(id) ON DELETE CASCADE,
+    part_name TEXT NOT NULL,
+    -- e.g. ppt/slideLayouts/slideLayout2.xml
+    name TEXT DEFAULT '',
+    layout_type TEXT DEFAULT '',
+    master_part TEXT DEFAULT '',
+    master_name TEXT DEFAULT '',
+    placeholders JSONB DEFAULT '[]',
+    -- First slide of the deck using the layout, NULL when unused
+    thumbnail_path TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (pptx_file_id, part_name)
+);
+CREATE INDEX IF NOT EXISTS idx_slide_layouts_name ON slide_layouts (name);
+ALTER TABLE collected_slides
+ADD COLUMN IF NOT EXISTS layout_id INTEGER REFERENCES slide_layouts(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_collected_slides_layout ON collected_slides (layout_id);
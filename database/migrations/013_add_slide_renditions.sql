@@ -0,0 +1,21 @@
+-- Migration to record the image renditions (thumbnail, preview, full size) of each slide
+-- PostgreSQL 18
+SET search_path TO slideforge,
+    public;
+CREATE TABLE IF NOT EXISTS slide_renditions (
+    id SERIAL PRIMARY KEY,
+    slide_id INTEGER NOT NULL REFERENCES collected_slides(id) ON DELETE CASCADE,
+    pptx_file_id INTEGER NOT NULL REFERENCES pptx_files(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    -- e.g. thumb, preview, full
+    format TEXT NOT NULL,
+    -- webp, jpeg or png
+    path TEXT NOT NULL,
+    width INTEGER,
+    height INTEGER,
+    size_bytes BIGINT,
+    sha256 TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (slide_id, name, format)
+);
+CREATE INDEX IF NOT EXISTS idx_slide_renditions_file ON slide_renditions (pptx_file_id);
@@ -0,0 +1,23 @@
+-- Migration to queue ingest work in the database, so that a restart resumes unfinished files
+-- PostgreSQL 18
+SET search_path TO slideforge,
+    public;
+CREATE TABLE IF NOT EXISTS ingest_jobs (
+    id SERIAL PRIMARY KEY,
+    file_path TEXT NOT NULL,
+    state TEXT NOT NULL DEFAULT 'queued',
+    -- queued, rendering, extracting, summarizing, done or failed
+    attempts INTEGER NOT NULL DEFAULT 0,
+    error TEXT,
+    pptx_file_id INTEGER REFERENCES pptx_files(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    started_at TIMESTAMP WITH TIME ZONE,
+    finished_at TIMESTAMP WITH TIME ZONE,
+    CHECK (state IN ('queued', 'rendering', 'extracting', 'summarizing', 'done', 'failed'))
+);
+-- At most one unfinished job per file
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ingest_jobs_active_path ON ingest_jobs (file_path)
+WHERE state NOT IN ('done', 'failed');
+CREATE INDEX IF NOT EXISTS idx_ingest_jobs_queued ON ingest_jobs (id)
+WHERE state = 'queued';
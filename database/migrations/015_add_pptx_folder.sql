@@ -0,0 +1,8 @@
+-- Migration to record the stage subfolder a presentation was dropped into (e.g. client/project)
+-- PostgreSQL 18
+SET search_path TO slideforge,
+    public;
+ALTER TABLE pptx_files
+ADD COLUMN IF NOT EXISTS folder TEXT NOT NULL DEFAULT '';
+-- '' for the stage root; slash-separated, relative to the stage directory
+CREATE INDEX IF NOT EXISTS idx_pptx_files_folder ON pptx_files (folder text_pattern_ops);
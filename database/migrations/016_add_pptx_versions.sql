@@ -0,0 +1,72 @@
+-- Migration to keep every distinct checksum of a presentation as a version
+-- with its own slides and thumbnails
+-- PostgreSQL 18
+SET search_path TO slideforge,
+    public;
+CREATE TABLE IF NOT EXISTS pptx_versions (
+    id SERIAL PRIMARY KEY,
+    pptx_file_id INTEGER NOT NULL REFERENCES pptx_files(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    checksum TEXT NOT NULL DEFAULT '',
+    original_file_path TEXT,
+    thumbnail_dir_path TEXT,
+    title TEXT DEFAULT '',
+    author TEXT DEFAULT '',
+    last_modified_by TEXT DEFAULT '',
+    doc_modified_at TIMESTAMP WITH TIME ZONE,
+    revision INTEGER DEFAULT 0,
+    slide_count INTEGER DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (pptx_file_id, version)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_pptx_versions_checksum ON pptx_versions (checksum)
+WHERE checksum != '';
+ALTER TABLE pptx_files
+ADD COLUMN IF NOT EXISTS current_version_id INTEGER REFERENCES pptx_versions(id) ON DELETE SET NULL;
+ALTER TABLE collected_slides
+ADD COLUMN IF NOT EXISTS version_id INTEGER REFERENCES pptx_versions(id) ON DELETE CASCADE;
+CREATE INDEX IF NOT EXISTS idx_collected_slides_version ON collected_slides (version_id);
+-- Existing presentations become their version 1
+INSERT INTO pptx_versions (
+        pptx_file_id,
+        version,
+        checksum,
+        original_file_path,
+        thumbnail_dir_path,
+        title,
+        author,
+        last_modified_by,
+        doc_modified_at,
+        revision,
+        slide_count,
+        created_at
+    )
+SELECT f.id,
+    1,
+    COALESCE(f.checksum, ''),
+    f.original_file_path,
+    f.thumbnail_dir_path,
+    COALESCE(f.title, ''),
+    COALESCE(f.author, ''),
+    COALESCE(f.last_modified_by, ''),
+    f.doc_modified_at,
+    COALESCE(f.revision, 0),
+    COALESCE(f.slide_count, 0),
+    f.created_at
+FROM pptx_files f
+WHERE NOT EXISTS (
+        SELECT 1
+        FROM pptx_versions v
+        WHERE v.pptx_file_id = f.id
+    );
+UPDATE pptx_files f
+SET current_version_id = v.id
+FROM pptx_versions v
+WHERE v.pptx_file_id = f.id
+    AND v.version = 1
+    AND f.current_version_id IS NULL;
+UPDATE collected_slides s
+SET version_id = f.current_version_id
+FROM pptx_files f
+WHERE s.pptx_file_id = f.id
+    AND s.version_id IS NULL;
@@ -0,0 +1,9 @@
+-- Migration to drop stored template payload schemas so they are inferred
+-- again: plain tags accept booleans and loops nested in a slide loop are
+-- placed inside its items
+-- PostgreSQL 18
+SET search_path TO slideforge,
+    public;
+UPDATE pptx_files
+SET template_schema = '{}'::jsonb
+WHERE template_schema IS DISTINCT FROM '{}'::jsonb;
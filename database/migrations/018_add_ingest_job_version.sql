@@ -0,0 +1,11 @@
+-- Migration to record the version an ingest job is writing, so that a resumed
+-- job removes what an interrupted attempt left half-written
+-- PostgreSQL 18
+SET search_path TO slideforge,
+    public;
+ALTER TABLE ingest_jobs
+ADD COLUMN IF NOT EXISTS version_id INTEGER REFERENCES pptx_versions(id) ON DELETE SET NULL;
+-- NULL once the version is complete
+ALTER TABLE ingest_jobs
+ADD COLUMN IF NOT EXISTS created_file BOOLEAN NOT NULL DEFAULT FALSE;
+-- Whether the job created pptx_file_id rather than adding a version to it
@@ -0,0 +1,55 @@
+-- Migration to keep the slide layouts of each version of a presentation apart,
+-- instead of one set per deck that every new version overwrote
+-- PostgreSQL 18
+SET search_path TO slideforge,
+    public;
+ALTER TABLE slide_layouts
+ADD COLUMN IF NOT EXISTS version_id INTEGER REFERENCES pptx_versions(id) ON DELETE CASCADE;
+-- Existing layouts belong to the current version of their deck
+UPDATE slide_layouts l
+SET version_id = f.current_version_id
+FROM pptx_files f
+WHERE f.id = l.pptx_file_id
+    AND l.version_id IS NULL;
+ALTER TABLE slide_layouts DROP CONSTRAINT IF EXISTS slide_layouts_pptx_file_id_part_name_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_slide_layouts_version_part ON slide_layouts (version_id, part_name);
+-- Older versions get their own copy of the layouts their slides use
+INSERT INTO slide_layouts (
+        pptx_file_id,
+        version_id,
+        part_name,
+        name,
+        layout_type,
+        master_part,
+        master_name,
+        placeholders
+    )
+SELECT DISTINCT l.pptx_file_id,
+    s.version_id,
+    l.part_name,
+    l.name,
+    l.layout_type,
+    l.master_part,
+    l.master_name,
+    l.placeholders
+FROM collected_slides s
+    JOIN slide_layouts l ON l.id = s.layout_id
+WHERE s.version_id IS NOT NULL
+    AND s.version_id IS DISTINCT FROM l.version_id ON CONFLICT (version_id, part_name) DO NOTHING;
+UPDATE collected_slides s
+SET layout_id = c.id
+FROM slide_layouts l,
+    slide_layouts c
+WHERE l.id = s.layout_id
+    AND c.version_id = s.version_id
+    AND c.part_name = l.part_name
+    AND c.id != l.id;
+UPDATE slide_layouts l
+SET thumbnail_path = (
+        SELECT s.png_path
+        FROM collected_slides s
+        WHERE s.layout_id = l.id
+        ORDER BY s.slide_number
+        LIMIT 1
+    )
+WHERE l.thumbnail_path IS NULL;
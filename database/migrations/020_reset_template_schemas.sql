@@ -0,0 +1,9 @@
+-- Migration to drop stored template payload schemas so they are inferred
+-- again: name hints match whole words only, so tags like summary or country
+-- are no longer typed as numbers or dates
+-- PostgreSQL 18
+SET search_path TO slideforge,
+    public;
+UPDATE pptx_files
+SET template_schema = '{}'::jsonb
+WHERE template_schema IS DISTINCT FROM '{}'::jsonb;
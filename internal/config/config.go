@@ -14,6 +14,9 @@ type Config struct {
 	AI          AIConfig          `mapstructure:"ai"`
 	Application ApplicationConfig `mapstructure:"application"`
 	Ldap        LdapConfig        `mapstructure:"ldap"`
+	Renderer    RendererConfig    `mapstructure:"renderer"`
+	Ingest      IngestConfig      `mapstructure:"ingest"`
+	Renditions  []RenditionConfig `mapstructure:"renditions"`
 }
 
 type ApplicationConfig struct {
@@ -38,6 +41,35 @@ type StorageConfig struct {
 	Thumbnails string `mapstructure:"thumbnails"`
 	Stage      string `mapstructure:"stage"`
 	Template   string `mapstructure:"template"`
+	PDFCache   string `mapstructure:"pdf_cache"` // intermediate PDFs by checksum
+}
+
+// RendererConfig selects the slide renderer and sizes the LibreOffice worker
+// pool.
+type RendererConfig struct {
+	Engine         string `mapstructure:"engine"`          // auto | libreoffice | schematic
+	Workers        int    `mapstructure:"workers"`         // concurrent soffice processes
+	TimeoutSeconds int    `mapstructure:"timeout_seconds"` // per conversion attempt
+	Retries        int    `mapstructure:"retries"`         // extra attempts after a failure
+	ProfileDir     string `mapstructure:"profile_dir"`     // parent of the per-worker profiles
+	Binary         string `mapstructure:"binary"`
+}
+
+// IngestConfig sizes the worker pool that ingests staged files from the
+// ingest_jobs queue.
+type IngestConfig struct {
+	Workers     int `mapstructure:"workers"`      // files processed concurrently
+	MaxAttempts int `mapstructure:"max_attempts"` // starts before an interrupted job fails
+}
+
+// RenditionConfig is an image variant rendered for every slide
+// (see pptx.Rendition, which has the same fields).
+type RenditionConfig struct {
+	Name    string   `mapstructure:"name"`
+	Width   int      `mapstructure:"width"` // pixels; 0 renders at DPI
+	DPI     int      `mapstructure:"dpi"`
+	Formats []string `mapstructure:"formats"` // webp, jpeg, png
+	Quality int      `mapstructure:"quality"`
 }
 
 type AIConfig struct {
@@ -131,6 +163,19 @@ func LoadConfig() (*Config, error) {
 		{"application.storage.stage", "STORAGE_STAGE"},
 		{"application.storage.template", "STORAGE_TEMPLATE"},
 		{"application.storage.thumbnails", "STORAGE_THUMBNAILS"},
+		{"application.storage.pdf_cache", "STORAGE_PDF_CACHE"},
+
+		// Renderer
+		{"renderer.engine", "RENDER_ENGINE"},
+		{"renderer.workers", "RENDER_WORKERS"},
+		{"renderer.timeout_seconds", "RENDER_TIMEOUT"},
+		{"renderer.retries", "RENDER_RETRIES"},
+		{"renderer.profile_dir", "RENDER_PROFILE_DIR"},
+		{"renderer.binary", "LIBREOFFICE_BIN"},
+
+		// Ingest
+		{"ingest.workers", "INGEST_WORKERS"},
+		{"ingest.max_attempts", "INGEST_MAX_ATTEMPTS"},
 
 		// AI Providers
 		{"ai.providers.gemini.key", "GEMINI_KEY"},
@@ -164,6 +209,13 @@ func LoadConfig() (*Config, error) {
 	viper.SetDefault("ldap.port", 389)
 	viper.SetDefault("ldap.search_filter", "(&(objectCategory=person)(objectClass=user))")
 	viper.SetDefault("ldap.postfix", "@alig.hu")
+	viper.SetDefault("renderer.engine", "auto")
+	viper.SetDefault("renderer.workers", 2)
+	viper.SetDefault("renderer.timeout_seconds", 120)
+	viper.SetDefault("renderer.retries", 1)
+	viper.SetDefault("renderer.binary", "libreoffice")
+	viper.SetDefault("ingest.workers", 2)
+	viper.SetDefault("ingest.max_attempts", 3)
 
 	if err := viper.ReadInConfig(); err != nil {
 		// Ignore if config.yaml is missing
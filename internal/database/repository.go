@@ -3,29 +3,62 @@ package database
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
 	"time"
+
+	"github.com/lib/pq"
 )
 
+// ErrSlideNotFound is returned, wrapped, when a requested slide ID does not exist.
+var ErrSlideNotFound = errors.New("slide not found")
+
 type PPTXFile struct {
 	ID               int             `json:"id"`
 	Filename         string          `json:"filename"`
 	OriginalFilePath string          `json:"original_file_path"`
 	TemplateFilePath string          `json:"template_file_path"`
 	ThumbnailDirPath string          `json:"thumbnail_dir_path"`
+	Folder           string          `json:"folder"` // stage subfolder, slash-separated; "" for the root
 	Metadata         json.RawMessage `json:"metadata"`
 	IsTemplate       bool            `json:"is_template"`
 	AISummary        string          `json:"ai_summary"`
 	Title            string          `json:"title"`
 	Checksum         string          `json:"checksum"`
+	TemplateSchema   json.RawMessage `json:"template_schema"`
+	Author           string          `json:"author"`
+	LastModifiedBy   string          `json:"last_modified_by"`
+	DocCreatedAt     *time.Time      `json:"doc_created_at"`
+	DocModifiedAt    *time.Time      `json:"doc_modified_at"`
+	Company          string          `json:"company"`
+	Revision         int             `json:"revision"`
+	SlideCount       int             `json:"slide_count"`
+	Application      string          `json:"application"`
 	CreatedAt        time.Time       `json:"created_at"`
 }
 
+// PPTXFilter narrows the presentation library. Empty fields do not filter.
+type PPTXFilter struct {
+	Author       string     // substring of author, case-insensitive
+	ModifiedFrom *time.Time // doc_modified_at on or after
+	ModifiedTo   *time.Time // doc_modified_at before
+	Folder       string     // folder, including its subfolders
+}
+
 type Slide struct {
 	ID         int             `json:"id"`
 	PPTXFileID int             `json:"pptx_file_id"`
+	VersionID  *int            `json:"version_id"`
 	SlideNum   int             `json:"slide_number"`
 	PNGPath    string          `json:"png_path"`
 	Content    string          `json:"content"`
+	Notes      string          `json:"notes"`
+	Section    string          `json:"section"`
+	Hidden     bool            `json:"is_hidden"`
+	LayoutID   *int            `json:"layout_id"`
 	StyleInfo  json.RawMessage `json:"style_info"`
 	AIAnalysis json.RawMessage `json:"ai_analysis"`
 	AISummary  string          `json:"ai_summary"`
@@ -38,21 +71,48 @@ type PPTXWithSlides struct {
 	Slides []Slide `json:"slides"`
 }
 
+// SlideSource locates a collected slide inside its original PPTX file.
+type SlideSource struct {
+	SlideID          int    `json:"slide_id"`
+	SlideNum         int    `json:"slide_number"`
+	Filename         string `json:"filename"`
+	OriginalFilePath string `json:"original_file_path"`
+}
+
 func SavePPTXMetadata(db *sql.DB, f *PPTXFile) (int, error) {
 	query := `
-		INSERT INTO pptx_files (filename, original_file_path, thumbnail_dir_path, metadata, is_template, ai_summary, title, checksum)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO pptx_files (filename, original_file_path, thumbnail_dir_path, metadata, is_template, ai_summary, title, checksum, template_schema,
+			author, last_modified_by, doc_created_at, doc_modified_at, company, revision, slide_count, application, folder)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
 		RETURNING id
 	`
+	schema := f.TemplateSchema
+	if len(schema) == 0 {
+		schema = json.RawMessage("{}")
+	}
 	var id int
-	err := db.QueryRow(query, f.Filename, f.OriginalFilePath, f.ThumbnailDirPath, f.Metadata, f.IsTemplate, f.AISummary, f.Title, f.Checksum).Scan(&id)
+	err := db.QueryRow(query, f.Filename, f.OriginalFilePath, f.ThumbnailDirPath, f.Metadata, f.IsTemplate, f.AISummary, f.Title, f.Checksum, schema,
+		f.Author, f.LastModifiedBy, f.DocCreatedAt, f.DocModifiedAt, f.Company, f.Revision, f.SlideCount, f.Application, f.Folder).Scan(&id)
 	return id, err
 }
 
+// docPropsColumns are the document property columns, in PPTXFile field order.
+const docPropsColumns = "COALESCE(author, ''), COALESCE(last_modified_by, ''), doc_created_at, doc_modified_at, COALESCE(company, ''), COALESCE(revision, 0), COALESCE(slide_count, 0), COALESCE(application, '')"
+
 func GetPPTXByChecksum(db *sql.DB, checksum string) (*PPTXFile, error) {
 	var f PPTXFile
-	query := "SELECT id, filename, original_file_path, thumbnail_dir_path, is_template, metadata, ai_summary, title, checksum, created_at FROM pptx_files WHERE checksum = $1"
-	err := db.QueryRow(query, checksum).Scan(&f.ID, &f.Filename, &f.OriginalFilePath, &f.ThumbnailDirPath, &f.IsTemplate, &f.Metadata, &f.AISummary, &f.Title, &f.Checksum, &f.CreatedAt)
+	query := "SELECT id, filename, original_file_path, thumbnail_dir_path, folder, is_template, metadata, ai_summary, title, checksum, COALESCE(template_schema, '{}'), " + docPropsColumns + ", created_at FROM pptx_files WHERE checksum = $1"
+	err := db.QueryRow(query, checksum).Scan(&f.ID, &f.Filename, &f.OriginalFilePath, &f.ThumbnailDirPath, &f.Folder, &f.IsTemplate, &f.Metadata, &f.AISummary, &f.Title, &f.Checksum, &f.TemplateSchema, &f.Author, &f.LastModifiedBy, &f.DocCreatedAt, &f.DocModifiedAt, &f.Company, &f.Revision, &f.SlideCount, &f.Application, &f.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func GetPPTXByID(db *sql.DB, id int) (*PPTXFile, error) {
+	var f PPTXFile
+	query := "SELECT id, filename, original_file_path, thumbnail_dir_path, folder, is_template, metadata, ai_summary, title, checksum, COALESCE(template_schema, '{}'), " + docPropsColumns + ", created_at FROM pptx_files WHERE id = $1"
+	err := db.QueryRow(query, id).Scan(&f.ID, &f.Filename, &f.OriginalFilePath, &f.ThumbnailDirPath, &f.Folder, &f.IsTemplate, &f.Metadata, &f.AISummary, &f.Title, &f.Checksum, &f.TemplateSchema, &f.Author, &f.LastModifiedBy, &f.DocCreatedAt, &f.DocModifiedAt, &f.Company, &f.Revision, &f.SlideCount, &f.Application, &f.CreatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -64,6 +124,158 @@ func UpdatePPTXTitle(db *sql.DB, id int, title string) error {
 	return err
 }
 
+// UpdatePPTXDocProps stores the document properties of an already saved file.
+func UpdatePPTXDocProps(db *sql.DB, f *PPTXFile) error {
+	_, err := db.Exec(`
+		UPDATE pptx_files SET author = $1, last_modified_by = $2, doc_created_at = $3, doc_modified_at = $4,
+			company = $5, revision = $6, slide_count = $7, application = $8
+		WHERE id = $9`,
+		f.Author, f.LastModifiedBy, f.DocCreatedAt, f.DocModifiedAt, f.Company, f.Revision, f.SlideCount, f.Application, f.ID)
+	return err
+}
+
+// DeletePPTX removes a file record with its slides; layouts, media and
+// renditions go with them.
+func DeletePPTX(db *sql.DB, id int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("DELETE FROM collected_slides WHERE pptx_file_id = $1", id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM pptx_files WHERE id = $1", id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// PPTXVersion is one distinct checksum of a presentation. Its slides keep
+// their own thumbnails; the file points at its current version.
+type PPTXVersion struct {
+	ID               int        `json:"id"`
+	PPTXFileID       int        `json:"pptx_file_id"`
+	Version          int        `json:"version"`
+	Checksum         string     `json:"checksum"`
+	OriginalFilePath string     `json:"original_file_path"`
+	ThumbnailDirPath string     `json:"thumbnail_dir_path"`
+	Title            string     `json:"title"`
+	Author           string     `json:"author"`
+	LastModifiedBy   string     `json:"last_modified_by"`
+	DocModifiedAt    *time.Time `json:"doc_modified_at"`
+	Revision         int        `json:"revision"`
+	SlideCount       int        `json:"slide_count"`
+	Current          bool       `json:"current"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+const versionColumns = `v.id, v.pptx_file_id, v.version, v.checksum, COALESCE(v.original_file_path, ''), COALESCE(v.thumbnail_dir_path, ''),
+	COALESCE(v.title, ''), COALESCE(v.author, ''), COALESCE(v.last_modified_by, ''), v.doc_modified_at, COALESCE(v.revision, 0),
+	COALESCE(v.slide_count, 0), v.id = f.current_version_id, v.created_at`
+
+func scanVersion(row interface{ Scan(...interface{}) error }) (*PPTXVersion, error) {
+	var v PPTXVersion
+	var current sql.NullBool
+	err := row.Scan(&v.ID, &v.PPTXFileID, &v.Version, &v.Checksum, &v.OriginalFilePath, &v.ThumbnailDirPath,
+		&v.Title, &v.Author, &v.LastModifiedBy, &v.DocModifiedAt, &v.Revision, &v.SlideCount, &current, &v.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	v.Current = current.Bool
+	return &v, nil
+}
+
+// FindPPTXDocument returns the ID of the presentation a staged file is a
+// version of: the one with the same filename in the same folder.
+func FindPPTXDocument(db *sql.DB, folder, filename string) (int, error) {
+	var id int
+	err := db.QueryRow("SELECT id FROM pptx_files WHERE folder = $1 AND filename = $2 ORDER BY id DESC LIMIT 1", folder, filename).Scan(&id)
+	return id, err
+}
+
+// NextPPTXVersion returns the number the next version of a file gets.
+func NextPPTXVersion(db *sql.DB, fileID int) (int, error) {
+	var version int
+	err := db.QueryRow("SELECT COALESCE(MAX(version), 0) + 1 FROM pptx_versions WHERE pptx_file_id = $1", fileID).Scan(&version)
+	return version, err
+}
+
+// SavePPTXVersion records a version of a file. It does not become the
+// current version until SetCurrentPPTXVersion.
+func SavePPTXVersion(db *sql.DB, v *PPTXVersion) (int, error) {
+	err := db.QueryRow(`
+		INSERT INTO pptx_versions (pptx_file_id, version, checksum, original_file_path, thumbnail_dir_path, title,
+			author, last_modified_by, doc_modified_at, revision, slide_count)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, created_at`,
+		v.PPTXFileID, v.Version, v.Checksum, v.OriginalFilePath, v.ThumbnailDirPath, v.Title,
+		v.Author, v.LastModifiedBy, v.DocModifiedAt, v.Revision, v.SlideCount).Scan(&v.ID, &v.CreatedAt)
+	return v.ID, err
+}
+
+// SetCurrentPPTXVersion makes a version the one the library, search and
+// generator show for its file.
+func SetCurrentPPTXVersion(db *sql.DB, fileID, versionID int) error {
+	_, err := db.Exec("UPDATE pptx_files SET current_version_id = $1 WHERE id = $2", versionID, fileID)
+	return err
+}
+
+// UpdatePPTXVersionPath records where the file of a version was moved to.
+func UpdatePPTXVersionPath(db *sql.DB, versionID int, path string) error {
+	_, err := db.Exec("UPDATE pptx_versions SET original_file_path = $1 WHERE id = $2", path, versionID)
+	return err
+}
+
+// DeletePPTXVersion removes a version with its slides.
+func DeletePPTXVersion(db *sql.DB, versionID int) error {
+	_, err := db.Exec("DELETE FROM pptx_versions WHERE id = $1", versionID)
+	return err
+}
+
+// GetPPTXVersions lists the versions of a file, newest first.
+func GetPPTXVersions(db *sql.DB, fileID int) ([]PPTXVersion, error) {
+	rows, err := db.Query(`
+		SELECT `+versionColumns+`
+		FROM pptx_versions v
+		JOIN pptx_files f ON f.id = v.pptx_file_id
+		WHERE v.pptx_file_id = $1
+		ORDER BY v.version DESC`, fileID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := []PPTXVersion{}
+	for rows.Next() {
+		v, err := scanVersion(rows)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, *v)
+	}
+	return versions, rows.Err()
+}
+
+// GetPPTXVersion loads a version of a file by its number.
+func GetPPTXVersion(db *sql.DB, fileID, version int) (*PPTXVersion, error) {
+	return scanVersion(db.QueryRow(`
+		SELECT `+versionColumns+`
+		FROM pptx_versions v
+		JOIN pptx_files f ON f.id = v.pptx_file_id
+		WHERE v.pptx_file_id = $1 AND v.version = $2`, fileID, version))
+}
+
+// FindPPTXVersionByChecksum returns the version, of any file, with the given
+// checksum.
+func FindPPTXVersionByChecksum(db *sql.DB, checksum string) (*PPTXVersion, error) {
+	return scanVersion(db.QueryRow(`
+		SELECT `+versionColumns+`
+		FROM pptx_versions v
+		JOIN pptx_files f ON f.id = v.pptx_file_id
+		WHERE v.checksum = $1`, checksum))
+}
+
 func UpdatePPTXSummary(db *sql.DB, id int, summary string) error {
 	_, err := db.Exec("UPDATE pptx_files SET ai_summary = $1 WHERE id = $2", summary, id)
 	return err
@@ -71,15 +283,239 @@ func UpdatePPTXSummary(db *sql.DB, id int, summary string) error {
 
 func SaveSlide(db *sql.DB, s *Slide) error {
 	query := `
-		INSERT INTO collected_slides (pptx_file_id, slide_number, png_path, content, style_info, ai_analysis, ai_summary, title)
+		INSERT INTO collected_slides (pptx_file_id, version_id, slide_number, png_path, content, notes, section, is_hidden, layout_id, style_info, ai_analysis, ai_summary, title)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING id
+	`
+	return db.QueryRow(query, s.PPTXFileID, s.VersionID, s.SlideNum, s.PNGPath, s.Content, s.Notes, s.Section, s.Hidden, s.LayoutID, s.StyleInfo, s.AIAnalysis, s.AISummary, s.Title).Scan(&s.ID)
+}
+
+// SlideLayout is a slide layout of a presentation version, with the master
+// it belongs to.
+type SlideLayout struct {
+	ID            int             `json:"id"`
+	PPTXFileID    int             `json:"pptx_file_id"`
+	VersionID     int             `json:"version_id"`
+	PartName      string          `json:"part_name"`
+	Name          string          `json:"name"`
+	LayoutType    string          `json:"layout_type"`
+	MasterPart    string          `json:"master_part"`
+	MasterName    string          `json:"master_name"`
+	Placeholders  json.RawMessage `json:"placeholders"`
+	ThumbnailPath string          `json:"thumbnail_path"`
+	SlideCount    int             `json:"slide_count"` // slides of the deck using the layout
+}
+
+// SaveSlideLayouts stores the layouts of a version of a file and returns their
+// IDs by part name. Layouts already stored for the version are updated in
+// place; other versions keep their own.
+func SaveSlideLayouts(db *sql.DB, fileID, versionID int, layouts []SlideLayout) (map[string]int, error) {
+	query := `
+		INSERT INTO slide_layouts (pptx_file_id, version_id, part_name, name, layout_type, master_part, master_name, placeholders)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (version_id, part_name) DO UPDATE SET
+			name = EXCLUDED.name, layout_type = EXCLUDED.layout_type, master_part = EXCLUDED.master_part,
+			master_name = EXCLUDED.master_name, placeholders = EXCLUDED.placeholders
+		RETURNING id
 	`
-	_, err := db.Exec(query, s.PPTXFileID, s.SlideNum, s.PNGPath, s.Content, s.StyleInfo, s.AIAnalysis, s.AISummary, s.Title)
+	ids := make(map[string]int)
+	for _, l := range layouts {
+		placeholders := l.Placeholders
+		if len(placeholders) == 0 {
+			placeholders = json.RawMessage("[]")
+		}
+		var id int
+		if err := db.QueryRow(query, fileID, versionID, l.PartName, l.Name, l.LayoutType, l.MasterPart, l.MasterName, placeholders).Scan(&id); err != nil {
+			return ids, err
+		}
+		ids[l.PartName] = id
+	}
+	return ids, nil
+}
+
+// UpdateLayoutThumbnails sets the thumbnail of each layout of a version to
+// the first slide using it.
+func UpdateLayoutThumbnails(db *sql.DB, versionID int) error {
+	_, err := db.Exec(`
+		UPDATE slide_layouts l SET thumbnail_path = (
+			SELECT s.png_path FROM collected_slides s
+			WHERE s.layout_id = l.id
+			ORDER BY s.slide_number LIMIT 1)
+		WHERE l.version_id = $1`, versionID)
 	return err
 }
 
+// GetLayoutsByFile lists the layouts of the current version of a file with
+// the number of slides using each.
+func GetLayoutsByFile(db *sql.DB, fileID int) ([]SlideLayout, error) {
+	return queryLayouts(db, "WHERE l.pptx_file_id = $1", fileID)
+}
+
+// FindLayoutsByName lists the layouts of the current version of all files
+// whose name contains the given text, e.g. to find decks still using a
+// deprecated layout.
+func FindLayoutsByName(db *sql.DB, name string) ([]SlideLayout, error) {
+	return queryLayouts(db, "WHERE l.name ILIKE $1", "%"+name+"%")
+}
+
+func queryLayouts(db *sql.DB, where string, args ...interface{}) ([]SlideLayout, error) {
+	rows, err := db.Query(`
+		SELECT l.id, l.pptx_file_id, l.version_id, l.part_name, COALESCE(l.name, ''), COALESCE(l.layout_type, ''),
+			COALESCE(l.master_part, ''), COALESCE(l.master_name, ''), COALESCE(l.placeholders, '[]'),
+			COALESCE(l.thumbnail_path, ''), COUNT(s.id)
+		FROM slide_layouts l
+		JOIN pptx_files f ON f.id = l.pptx_file_id AND l.version_id = f.current_version_id
+		LEFT JOIN collected_slides s ON s.layout_id = l.id
+		`+where+`
+		GROUP BY l.id
+		ORDER BY l.pptx_file_id, l.id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	layouts := []SlideLayout{}
+	for rows.Next() {
+		var l SlideLayout
+		if err := rows.Scan(&l.ID, &l.PPTXFileID, &l.VersionID, &l.PartName, &l.Name, &l.LayoutType, &l.MasterPart, &l.MasterName, &l.Placeholders, &l.ThumbnailPath, &l.SlideCount); err != nil {
+			return nil, err
+		}
+		layouts = append(layouts, l)
+	}
+	return layouts, rows.Err()
+}
+
+// SlideRendition is an image variant (thumbnail, preview, full size) of a slide.
+type SlideRendition struct {
+	ID         int    `json:"id"`
+	SlideID    int    `json:"slide_id"`
+	PPTXFileID int    `json:"pptx_file_id"`
+	Name       string `json:"name"`
+	Format     string `json:"format"`
+	Path       string `json:"path"`
+	Width      int    `json:"width"`
+	Height     int    `json:"height"`
+	SizeBytes  int64  `json:"size_bytes"`
+	SHA256     string `json:"sha256"`
+}
+
+// SaveSlideRenditions records the renditions of a slide (s.ID must be set),
+// replacing earlier records of the same name and format.
+func SaveSlideRenditions(db *sql.DB, s *Slide, renditions []SlideRendition) error {
+	query := `
+		INSERT INTO slide_renditions (slide_id, pptx_file_id, name, format, path, width, height, size_bytes, sha256)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (slide_id, name, format) DO UPDATE SET
+			path = EXCLUDED.path, width = EXCLUDED.width, height = EXCLUDED.height,
+			size_bytes = EXCLUDED.size_bytes, sha256 = EXCLUDED.sha256, created_at = CURRENT_TIMESTAMP
+	`
+	for _, r := range renditions {
+		if _, err := db.Exec(query, s.ID, s.PPTXFileID, r.Name, r.Format, r.Path, r.Width, r.Height, r.SizeBytes, r.SHA256); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetSlideRenditions lists the renditions of a slide.
+func GetSlideRenditions(db *sql.DB, slideID int) ([]SlideRendition, error) {
+	rows, err := db.Query(`
+		SELECT id, slide_id, pptx_file_id, name, format, path, COALESCE(width, 0), COALESCE(height, 0), COALESCE(size_bytes, 0), COALESCE(sha256, '')
+		FROM slide_renditions WHERE slide_id = $1 ORDER BY name, format`, slideID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	renditions := []SlideRendition{}
+	for rows.Next() {
+		var r SlideRendition
+		if err := rows.Scan(&r.ID, &r.SlideID, &r.PPTXFileID, &r.Name, &r.Format, &r.Path, &r.Width, &r.Height, &r.SizeBytes, &r.SHA256); err != nil {
+			return nil, err
+		}
+		renditions = append(renditions, r)
+	}
+	return renditions, rows.Err()
+}
+
+// SlideMedia is a picture, video or audio part used by a collected slide.
+type SlideMedia struct {
+	ID          int    `json:"id"`
+	SlideID     int    `json:"slide_id"`
+	PPTXFileID  int    `json:"pptx_file_id"`
+	PartName    string `json:"part_name"`
+	RelID       string `json:"rel_id"`
+	MediaType   string `json:"media_type"`
+	ContentType string `json:"content_type"`
+	SizeBytes   int64  `json:"size_bytes"`
+	SHA256      string `json:"sha256"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+}
+
+// SaveSlideMedia stores the media inventory of a slide (s.ID must be set).
+func SaveSlideMedia(db *sql.DB, s *Slide, media []SlideMedia) error {
+	query := `
+		INSERT INTO slide_media (slide_id, pptx_file_id, part_name, rel_id, media_type, content_type, size_bytes, sha256, width, height)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+	for _, m := range media {
+		if _, err := db.Exec(query, s.ID, s.PPTXFileID, m.PartName, m.RelID, m.MediaType, m.ContentType, m.SizeBytes, m.SHA256, m.Width, m.Height); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MediaUsage is a slide that uses a media part with a given hash.
+type MediaUsage struct {
+	PPTXFileID int    `json:"pptx_file_id"`
+	Filename   string `json:"filename"`
+	SlideID    int    `json:"slide_id"`
+	SlideNum   int    `json:"slide_number"`
+	Title      string `json:"title"`
+	PNGPath    string `json:"png_path"`
+	PartName   string `json:"part_name"`
+}
+
+// FindMediaUsages returns every slide in the library that uses the media part
+// with the given SHA-256 (hex), ordered by file and slide.
+func FindMediaUsages(db *sql.DB, sha256 string) ([]MediaUsage, error) {
+	rows, err := db.Query(`
+		SELECT f.id, f.filename, s.id, s.slide_number, COALESCE(s.title, ''), s.png_path, m.part_name
+		FROM slide_media m
+		JOIN collected_slides s ON s.id = m.slide_id
+		JOIN pptx_files f ON f.id = m.pptx_file_id
+		WHERE m.sha256 = $1 AND s.version_id = f.current_version_id
+		ORDER BY f.filename, s.slide_number`, sha256)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	usages := []MediaUsage{}
+	for rows.Next() {
+		var u MediaUsage
+		if err := rows.Scan(&u.PPTXFileID, &u.Filename, &u.SlideID, &u.SlideNum, &u.Title, &u.PNGPath, &u.PartName); err != nil {
+			return nil, err
+		}
+		usages = append(usages, u)
+	}
+	return usages, rows.Err()
+}
+
+// GetSlidesByFile lists the slides of the current version of a file.
 func GetSlidesByFile(db *sql.DB, fileID int) ([]Slide, error) {
-	rows, err := db.Query("SELECT id, pptx_file_id, slide_number, png_path, content, style_info, ai_analysis, ai_summary, title, created_at FROM collected_slides WHERE pptx_file_id = $1 ORDER BY slide_number", fileID)
+	return querySlides(db, "WHERE pptx_file_id = $1 AND version_id = (SELECT current_version_id FROM pptx_files WHERE id = $1)", fileID)
+}
+
+// GetSlidesByVersion lists the slides of a version of a file.
+func GetSlidesByVersion(db *sql.DB, versionID int) ([]Slide, error) {
+	return querySlides(db, "WHERE version_id = $1", versionID)
+}
+
+func querySlides(db *sql.DB, where string, args ...interface{}) ([]Slide, error) {
+	rows, err := db.Query("SELECT id, pptx_file_id, version_id, slide_number, png_path, content, COALESCE(notes, ''), COALESCE(section, ''), COALESCE(is_hidden, FALSE), layout_id, style_info, ai_analysis, ai_summary, title, created_at FROM collected_slides "+where+" ORDER BY slide_number", args...)
 	if err != nil {
 		return nil, err
 	}
@@ -88,7 +524,7 @@ func GetSlidesByFile(db *sql.DB, fileID int) ([]Slide, error) {
 	var slides []Slide
 	for rows.Next() {
 		var s Slide
-		if err := rows.Scan(&s.ID, &s.PPTXFileID, &s.SlideNum, &s.PNGPath, &s.Content, &s.StyleInfo, &s.AIAnalysis, &s.AISummary, &s.Title, &s.CreatedAt); err != nil {
+		if err := rows.Scan(&s.ID, &s.PPTXFileID, &s.VersionID, &s.SlideNum, &s.PNGPath, &s.Content, &s.Notes, &s.Section, &s.Hidden, &s.LayoutID, &s.StyleInfo, &s.AIAnalysis, &s.AISummary, &s.Title, &s.CreatedAt); err != nil {
 			return nil, err
 		}
 		slides = append(slides, s)
@@ -96,8 +532,76 @@ func GetSlidesByFile(db *sql.DB, fileID int) ([]Slide, error) {
 	return slides, nil
 }
 
+func GetSlideByID(db *sql.DB, id int) (*Slide, error) {
+	var s Slide
+	query := "SELECT id, pptx_file_id, version_id, slide_number, png_path, content, COALESCE(notes, ''), COALESCE(section, ''), COALESCE(is_hidden, FALSE), layout_id, style_info, ai_analysis, ai_summary, title, created_at FROM collected_slides WHERE id = $1"
+	err := db.QueryRow(query, id).Scan(&s.ID, &s.PPTXFileID, &s.VersionID, &s.SlideNum, &s.PNGPath, &s.Content, &s.Notes, &s.Section, &s.Hidden, &s.LayoutID, &s.StyleInfo, &s.AIAnalysis, &s.AISummary, &s.Title, &s.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// GetSlidesByIDs loads slides by ID, preserving the requested order.
+func GetSlidesByIDs(db *sql.DB, ids []int) ([]Slide, error) {
+	rows, err := db.Query("SELECT id, pptx_file_id, version_id, slide_number, png_path, content, COALESCE(notes, ''), COALESCE(section, ''), COALESCE(is_hidden, FALSE), layout_id, style_info, ai_analysis, ai_summary, title, created_at FROM collected_slides WHERE id = ANY($1)", pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	byID := make(map[int]Slide)
+	for rows.Next() {
+		var s Slide
+		if err := rows.Scan(&s.ID, &s.PPTXFileID, &s.VersionID, &s.SlideNum, &s.PNGPath, &s.Content, &s.Notes, &s.Section, &s.Hidden, &s.LayoutID, &s.StyleInfo, &s.AIAnalysis, &s.AISummary, &s.Title, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		byID[s.ID] = s
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	slides := make([]Slide, 0, len(ids))
+	for _, id := range ids {
+		s, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("slide %d: %w", id, ErrSlideNotFound)
+		}
+		slides = append(slides, s)
+	}
+	return slides, nil
+}
+
 func GetAllPPTX(db *sql.DB) ([]PPTXFile, error) {
-	rows, err := db.Query("SELECT id, filename, original_file_path, thumbnail_dir_path, is_template, metadata, ai_summary, title, created_at FROM pptx_files ORDER BY created_at DESC")
+	return FindPPTX(db, PPTXFilter{})
+}
+
+// FindPPTX lists the presentations matching the filter, newest first.
+func FindPPTX(db *sql.DB, filter PPTXFilter) ([]PPTXFile, error) {
+	var where []string
+	var args []interface{}
+	if filter.Author != "" {
+		args = append(args, "%"+filter.Author+"%")
+		where = append(where, fmt.Sprintf("author ILIKE $%d", len(args)))
+	}
+	if filter.ModifiedFrom != nil {
+		args = append(args, *filter.ModifiedFrom)
+		where = append(where, fmt.Sprintf("doc_modified_at >= $%d", len(args)))
+	}
+	if filter.ModifiedTo != nil {
+		args = append(args, *filter.ModifiedTo)
+		where = append(where, fmt.Sprintf("doc_modified_at < $%d", len(args)))
+	}
+	if filter.Folder != "" {
+		args = append(args, filter.Folder, FolderPattern(filter.Folder))
+		where = append(where, fmt.Sprintf("(folder = $%d OR folder LIKE $%d)", len(args)-1, len(args)))
+	}
+	query := "SELECT id, filename, original_file_path, thumbnail_dir_path, folder, is_template, metadata, ai_summary, title, " + docPropsColumns + ", created_at FROM pptx_files"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	rows, err := db.Query(query+" ORDER BY created_at DESC", args...)
 	if err != nil {
 		return nil, err
 	}
@@ -106,7 +610,8 @@ func GetAllPPTX(db *sql.DB) ([]PPTXFile, error) {
 	var files []PPTXFile
 	for rows.Next() {
 		var f PPTXFile
-		if err := rows.Scan(&f.ID, &f.Filename, &f.OriginalFilePath, &f.ThumbnailDirPath, &f.IsTemplate, &f.Metadata, &f.AISummary, &f.Title, &f.CreatedAt); err != nil {
+		if err := rows.Scan(&f.ID, &f.Filename, &f.OriginalFilePath, &f.ThumbnailDirPath, &f.Folder, &f.IsTemplate, &f.Metadata, &f.AISummary, &f.Title,
+			&f.Author, &f.LastModifiedBy, &f.DocCreatedAt, &f.DocModifiedAt, &f.Company, &f.Revision, &f.SlideCount, &f.Application, &f.CreatedAt); err != nil {
 			return nil, err
 		}
 		files = append(files, f)
@@ -114,6 +619,45 @@ func GetAllPPTX(db *sql.DB) ([]PPTXFile, error) {
 	return files, nil
 }
 
+// FolderPattern is the LIKE pattern matching everything below a folder.
+func FolderPattern(folder string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(folder)
+	return escaped + "/%"
+}
+
+// GetPPTXFolders lists the folders holding presentations and their parent
+// folders, in tree order.
+func GetPPTXFolders(db *sql.DB) ([]string, error) {
+	rows, err := db.Query("SELECT DISTINCT folder FROM pptx_files WHERE folder != ''")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	seen := make(map[string]bool)
+	for rows.Next() {
+		var folder string
+		if err := rows.Scan(&folder); err != nil {
+			return nil, err
+		}
+		for f := folder; f != "." && f != "/" && !seen[f]; f = path.Dir(f) {
+			seen[f] = true
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	folders := make([]string, 0, len(seen))
+	for f := range seen {
+		folders = append(folders, f)
+	}
+	// Compare by path segment, so that subfolders follow their parent
+	// directly ("a", "a/b", "a b")
+	key := func(f string) string { return strings.ReplaceAll(f, "/", "\x00") }
+	sort.Slice(folders, func(i, j int) bool { return key(folders[i]) < key(folders[j]) })
+	return folders, nil
+}
+
 func GetAllPPTXWithSlides(db *sql.DB) ([]PPTXWithSlides, error) {
 	files, err := GetAllPPTX(db)
 	if err != nil {
@@ -136,6 +680,243 @@ func GetAllPPTXWithSlides(db *sql.DB) ([]PPTXWithSlides, error) {
 
 func GetTotalSlideCount(db *sql.DB) (int, error) {
 	var count int
-	err := db.QueryRow("SELECT COUNT(*) FROM collected_slides").Scan(&count)
+	err := db.QueryRow(`
+		SELECT COUNT(*) FROM collected_slides s
+		JOIN pptx_files f ON s.pptx_file_id = f.id
+		WHERE s.version_id = f.current_version_id`).Scan(&count)
+	return count, err
+}
+
+// GetSlideSources resolves slide IDs to their source files, preserving the
+// requested order. Slides of earlier versions resolve to that version's file.
+func GetSlideSources(db *sql.DB, slideIDs []int) ([]SlideSource, error) {
+	rows, err := db.Query(`
+		SELECT s.id, s.slide_number, f.filename, COALESCE(v.original_file_path, f.original_file_path)
+		FROM collected_slides s
+		JOIN pptx_files f ON s.pptx_file_id = f.id
+		LEFT JOIN pptx_versions v ON s.version_id = v.id
+		WHERE s.id = ANY($1)`, pq.Array(slideIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	byID := make(map[int]SlideSource)
+	for rows.Next() {
+		var src SlideSource
+		if err := rows.Scan(&src.SlideID, &src.SlideNum, &src.Filename, &src.OriginalFilePath); err != nil {
+			return nil, err
+		}
+		byID[src.SlideID] = src
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sources := make([]SlideSource, 0, len(slideIDs))
+	for _, id := range slideIDs {
+		src, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("slide %d: %w", id, ErrSlideNotFound)
+		}
+		sources = append(sources, src)
+	}
+	return sources, nil
+}
+
+// Ingest job states, in processing order.
+const (
+	JobQueued      = "queued"
+	JobRendering   = "rendering"
+	JobExtracting  = "extracting"
+	JobSummarizing = "summarizing"
+	JobDone        = "done"
+	JobFailed      = "failed"
+)
+
+// IngestJob is a staged PPTX file waiting for or going through ingestion.
+type IngestJob struct {
+	ID         int        `json:"id"`
+	FilePath   string     `json:"file_path"`
+	State      string     `json:"state"`
+	Attempts   int        `json:"attempts"`
+	Error      string     `json:"error,omitempty"`
+	PPTXFileID *int       `json:"pptx_file_id,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// EnqueueIngestJob queues a file for ingestion. A file that already has an
+// unfinished job is not queued twice; the existing job's ID is returned.
+func EnqueueIngestJob(db *sql.DB, path string) (int, error) {
+	var id int
+	err := db.QueryRow(`
+		INSERT INTO ingest_jobs (file_path) VALUES ($1)
+		ON CONFLICT (file_path) WHERE state NOT IN ('done', 'failed') DO NOTHING
+		RETURNING id`, path).Scan(&id)
+	if err == sql.ErrNoRows {
+		err = db.QueryRow("SELECT id FROM ingest_jobs WHERE file_path = $1 AND state NOT IN ('done', 'failed')", path).Scan(&id)
+	}
+	return id, err
+}
+
+// ClaimIngestJob takes the oldest queued job and moves it to rendering.
+// Concurrent workers skip each other's locked rows, so a job is claimed once.
+// It returns sql.ErrNoRows when the queue is empty.
+func ClaimIngestJob(db *sql.DB) (*IngestJob, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var j IngestJob
+	err = tx.QueryRow(`
+		SELECT id, file_path, attempts, created_at FROM ingest_jobs
+		WHERE state = 'queued' ORDER BY id
+		LIMIT 1 FOR UPDATE SKIP LOCKED`).Scan(&j.ID, &j.FilePath, &j.Attempts, &j.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	err = tx.QueryRow(`
+		UPDATE ingest_jobs SET state = 'rendering', attempts = attempts + 1, error = NULL,
+			started_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING state, attempts, updated_at, started_at`, j.ID).Scan(&j.State, &j.Attempts, &j.UpdatedAt, &j.StartedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &j, tx.Commit()
+}
+
+// UpdateIngestJobState records the stage a running job has reached.
+func UpdateIngestJobState(db *sql.DB, id int, state string) error {
+	_, err := db.Exec("UPDATE ingest_jobs SET state = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2", state, id)
+	return err
+}
+
+// SetIngestJobVersion records the version a running job is writing and
+// whether the job created its file, so that a resumed job can discard them.
+func SetIngestJobVersion(db *sql.DB, id, fileID, versionID int, createdFile bool) error {
+	_, err := db.Exec(`
+		UPDATE ingest_jobs SET pptx_file_id = $1, version_id = $2, created_file = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $4`, fileID, versionID, createdFile, id)
+	return err
+}
+
+// CompleteIngestJobVersion marks the version of a job complete; it is no
+// longer discarded if the job is resumed.
+func CompleteIngestJobVersion(db *sql.DB, id int) error {
+	_, err := db.Exec("UPDATE ingest_jobs SET version_id = NULL, created_file = FALSE, updated_at = CURRENT_TIMESTAMP WHERE id = $1", id)
+	return err
+}
+
+// DiscardIngestJobVersion removes the version an interrupted attempt of a job
+// left half-written, with its slides; the whole file if the job created it.
+// It reports whether there was one.
+func DiscardIngestJobVersion(db *sql.DB, id int) (bool, error) {
+	var versionID, fileID sql.NullInt64
+	var createdFile bool
+	err := db.QueryRow("SELECT version_id, pptx_file_id, created_file FROM ingest_jobs WHERE id = $1", id).Scan(&versionID, &fileID, &createdFile)
+	if err != nil || !versionID.Valid {
+		return false, err
+	}
+	if createdFile && fileID.Valid {
+		err = DeletePPTX(db, int(fileID.Int64))
+	} else {
+		err = DeletePPTXVersion(db, int(versionID.Int64))
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, CompleteIngestJobVersion(db, id)
+}
+
+// FinishIngestJob marks a job done, linked to the file it produced, or failed
+// with the error.
+func FinishIngestJob(db *sql.DB, id int, fileID int, jobErr error) error {
+	state, errMsg, file := JobDone, sql.NullString{}, sql.NullInt64{}
+	if jobErr != nil {
+		state, errMsg = JobFailed, sql.NullString{String: jobErr.Error(), Valid: true}
+	}
+	if fileID > 0 {
+		file = sql.NullInt64{Int64: int64(fileID), Valid: true}
+	}
+	_, err := db.Exec(`
+		UPDATE ingest_jobs SET state = $1, error = $2, pptx_file_id = $3,
+			finished_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $4`, state, errMsg, file, id)
+	return err
+}
+
+// TouchIngestJob renews the lease of a running job. The attempt identifies the
+// claim; it reports false once the job was taken back or claimed again.
+func TouchIngestJob(db *sql.DB, id, attempt int) (bool, error) {
+	res, err := db.Exec(`
+		UPDATE ingest_jobs SET updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND attempts = $2 AND state IN ('rendering', 'extracting', 'summarizing')`, id, attempt)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ResumeIngestJobs requeues the running jobs whose lease ran out, i.e. that
+// were not updated for the lease duration: their worker, in this or another
+// server instance, is gone. Jobs that were already started maxAttempts times
+// fail instead, so a file that crashes the server is not retried forever. It
+// returns the number of requeued jobs.
+func ResumeIngestJobs(db *sql.DB, maxAttempts int, lease time.Duration) (int64, error) {
+	const stale = `state IN ('rendering', 'extracting', 'summarizing')
+		AND updated_at < CURRENT_TIMESTAMP - $1 * INTERVAL '1 second'`
+	_, err := db.Exec(`
+		UPDATE ingest_jobs SET state = 'failed', error = 'interrupted too many times',
+			finished_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE `+stale+` AND attempts >= $2`, lease.Seconds(), maxAttempts)
+	if err != nil {
+		return 0, err
+	}
+	res, err := db.Exec(`
+		UPDATE ingest_jobs SET state = 'queued', updated_at = CURRENT_TIMESTAMP
+		WHERE `+stale, lease.Seconds())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// CancelQueuedIngestJobs fails the queued jobs of a file with the reason and
+// returns how many there were.
+func CancelQueuedIngestJobs(db *sql.DB, path, reason string) (int64, error) {
+	res, err := db.Exec(`
+		UPDATE ingest_jobs SET state = 'failed', error = $1,
+			finished_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE file_path = $2 AND state = 'queued'`, reason, path)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// CancelQueuedIngestJobsUnder fails the queued jobs of the files below a
+// directory with the reason.
+func CancelQueuedIngestJobsUnder(db *sql.DB, dir, reason string) (int64, error) {
+	res, err := db.Exec(`
+		UPDATE ingest_jobs SET state = 'failed', error = $1,
+			finished_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE file_path LIKE $2 AND state = 'queued'`, reason, FolderPattern(dir))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// CountActiveIngestJobs counts the queued and running jobs.
+func CountActiveIngestJobs(db *sql.DB) (int, error) {
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM ingest_jobs WHERE state NOT IN ('done', 'failed')").Scan(&count)
 	return count, err
 }
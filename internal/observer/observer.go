@@ -1,17 +1,21 @@
 package observer
 
 import (
+	"archive/zip"
 	"context"
 	"crypto/sha256"
 	"database/sql"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io/fs"
 	"log"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
@@ -21,21 +25,92 @@ import (
 	"github.com/gnemet/SlideForge/internal/pptx"
 )
 
+const (
+	// ingestPollInterval is how often idle workers look for jobs queued
+	// without a wake-up (e.g. by another server instance).
+	ingestPollInterval = 5 * time.Second
+
+	// ingestHeartbeat is how often a running job renews its lease.
+	ingestHeartbeat = 30 * time.Second
+
+	// ingestLease is how long a running job may go without a heartbeat before
+	// it counts as abandoned (its server crashed) and is queued again.
+	ingestLease = 4 * ingestHeartbeat
+
+	// settleDelay is the quiet period before a staged file is checked; it is
+	// queued once two checks in a row see the same size and mtime and its zip
+	// central directory reads.
+	settleDelay = 2 * time.Second
+
+	// maxSettleWait bounds how long a file may keep changing (or stay an
+	// invalid zip) before it is given up on.
+	maxSettleWait = 30 * time.Minute
+)
+
+// Progress event types.
+const (
+	EventProgress = "progress" // a job was queued, reached a stage or finished a slide
+	EventWarning  = "warning"  // a step failed; the job goes on
+	EventDone     = "done"
+	EventFailed   = "failed"
+)
+
+// Progress is a structured ingest progress event, streamed to the dashboard
+// and the generator.
+type Progress struct {
+	Type       string    `json:"type"`
+	JobID      int       `json:"job_id"`
+	File       string    `json:"file"`
+	Stage      string    `json:"stage"`            // the job state, see database.JobQueued etc.
+	Slide      int       `json:"slide,omitempty"`  // slide being processed
+	Slides     int       `json:"slides,omitempty"` // slides in the deck
+	AICalls    int       `json:"ai_calls"`
+	Error      string    `json:"error,omitempty"`
+	PPTXFileID int       `json:"pptx_file_id,omitempty"`
+	Time       time.Time `json:"time"`
+}
+
+// Observer watches the stage directory and ingests PPTX files through the
+// ingest_jobs queue: the watcher only queues files, a pool of workers
+// processes them.
 type Observer struct {
-	cfg         *config.Config
-	db          *sql.DB
-	aiClient    *ai.Client
-	activeTasks int
-	mu          sync.Mutex
-	LogChan     chan string
+	cfg          *config.Config
+	db           *sql.DB
+	aiClient     *ai.Client
+	wake         chan struct{}
+	mu           sync.Mutex
+	pending      map[string]*pendingFile // staged files waiting to settle
+	running      map[string]*runningFile // files being processed
+	workers      sync.WaitGroup
+	LogChan      chan string
+	ProgressChan chan Progress
+}
+
+// runningFile is a staged file whose job is running. It is dirty once the
+// file was written again; the job is then cancelled and the file queued anew.
+type runningFile struct {
+	cancel context.CancelFunc
+	dirty  bool
+}
+
+// pendingFile is a staged file being debounced.
+type pendingFile struct {
+	timer   *time.Timer
+	size    int64
+	modTime time.Time
+	since   time.Time
 }
 
-func NewObserver(cfg *config.Config, db *sql.DB, ai *ai.Client, logChan chan string) *Observer {
+func NewObserver(cfg *config.Config, db *sql.DB, ai *ai.Client, logChan chan string, progressChan chan Progress) *Observer {
 	return &Observer{
-		cfg:      cfg,
-		db:       db,
-		aiClient: ai,
-		LogChan:  logChan,
+		cfg:          cfg,
+		db:           db,
+		aiClient:     ai,
+		wake:         make(chan struct{}, 1),
+		pending:      make(map[string]*pendingFile),
+		running:      make(map[string]*runningFile),
+		LogChan:      logChan,
+		ProgressChan: progressChan,
 	}
 }
 
@@ -51,18 +126,36 @@ func (o *Observer) log(format string, v ...interface{}) {
 	}
 }
 
-func (o *Observer) incrementTask() {
-	o.mu.Lock()
-	o.activeTasks++
-	o.mu.Unlock()
+// emit sends a progress event. Progress and warnings are dropped while the
+// buffer is full; the end of a job (done or failed) is never dropped, since
+// clients would show the job running forever.
+func (o *Observer) emit(p Progress) {
+	if o.ProgressChan == nil {
+		return
+	}
+	p.Time = time.Now()
+	if p.Type == EventDone || p.Type == EventFailed {
+		o.ProgressChan <- p
+		return
+	}
+	select {
+	case o.ProgressChan <- p:
+	default:
+	}
 }
 
-func (o *Observer) decrementTask() {
-	o.mu.Lock()
-	o.activeTasks--
-	o.mu.Unlock()
+// warn logs a failed step of a job and reports it as a warning event.
+func (o *Observer) warn(p *Progress, format string, v ...interface{}) {
+	o.log(format, v...)
+	w := *p
+	w.Type = EventWarning
+	w.Error = fmt.Sprintf(format, v...)
+	o.emit(w)
 }
 
+// Start watches the stage directory and runs the ingest workers until ctx is
+// done. It returns once the workers have stopped, so that a cancelled job has
+// removed what it wrote.
 func (o *Observer) Start(ctx context.Context) error {
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
@@ -88,13 +181,31 @@ func (o *Observer) Start(ctx context.Context) error {
 		}
 	}
 
-	err = watcher.Add(stageDir)
-	if err != nil {
+	if err := o.watchTree(watcher, stageDir); err != nil {
 		return err
 	}
 
 	o.log("Background observer started, watching: %s", stageDir)
 
+	// Resume the jobs abandoned by this or another instance, now and whenever
+	// a lease may have run out
+	o.resume()
+	resumeTicker := time.NewTicker(ingestLease / 2)
+	defer resumeTicker.Stop()
+
+	workers := o.cfg.Ingest.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	for i := 0; i < workers; i++ {
+		o.workers.Add(1)
+		go func() {
+			defer o.workers.Done()
+			o.worker(ctx)
+		}()
+	}
+	defer o.workers.Wait()
+
 	// Initial scan
 	o.scanDirectory(stageDir)
 
@@ -104,14 +215,17 @@ func (o *Observer) Start(ctx context.Context) error {
 			if !ok {
 				return nil
 			}
-			if event.Has(fsnotify.Write) || event.Has(fsnotify.Create) {
-				if strings.HasSuffix(strings.ToLower(event.Name), ".pptx") {
-					o.log("Detected change in: %s", event.Name)
-
-					// Debounce/delay for file transfer to complete
-					time.Sleep(2 * time.Second)
-					o.processFile(event.Name)
-				}
+			if !strings.HasSuffix(strings.ToLower(event.Name), ".pptx") {
+				o.handleDirEvent(watcher, event)
+				continue
+			}
+			switch {
+			case event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename):
+				// Renames report the old name; the new one, if still in
+				// stage, arrives as a Create
+				o.forget(event.Name)
+			case event.Has(fsnotify.Write) || event.Has(fsnotify.Create):
+				o.changed(event.Name)
 			}
 		case err, ok := <-watcher.Errors:
 			if !ok {
@@ -119,135 +233,696 @@ func (o *Observer) Start(ctx context.Context) error {
 			}
 			o.log("Watcher error: %v", err)
 
+		case <-resumeTicker.C:
+			o.resume()
+
 		case <-ctx.Done():
 			return nil
 		}
 	}
 }
 
+// scanDirectory schedules the decks in a directory tree.
 func (o *Observer) scanDirectory(dir string) {
-	files, err := os.ReadDir(dir)
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			o.log("Failed to scan directory: %v", err)
+			return nil
+		}
+		if d.IsDir() {
+			if path != dir && hiddenDir(d.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(strings.ToLower(d.Name()), ".pptx") {
+			o.schedule(path)
+		}
+		return nil
+	})
+}
+
+// watchTree watches a directory and its subdirectories. Only a failure to
+// watch root itself is returned.
+func (o *Observer) watchTree(watcher *fsnotify.Watcher, root string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			o.log("Failed to read %s: %v", path, err)
+			return nil
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != root && hiddenDir(d.Name()) {
+			return filepath.SkipDir
+		}
+		if err := watcher.Add(path); err != nil {
+			if path == root {
+				return err
+			}
+			o.log("Failed to watch %s: %v", path, err)
+		}
+		return nil
+	})
+}
+
+// handleDirEvent follows the folders of the stage tree: a new (or moved-in)
+// folder is watched and scanned, since the decks already inside raise no
+// events; a folder moved out is dropped with everything in it.
+func (o *Observer) handleDirEvent(watcher *fsnotify.Watcher, event fsnotify.Event) {
+	switch {
+	case event.Has(fsnotify.Create):
+		info, err := os.Stat(event.Name)
+		if err != nil || !info.IsDir() || hiddenDir(info.Name()) {
+			return
+		}
+		if err := o.watchTree(watcher, event.Name); err != nil {
+			o.log("Failed to watch %s: %v", event.Name, err)
+		}
+		o.scanDirectory(event.Name)
+	case event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename):
+		if !slices.Contains(watcher.WatchList(), event.Name) {
+			return
+		}
+		prefix := event.Name + string(filepath.Separator)
+		for _, w := range watcher.WatchList() {
+			if w == event.Name || strings.HasPrefix(w, prefix) {
+				watcher.Remove(w)
+			}
+		}
+		o.forgetTree(event.Name)
+	}
+}
+
+// hiddenDir reports whether a folder is skipped, e.g. .git or a sync tool's
+// temp folder.
+func hiddenDir(name string) bool {
+	return strings.HasPrefix(name, ".")
+}
+
+// changed handles a write to a staged file. A running job of the file would
+// mix old and new content, so it is cancelled; the file is queued again once
+// the job has unwound.
+func (o *Observer) changed(path string) {
+	o.mu.Lock()
+	rf := o.running[path]
+	wasDirty := rf != nil && rf.dirty
+	if rf != nil {
+		rf.dirty = true
+	}
+	o.mu.Unlock()
+
+	if rf != nil && !wasDirty {
+		o.log("%s changed during processing; cancelling its ingest", filepath.Base(path))
+		rf.cancel()
+	}
+	o.schedule(path)
+}
+
+// schedule (re)starts the debounce of a staged file; repeated events while it
+// settles are coalesced.
+func (o *Observer) schedule(path string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if p, ok := o.pending[path]; ok {
+		p.timer.Reset(settleDelay)
+		return
+	}
+	o.log("Detected change in: %s", path)
+	o.pending[path] = &pendingFile{
+		timer: time.AfterFunc(settleDelay, func() { o.settle(path) }),
+		since: time.Now(),
+	}
+}
+
+// settle queues a pending file once it is complete: its size and mtime are
+// unchanged since the last check and it opens as a zip. Otherwise the check
+// is repeated after another quiet period.
+func (o *Observer) settle(path string) {
+	info, err := os.Stat(path)
+	if err != nil {
+		o.forget(path)
+		return
+	}
+
+	o.mu.Lock()
+	p, ok := o.pending[path]
+	if !ok {
+		o.mu.Unlock()
+		return
+	}
+	stable := info.Size() == p.size && info.ModTime().Equal(p.modTime)
+	p.size, p.modTime = info.Size(), info.ModTime()
+	o.mu.Unlock()
+
+	var zipErr error
+	if stable {
+		zipErr = checkZip(path)
+		if zipErr == nil {
+			o.mu.Lock()
+			delete(o.pending, path)
+			o.mu.Unlock()
+			o.Enqueue(path)
+			return
+		}
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.pending[path] != p {
+		return // forgotten meanwhile
+	}
+	if time.Since(p.since) > maxSettleWait {
+		delete(o.pending, path)
+		if zipErr != nil {
+			o.log("Giving up on %s: not a valid PPTX after %v: %v", filepath.Base(path), maxSettleWait, zipErr)
+		} else {
+			o.log("Giving up on %s: still changing after %v", filepath.Base(path), maxSettleWait)
+		}
+		return
+	}
+	p.timer.Reset(settleDelay)
+}
+
+// checkZip reports whether a file is a complete zip archive, i.e. its
+// central directory reads.
+func checkZip(path string) error {
+	r, err := zip.OpenReader(path)
 	if err != nil {
-		o.log("Failed to scan directory: %v", err)
+		return err
+	}
+	return r.Close()
+}
+
+// forget drops a file that left the stage directory: its debounce stops, its
+// queued jobs fail and a running job is cancelled.
+func (o *Observer) forget(path string) {
+	o.mu.Lock()
+	if p, ok := o.pending[path]; ok {
+		p.timer.Stop()
+		delete(o.pending, path)
+	}
+	rf := o.running[path]
+	o.mu.Unlock()
+
+	if rf != nil {
+		o.log("%s left the stage directory; cancelling its ingest", filepath.Base(path))
+		rf.cancel()
+	}
+	if n, err := database.CancelQueuedIngestJobs(o.db, path, "removed from stage"); err != nil {
+		o.log("Failed to cancel ingest jobs of %s: %v", filepath.Base(path), err)
+	} else if n > 0 {
+		o.log("Cancelled %d queued ingest jobs of %s", n, filepath.Base(path))
+	}
+}
+
+// forgetTree forgets every file below a directory that left the stage.
+func (o *Observer) forgetTree(dir string) {
+	prefix := dir + string(filepath.Separator)
+	var cancels []context.CancelFunc
+	o.mu.Lock()
+	for path, p := range o.pending {
+		if strings.HasPrefix(path, prefix) {
+			p.timer.Stop()
+			delete(o.pending, path)
+		}
+	}
+	for path, rf := range o.running {
+		if strings.HasPrefix(path, prefix) {
+			cancels = append(cancels, rf.cancel)
+		}
+	}
+	o.mu.Unlock()
+
+	if len(cancels) > 0 {
+		o.log("%s left the stage directory; cancelling %d ingests", filepath.Base(dir), len(cancels))
+	}
+	for _, cancel := range cancels {
+		cancel()
+	}
+	if n, err := database.CancelQueuedIngestJobsUnder(o.db, dir, "removed from stage"); err != nil {
+		o.log("Failed to cancel ingest jobs under %s: %v", filepath.Base(dir), err)
+	} else if n > 0 {
+		o.log("Cancelled %d queued ingest jobs under %s", n, filepath.Base(dir))
+	}
+}
+
+// release stops tracking a running job's file, so that moving it out of
+// stage once processed does not cancel the job.
+func (o *Observer) release(path string) {
+	o.mu.Lock()
+	delete(o.running, path)
+	o.mu.Unlock()
+}
+
+// Enqueue queues a staged file for ingestion, wakes a worker and returns the
+// job ID.
+func (o *Observer) Enqueue(path string) (int, error) {
+	if _, err := os.Stat(path); err != nil {
+		return 0, err // moved or removed meanwhile
+	}
+	id, err := database.EnqueueIngestJob(o.db, path)
+	if err != nil {
+		o.log("Failed to queue %s: %v", filepath.Base(path), err)
+		return 0, err
+	}
+	o.log("Queued %s (job %d)", filepath.Base(path), id)
+	o.emit(Progress{Type: EventProgress, JobID: id, File: filepath.Base(path), Stage: database.JobQueued})
+	o.notify()
+	return id, nil
+}
+
+// resume requeues the running jobs whose lease ran out and wakes the workers.
+func (o *Observer) resume() {
+	maxAttempts := o.cfg.Ingest.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	n, err := database.ResumeIngestJobs(o.db, maxAttempts, ingestLease)
+	if err != nil {
+		o.log("Failed to resume ingest jobs: %v", err)
+		return
+	}
+	if n > 0 {
+		o.log("Resuming %d abandoned ingest jobs", n)
+		o.notify()
+	}
+}
+
+// notify wakes one idle worker, if any.
+func (o *Observer) notify() {
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+// worker claims and runs queued jobs until ctx is done.
+func (o *Observer) worker(ctx context.Context) {
+	ticker := time.NewTicker(ingestPollInterval)
+	defer ticker.Stop()
+	for {
+		job, err := database.ClaimIngestJob(o.db)
+		if err == nil {
+			// More jobs may be queued; let another idle worker look
+			o.notify()
+			o.runJob(ctx, job)
+			continue
+		}
+		if err != sql.ErrNoRows {
+			o.log("Failed to claim ingest job: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-o.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// runJob processes a claimed job and records the outcome. A job cut short by
+// shutdown stays unfinished and is resumed once its lease runs out; one whose
+// file left the stage directory fails, and one whose file changed is queued
+// again. A job that lost its lease is left to whoever took it over.
+func (o *Observer) runJob(ctx context.Context, job *database.IngestJob) {
+	p := &Progress{Type: EventProgress, JobID: job.ID, File: filepath.Base(job.FilePath), Stage: job.State}
+	o.emit(*p)
+
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	rf := &runningFile{cancel: cancel}
+	o.mu.Lock()
+	o.running[job.FilePath] = rf
+	o.mu.Unlock()
+	defer o.release(job.FilePath)
+
+	var lost atomic.Bool
+	go o.heartbeat(jobCtx, job, cancel, &lost)
+
+	fileID, err := o.processFile(jobCtx, job, p)
+	if lost.Load() || (err != nil && ctx.Err() != nil) {
 		return
 	}
+	o.mu.Lock()
+	dirty := rf.dirty
+	o.mu.Unlock()
+	switch {
+	case dirty:
+		// Queued once this job is finished, so the new job is not merged
+		// into it
+		defer o.schedule(job.FilePath)
+		if err != nil {
+			err = fmt.Errorf("%s changed during processing; it is queued again", p.File)
+		}
+	case jobCtx.Err() != nil:
+		err = fmt.Errorf("%s was removed from stage during processing", p.File)
+	}
+	p.PPTXFileID = fileID
+	if err != nil {
+		o.log("Failed to ingest %s: %v", p.File, err)
+		p.Type, p.Stage, p.Error = EventFailed, database.JobFailed, err.Error()
+	} else {
+		p.Type, p.Stage = EventDone, database.JobDone
+	}
+	if err := database.FinishIngestJob(o.db, job.ID, fileID, err); err != nil {
+		o.log("Failed to record ingest job %d: %v", job.ID, err)
+	}
+	o.emit(*p)
+}
 
-	for _, f := range files {
-		if !f.IsDir() && strings.HasSuffix(strings.ToLower(f.Name()), ".pptx") {
-			fullPath := filepath.Join(dir, f.Name())
-			o.processFile(fullPath)
+// heartbeat renews the lease of a running job until ctx is done. If the job
+// was taken back meanwhile, lost is set and the job cancelled.
+func (o *Observer) heartbeat(ctx context.Context, job *database.IngestJob, cancel context.CancelFunc, lost *atomic.Bool) {
+	ticker := time.NewTicker(ingestHeartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		held, err := database.TouchIngestJob(o.db, job.ID, job.Attempts)
+		if err != nil {
+			o.log("Failed to renew ingest job %d: %v", job.ID, err)
+			continue
 		}
+		if !held {
+			o.log("Ingest job %d lost its lease; cancelling it", job.ID)
+			lost.Store(true)
+			cancel()
+			return
+		}
+	}
+}
+
+// setState records and reports the stage a job has reached.
+func (o *Observer) setState(p *Progress, state string) {
+	p.Stage = state
+	if err := database.UpdateIngestJobState(o.db, p.JobID, state); err != nil {
+		o.log("Failed to update ingest job %d: %v", p.JobID, err)
+	}
+	o.emit(*p)
+}
+
+// thumbnailURL maps a file in the thumbnails storage to its /thumbnails/ URL.
+func (o *Observer) thumbnailURL(path string) string {
+	rel, err := filepath.Rel(o.cfg.Application.Storage.Thumbnails, path)
+	if err != nil {
+		rel = path
 	}
+	return "/thumbnails/" + filepath.ToSlash(rel)
 }
 
-func (o *Observer) processFile(path string) {
-	o.incrementTask()
-	defer o.decrementTask()
+// stageFolder returns the folder of a staged file relative to the stage
+// directory, slash-separated; "" for the stage root.
+func (o *Observer) stageFolder(path string) string {
+	rel, err := filepath.Rel(o.cfg.Application.Storage.Stage, filepath.Dir(path))
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return ""
+	}
+	return filepath.ToSlash(rel)
+}
+
+// versionName is the name of a version's thumbnail folder and template file:
+// the deck's own name for version 1, name.vN for later versions.
+func versionName(name string, version int) string {
+	if version <= 1 {
+		return name
+	}
+	return fmt.Sprintf("%s.v%d", name, version)
+}
 
+// processFile ingests the file of a job and returns the ID of its pptx_files
+// record.
+func (o *Observer) processFile(ctx context.Context, job *database.IngestJob, p *Progress) (int, error) {
+	path := job.FilePath
 	filename := filepath.Base(path)
-	o.log("Processing file: %s", filename)
+	if _, err := os.Stat(path); err != nil {
+		return 0, err
+	}
+	o.log("Processing file: %s (job %d, attempt %d)", filename, job.ID, job.Attempts)
+
+	// A previous attempt may have been interrupted halfway; its version
+	// would otherwise pass for a duplicate of the file
+	if job.Attempts > 1 {
+		discarded, err := database.DiscardIngestJobVersion(o.db, job.ID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to discard the partial ingest of %s: %v", filename, err)
+		}
+		if discarded {
+			o.log("Discarded the partial ingest of %s left by attempt %d", filename, job.Attempts-1)
+		}
+	}
+
+	// Calculate Checksum (SHA256)
+	fileBytes, err := os.ReadFile(path)
+	checksum := ""
+	if err == nil {
+		hash := sha256.Sum256(fileBytes)
+		checksum = hex.EncodeToString(hash[:])
+	} else {
+		o.warn(p, "Failed to read file for checksum %s: %v", filename, err)
+	}
+
+	// An identical file is already a version of some deck; idempotency:
+	// don't reprocess it
+	if checksum != "" {
+		v, err := database.FindPPTXVersionByChecksum(o.db, checksum)
+		if err == nil {
+			o.warn(p, "%s is identical to version %d of file %d, skipping", filename, v.Version, v.PPTXFileID)
+			return v.PPTXFileID, nil
+		} else if err != sql.ErrNoRows {
+			return 0, fmt.Errorf("DB error checking existing file: %v", err)
+		}
+	}
 
-	// Extract Tags
-	tags, err := pptx.ExtractTags(path)
+	// A changed file with the name of a deck in the same folder becomes the
+	// next version of that deck
+	folder := o.stageFolder(path)
+	version := 1
+	fileID, err := database.FindPPTXDocument(o.db, folder, filename)
+	switch {
+	case err == sql.ErrNoRows:
+		// A new deck
+	case err != nil:
+		return 0, fmt.Errorf("DB error checking existing file: %v", err)
+	default:
+		if version, err = database.NextPPTXVersion(o.db, fileID); err != nil {
+			return 0, fmt.Errorf("DB error numbering version of %s: %v", filename, err)
+		}
+	}
+
+	// Extract template schema (value tags, loops, conditionals)
+	schema, err := pptx.ExtractTemplateSchema(path)
 	if err != nil {
-		o.log("Failed to extract tags from %s: %v", filename, err)
+		o.warn(p, "Failed to extract template schema from %s: %v", filename, err)
+		schema = &pptx.TemplateSchema{}
 	}
 
 	metadata := map[string]interface{}{
-		"tags":         tags,
+		"template":     schema,
 		"processed_at": time.Now().Format(time.RFC3339),
 	}
+
+	// Theme palette and fonts
+	if theme, err := pptx.ExtractTheme(path); err == nil {
+		metadata["theme"] = theme
+	} else {
+		o.warn(p, "Failed to extract theme from %s: %v", filename, err)
+	}
 	metadataJSON, _ := json.Marshal(metadata)
 
+	schemaJSON := []byte("{}")
+	if !schema.IsEmpty() {
+		schemaJSON, _ = json.Marshal(schema.JSONSchema())
+	}
+
 	// Thumbnails directory
-	cleanFilename := strings.TrimSuffix(filename, filepath.Ext(filename))
-	thumbDir := filepath.Join(o.cfg.Application.Storage.Thumbnails, cleanFilename)
+	// Stage subfolders (e.g. client/project) are kept for thumbnails and the
+	// template directory, so equally named decks in different folders do not
+	// collide; each version has its own
+	cleanFilename := versionName(strings.TrimSuffix(filename, filepath.Ext(filename)), version)
+	thumbDirPath := filepath.Join(filepath.FromSlash(folder), cleanFilename)
+	thumbDir := filepath.Join(o.cfg.Application.Storage.Thumbnails, thumbDirPath)
 
 	// Create thumbnails
-	pngFiles, err := pptx.ExtractSlidesToPNG(path, thumbDir)
+	pngFiles, err := pptx.ExtractSlidesToPNGWithOptions(ctx, path, thumbDir, pptx.RenderOptions{CacheDir: o.cfg.Application.Storage.PDFCache})
 	if err != nil {
-		o.log("Failed to extract thumbnails from %s: %v", filename, err)
+		o.warn(p, "Failed to extract thumbnails from %s: %v", filename, err)
+	}
+
+	// Renditions (thumbnail, preview, full size) from the same cached PDF
+	var renditionSpecs []pptx.Rendition
+	for _, rc := range o.cfg.Renditions {
+		renditionSpecs = append(renditionSpecs, pptx.Rendition(rc))
+	}
+	rendered, err := pptx.RenderRenditions(ctx, path, thumbDir, pptx.RenderOptions{CacheDir: o.cfg.Application.Storage.PDFCache}, renditionSpecs)
+	if err != nil {
+		o.warn(p, "Failed to render renditions of %s: %v", filename, err)
+	}
+	renditionsByPage := make(map[int][]database.SlideRendition)
+	for _, img := range rendered {
+		renditionsByPage[img.Page] = append(renditionsByPage[img.Page], database.SlideRendition{
+			Name:      img.Name,
+			Format:    img.Format,
+			Path:      o.thumbnailURL(img.Path),
+			Width:     img.Width,
+			Height:    img.Height,
+			SizeBytes: img.Size,
+			SHA256:    img.SHA256,
+		})
 	}
 
 	// Extract Slide Content (Text & Styles)
+	o.setState(p, database.JobExtracting)
 	slideDataMap, err := pptx.ExtractSlideContent(path)
 	if err != nil {
-		o.log("Failed to extract slide content from %s: %v", filename, err)
+		o.warn(p, "Failed to extract slide content from %s: %v", filename, err)
 	}
 
-	// Calculate Checksum (SHA256)
-	fileBytes, err := os.ReadFile(path)
-	checksum := ""
-	if err == nil {
-		hash := sha256.Sum256(fileBytes)
-		checksum = hex.EncodeToString(hash[:])
-	} else {
-		o.log("Failed to read file for checksum %s: %v", filename, err)
+	// Nothing is written once the file has left the stage
+	if err := ctx.Err(); err != nil {
+		return 0, err
 	}
 
 	// Database persist
 	pptxFile := &database.PPTXFile{
 		Filename:         filename,
 		OriginalFilePath: path,
-		ThumbnailDirPath: cleanFilename,
+		ThumbnailDirPath: filepath.ToSlash(thumbDirPath),
+		Folder:           folder,
 		Metadata:         metadataJSON,
-		IsTemplate:       len(tags) > 0,
+		IsTemplate:       !schema.IsEmpty(),
 		Checksum:         checksum,
+		TemplateSchema:   schemaJSON,
 	}
 
-	// Check for existing file by Checksum
-	var existingID int
-	// First check strictly by checksum if valid
-	if checksum != "" {
-		err = o.db.QueryRow("SELECT id FROM pptx_files WHERE checksum = $1", checksum).Scan(&existingID)
-		if err == nil {
-			o.log("File %s (checksum: %s) already exists (ID: %d). Skipping duplicate processing.", filename, checksum, existingID)
-			return // idempotency: don't reprocess identical files
-		}
-	}
-
-	// Fallback to filename/path check if checksum logic didn't hit (e.g., checksum empty or not found)
-	if existingID == 0 {
-		err = o.db.QueryRow("SELECT id FROM pptx_files WHERE filename = $1 AND original_file_path = $2", filename, path).Scan(&existingID)
+	// Document properties (docProps/core.xml, docProps/app.xml)
+	if props, err := pptx.ExtractDocProps(path); err == nil {
+		pptxFile.Title = props.Title
+		pptxFile.Author = props.Author
+		pptxFile.LastModifiedBy = props.LastModifiedBy
+		pptxFile.DocCreatedAt = props.Created
+		pptxFile.DocModifiedAt = props.Modified
+		pptxFile.Company = props.Company
+		pptxFile.Revision = props.Revision
+		pptxFile.SlideCount = props.SlideCount
+		pptxFile.Application = props.Application
+	} else {
+		o.warn(p, "Failed to read document properties of %s: %v", filename, err)
 	}
 
-	var fileID int
-	if err == sql.ErrNoRows || existingID == 0 {
+	created := false
+	if fileID == 0 {
 		fileID, err = database.SavePPTXMetadata(o.db, pptxFile)
 		if err != nil {
-			o.log("Failed to save metadata to DB: %v", err)
-			return
+			return 0, fmt.Errorf("failed to save metadata to DB: %v", err)
 		}
-	} else if err == nil {
-		fileID = existingID
-		// Update existing (e.g. metadata or checksum if it was empty)
-		_, err = o.db.Exec("UPDATE pptx_files SET metadata = $1, is_template = $2, thumbnail_dir_path = $3, checksum = $4 WHERE id = $5",
-			pptxFile.Metadata, pptxFile.IsTemplate, pptxFile.ThumbnailDirPath, pptxFile.Checksum, fileID)
-		if err != nil {
-			o.log("Failed to update metadata in DB: %v", err)
+		created = true
+	}
+	pptxFile.ID = fileID
+	versionID, err := database.SavePPTXVersion(o.db, &database.PPTXVersion{
+		PPTXFileID:       fileID,
+		Version:          version,
+		Checksum:         checksum,
+		OriginalFilePath: path,
+		ThumbnailDirPath: pptxFile.ThumbnailDirPath,
+		Title:            pptxFile.Title,
+		Author:           pptxFile.Author,
+		LastModifiedBy:   pptxFile.LastModifiedBy,
+		DocModifiedAt:    pptxFile.DocModifiedAt,
+		Revision:         pptxFile.Revision,
+		SlideCount:       pptxFile.SlideCount,
+	})
+	if err != nil {
+		if created {
+			database.DeletePPTX(o.db, fileID)
+		}
+		return 0, fmt.Errorf("failed to save version to DB: %v", err)
+	}
+	// Don't leave a half-ingested deck or version behind
+	discard := func() {
+		if created {
+			if err := database.DeletePPTX(o.db, fileID); err != nil {
+				o.log("Failed to remove partially ingested %s: %v", filename, err)
+			}
+		} else if err := database.DeletePPTXVersion(o.db, versionID); err != nil {
+			o.log("Failed to remove partially ingested version %d of %s: %v", version, filename, err)
+		}
+	}
+	if err := database.SetIngestJobVersion(o.db, job.ID, fileID, versionID, created); err != nil {
+		discard()
+		return 0, fmt.Errorf("failed to record version in ingest job: %v", err)
+	}
+	// A new deck shows its slides as they are saved; a new version replaces
+	// the current one only once it is complete
+	if created {
+		if err := database.SetCurrentPPTXVersion(o.db, fileID, versionID); err != nil {
+			o.warn(p, "Failed to set current version of %s: %v", filename, err)
 		}
-
-		// If we are updating, we MIGHT want to reprocess slides if forced, but for now we assume simple idempotency
-		// For safety, let's delete existing slides so we don't duplicate them if we continue
-		_, _ = o.db.Exec("DELETE FROM collected_slides WHERE pptx_file_id = $1", fileID)
 	} else {
-		o.log("DB error checking existing file: %v", err)
-		return
+		o.log("%s is version %d of file %d", filename, version, fileID)
 	}
 
 	// Save slides and collect summaries
 	var slideSummaries []string
-	ctx := context.Background()
 
-	for i, png := range pngFiles {
-		slideNum := i + 1
+	// Layout catalog, linked from the slides below
+	var layouts []database.SlideLayout
+	if deckLayouts, err := pptx.ExtractLayouts(path); err == nil {
+		for _, l := range deckLayouts {
+			placeholders, _ := json.Marshal(l.Placeholders)
+			layouts = append(layouts, database.SlideLayout{
+				PartName:     l.Part,
+				Name:         l.Name,
+				LayoutType:   l.Type,
+				MasterPart:   l.Master,
+				MasterName:   l.MasterName,
+				Placeholders: placeholders,
+			})
+		}
+	} else {
+		o.warn(p, "Failed to extract layouts from %s: %v", filename, err)
+	}
+	layoutIDs, err := database.SaveSlideLayouts(o.db, fileID, versionID, layouts)
+	if err != nil {
+		o.warn(p, "Failed to save layouts of %s: %v", filename, err)
+	}
+
+	// Pair pages with slides by presentation order
+	thumbs := pptx.SlideThumbnails(slideDataMap, pngFiles)
+	slideCount := len(slideDataMap)
+	if len(pngFiles) > slideCount {
+		slideCount = len(pngFiles)
+	}
+
+	o.setState(p, database.JobSummarizing)
+	p.Slides = slideCount
+	for slideNum := 1; slideNum <= slideCount && ctx.Err() == nil; slideNum++ {
+		p.Slide = slideNum
+		o.emit(*p)
+
+		png, ok := thumbs[slideNum]
+		if !ok {
+			o.warn(p, "No thumbnail for slide %d of %s, skipping", slideNum, filename)
+			continue
+		}
 		relPath, err := filepath.Rel(o.cfg.Application.Storage.Thumbnails, png)
 		if err != nil {
-			o.log("Failed to get relative path for %s: %v", png, err)
+			o.warn(p, "Failed to get relative path for %s: %v", png, err)
 			relPath = png // fallback
 		}
 
 		content := ""
+		notes := ""
+		section := ""
+		hidden := false
+		var layoutID *int
+		var media []database.SlideMedia
 		styleJSON := []byte("{}")
 		slideSummary := ""
 
@@ -255,6 +930,24 @@ func (o *Observer) processFile(path string) {
 
 		if data, ok := slideDataMap[slideNum]; ok {
 			content = data.Text
+			notes = data.Notes
+			section = data.Section
+			hidden = data.Hidden
+			if id, ok := layoutIDs[data.Layout]; ok {
+				layoutID = &id
+			}
+			for _, m := range data.Media {
+				media = append(media, database.SlideMedia{
+					PartName:    m.Part,
+					RelID:       m.RelID,
+					MediaType:   m.Kind,
+					ContentType: m.ContentType,
+					SizeBytes:   m.Size,
+					SHA256:      m.SHA256,
+					Width:       m.Width,
+					Height:      m.Height,
+				})
+			}
 			if sj, err := json.Marshal(data.Styles); err == nil {
 				styleJSON = sj
 			}
@@ -263,34 +956,75 @@ func (o *Observer) processFile(path string) {
 			if content != "" {
 				// Summary
 				summary, err := o.aiClient.SummarizeText(ctx, content)
+				p.AICalls++
 				if err == nil {
 					slideSummary = summary
 					slideSummaries = append(slideSummaries, summary)
 				} else {
-					o.log("Failed to summarize slide %d of %s: %v", slideNum, filename, err)
+					o.warn(p, "Failed to summarize slide %d of %s: %v", slideNum, filename, err)
 				}
 
 				// Title
 				rawTitle, err := o.aiClient.ExtractSlideTitle(ctx, content)
+				p.AICalls++
 				if err == nil && rawTitle != "" {
 					slideTitle = fmt.Sprintf("%d. %s", slideNum, rawTitle)
 				}
 			}
 		}
 
-		err = database.SaveSlide(o.db, &database.Slide{
+		slide := &database.Slide{
 			PPTXFileID: fileID,
+			VersionID:  &versionID,
 			SlideNum:   slideNum,
 			PNGPath:    "/thumbnails/" + relPath,
 			Content:    content,
+			Notes:      notes,
+			Section:    section,
+			Hidden:     hidden,
+			LayoutID:   layoutID,
 			StyleInfo:  styleJSON,
 			AIAnalysis: []byte("{}"),
 			AISummary:  slideSummary,
 			Title:      slideTitle,
-		})
+		}
+		if err := database.SaveSlide(o.db, slide); err != nil {
+			o.warn(p, "Failed to save slide %d: %v", slideNum, err)
+			continue
+		}
+		if err := database.SaveSlideMedia(o.db, slide, media); err != nil {
+			o.warn(p, "Failed to save media of slide %d: %v", slideNum, err)
+		}
+		if err := database.SaveSlideRenditions(o.db, slide, renditionsByPage[pptx.ThumbnailPage(png)]); err != nil {
+			o.warn(p, "Failed to save renditions of slide %d: %v", slideNum, err)
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		discard()
+		return 0, err
+	}
+	if !created {
+		// The file record describes its current version
+		_, err = o.db.Exec("UPDATE pptx_files SET original_file_path = $1, metadata = $2, is_template = $3, thumbnail_dir_path = $4, checksum = $5, template_schema = $6 WHERE id = $7",
+			path, pptxFile.Metadata, pptxFile.IsTemplate, pptxFile.ThumbnailDirPath, pptxFile.Checksum, pptxFile.TemplateSchema, fileID)
 		if err != nil {
-			o.log("Failed to save slide %d: %v", slideNum, err)
+			o.warn(p, "Failed to update metadata in DB: %v", err)
 		}
+		if err := database.UpdatePPTXDocProps(o.db, pptxFile); err != nil {
+			o.warn(p, "Failed to update document properties in DB: %v", err)
+		}
+		if pptxFile.Title != "" {
+			database.UpdatePPTXTitle(o.db, fileID, pptxFile.Title)
+		}
+		if err := database.SetCurrentPPTXVersion(o.db, fileID, versionID); err != nil {
+			o.warn(p, "Failed to set current version of %s: %v", filename, err)
+		}
+	}
+	if err := database.CompleteIngestJobVersion(o.db, job.ID); err != nil {
+		o.warn(p, "Failed to record ingest job %d: %v", job.ID, err)
+	}
+	if err := database.UpdateLayoutThumbnails(o.db, versionID); err != nil {
+		o.warn(p, "Failed to update layout thumbnails of %s: %v", filename, err)
 	}
 
 	// Generate and save presentation summary & title
@@ -298,53 +1032,82 @@ func (o *Observer) processFile(path string) {
 		// Summary
 		fullTextForSummary := strings.Join(slideSummaries, "\n")
 		overallSummary, err := o.aiClient.SummarizeText(ctx, "This is a summary of all slides in a presentation. Please provide a high-level summary of the entire deck: \n"+fullTextForSummary)
+		p.AICalls++
 		if err == nil {
 			database.UpdatePPTXSummary(o.db, fileID, overallSummary)
 		} else {
-			o.log("Failed to generate overall summary for %s: %v", filename, err)
+			o.warn(p, "Failed to generate overall summary for %s: %v", filename, err)
 		}
 
-		// Title
-		if data, ok := slideDataMap[1]; ok && data.Text != "" {
+		// Title, unless the deck has one in its document properties
+		if data, ok := slideDataMap[1]; ok && data.Text != "" && pptxFile.Title == "" {
 			title, err := o.aiClient.ExtractTitle(ctx, data.Text)
+			p.AICalls++
 			if err == nil && title != "" {
 				database.UpdatePPTXTitle(o.db, fileID, title)
 			}
 		}
 	}
 
-	o.log("Successfully processed: %s (Tags: %v)", filename, tags)
+	o.log("Successfully processed: %s (Tags: %v, Loops: %d, Conditionals: %d)", filename, schema.Tags, len(schema.Loops), len(schema.Conditionals))
 
-	// Move file to Template directory
+	// Move file to Template directory; the move is not a removal from stage
+	o.release(path)
 	if o.cfg.Application.Storage.Template != "" {
-		newPath := filepath.Join(o.cfg.Application.Storage.Template, filename)
-		err := os.Rename(path, newPath)
+		newPath := filepath.Join(o.cfg.Application.Storage.Template, filepath.FromSlash(folder), cleanFilename+filepath.Ext(filename))
+		err := os.MkdirAll(filepath.Dir(newPath), 0755)
+		if err == nil {
+			if o.unchanged(path, checksum) {
+				err = os.Rename(path, newPath)
+			} else {
+				// Written again since it was read: archive the content this
+				// version was made from and leave the new one to its own job
+				o.log("%s changed during processing; keeping it staged", filename)
+				err = os.WriteFile(newPath, fileBytes, 0644)
+			}
+		}
 		if err != nil {
-			o.log("Failed to move %s to template folder: %v", filename, err)
+			o.warn(p, "Failed to move %s to template folder: %v", filename, err)
 		} else {
 			o.log("Moved %s to %s", filename, newPath)
 
 			// Update database path
 			_, err := o.db.Exec("UPDATE pptx_files SET original_file_path = $1 WHERE id = $2", newPath, fileID)
+			if err == nil {
+				err = database.UpdatePPTXVersionPath(o.db, versionID, newPath)
+			}
 			if err != nil {
-				o.log("Failed to update file path in DB: %v", err)
+				o.warn(p, "Failed to update file path in DB: %v", err)
 			}
 		}
 	}
+	return fileID, nil
 }
 
-func (o *Observer) ReprocessAll() {
-	o.incrementTask()
-	defer o.decrementTask()
+// unchanged reports whether a staged file still has the given checksum. An
+// unknown checksum or an unreadable file counts as unchanged.
+func (o *Observer) unchanged(path, checksum string) bool {
+	if checksum == "" {
+		return true
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return true
+	}
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:]) == checksum
+}
 
+// ReprocessAll queues every file in the stage directory.
+func (o *Observer) ReprocessAll() {
 	stageDir := o.cfg.Application.Storage.Stage
 	o.log("Retriggering full scan of %s", stageDir)
 
 	o.scanDirectory(stageDir)
 }
 
+// IsProcessing reports whether ingest jobs are queued or running.
 func (o *Observer) IsProcessing() bool {
-	o.mu.Lock()
-	defer o.mu.Unlock()
-	return o.activeTasks > 0
+	n, err := database.CountActiveIngestJobs(o.db)
+	return err == nil && n > 0
 }
@@ -0,0 +1,338 @@
+// Package pdf writes PDF documents from rendered slide images: one slide per
+// page, or handouts with several slides and their speaker notes per page.
+// Text uses the standard Helvetica fonts, so no fonts are embedded.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf16"
+)
+
+// Layouts of an export.
+const (
+	LayoutSlides  = "slides"  // one slide per page, the page sized to the slide
+	LayoutHandout = "handout" // PerPage slides per A4 page with their notes
+)
+
+// MaxPerPage bounds the slides on a handout page.
+const MaxPerPage = 6
+
+// Slide is a rendered slide to export.
+type Slide struct {
+	Image string // PNG or JPEG file
+	Label string // handout caption, e.g. "deck.pptx - 3"
+	Notes string // speaker notes, printed next to the slide in handouts
+}
+
+// Options of an export. Zero values take the defaults.
+type Options struct {
+	Layout  string // LayoutSlides (default) or LayoutHandout
+	PerPage int    // handout slides per page, 1-MaxPerPage (default 3)
+	Title   string // document title; also the handout header
+}
+
+const (
+	slidePageWidth = 792.0 // 11 in; the height follows the slide's aspect ratio
+	a4Width        = 595.0
+	a4Height       = 842.0
+	margin         = 36.0
+)
+
+// Write renders the slides as a PDF document.
+func Write(out io.Writer, slides []Slide, opts Options) error {
+	if len(slides) == 0 {
+		return fmt.Errorf("no slides to export")
+	}
+	if opts.Layout == "" {
+		opts.Layout = LayoutSlides
+	}
+	if opts.Layout != LayoutSlides && opts.Layout != LayoutHandout {
+		return fmt.Errorf("unknown layout %q (want slides or handout)", opts.Layout)
+	}
+	if opts.PerPage <= 0 {
+		opts.PerPage = 3
+	}
+	if opts.PerPage > MaxPerPage {
+		opts.PerPage = MaxPerPage
+	}
+
+	w := newWriter()
+	images := make([]pdfImage, len(slides))
+	for i, s := range slides {
+		img, err := w.addImage(s.Image)
+		if err != nil {
+			return fmt.Errorf("slide %d: %v", i+1, err)
+		}
+		images[i] = img
+	}
+
+	if opts.Layout == LayoutSlides {
+		for i, img := range images {
+			height := slidePageWidth * float64(img.height) / float64(img.width)
+			var c content
+			c.image(img, 0, 0, slidePageWidth, height)
+			w.addPage(slidePageWidth, height, c.Bytes(), images[i:i+1])
+		}
+	} else {
+		pages := (len(slides) + opts.PerPage - 1) / opts.PerPage
+		for p := 0; p < pages; p++ {
+			from := p * opts.PerPage
+			to := min(from+opts.PerPage, len(slides))
+			c := handoutPage(slides[from:to], images[from:to], opts, p+1, pages)
+			w.addPage(a4Width, a4Height, c.Bytes(), images[from:to])
+		}
+	}
+
+	_, err := out.Write(w.finish(opts.Title))
+	return err
+}
+
+// handoutPage lays out up to PerPage slides in rows: the slide on the left,
+// its caption and notes on the right (ruled lines when it has no notes).
+func handoutPage(slides []Slide, images []pdfImage, opts Options, page, pages int) *content {
+	c := &content{}
+	top := a4Height - margin
+	if opts.Title != "" {
+		c.text(fontBold, 11, margin, top-11, opts.Title)
+		top -= 24
+	}
+	footer := fmt.Sprintf("%d / %d", page, pages)
+	c.text(fontRegular, 8, (a4Width-textWidth(footer, 8))/2, margin/2, footer)
+
+	rowHeight := (top - margin) / float64(opts.PerPage)
+	imageMaxWidth := (a4Width - 2*margin) * 0.5
+	notesX := margin + imageMaxWidth + 14
+	notesWidth := a4Width - margin - notesX
+
+	for i, s := range slides {
+		rowTop := top - float64(i)*rowHeight
+		rowBottom := rowTop - rowHeight + 10
+
+		img := images[i]
+		h := rowHeight - 10
+		wd := h * float64(img.width) / float64(img.height)
+		if wd > imageMaxWidth {
+			wd = imageMaxWidth
+			h = wd * float64(img.height) / float64(img.width)
+		}
+		c.image(img, margin, rowTop-h, wd, h)
+		c.frame(margin, rowTop-h, wd, h)
+
+		y := rowTop - 9
+		if s.Label != "" {
+			c.text(fontBold, 9, notesX, y, s.Label)
+			y -= 14
+		}
+		notes := strings.TrimSpace(s.Notes)
+		if notes == "" {
+			for ; y > rowBottom; y -= 18 {
+				c.rule(notesX, y-4, notesWidth)
+			}
+			continue
+		}
+		lines := wrapText(notes, 9, notesWidth)
+		for j, line := range lines {
+			if y-11 < rowBottom && j < len(lines)-1 {
+				c.text(fontRegular, 9, notesX, y, truncate(line, 9, notesWidth))
+				break
+			}
+			c.text(fontRegular, 9, notesX, y, line)
+			y -= 11
+		}
+	}
+	return c
+}
+
+// pdfImage is an image XObject.
+type pdfImage struct {
+	name          string // resource name, e.g. Im3
+	obj           int
+	width, height int
+}
+
+// writer assembles the objects of a document. Objects 1-4 are the catalog,
+// the page tree and the two fonts.
+type writer struct {
+	buf     bytes.Buffer
+	offsets []int
+	pages   []int
+}
+
+const (
+	catalogObj = 1
+	pagesObj   = 2
+	fontObj    = 3
+	boldObj    = 4
+)
+
+func newWriter() *writer {
+	w := &writer{offsets: make([]int, 4)}
+	w.buf.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+	w.object(fontObj, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding "+fontEncoding+" >>")
+	w.object(boldObj, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding "+fontEncoding+" >>")
+	return w
+}
+
+// reserve allocates an object number.
+func (w *writer) reserve() int {
+	w.offsets = append(w.offsets, 0)
+	return len(w.offsets)
+}
+
+func (w *writer) object(n int, body string) {
+	w.offsets[n-1] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n%s\nendobj\n", n, body)
+}
+
+func (w *writer) stream(n int, dict string, data []byte) {
+	w.offsets[n-1] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n<< %s /Length %d >>\nstream\n", n, dict, len(data))
+	w.buf.Write(data)
+	w.buf.WriteString("\nendstream\nendobj\n")
+}
+
+// addImage embeds a JPEG as is and any other image as deflated RGB, with
+// transparency flattened onto white.
+func (w *writer) addImage(path string) (pdfImage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return pdfImage{}, err
+	}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return pdfImage{}, fmt.Errorf("unsupported image %s: %v", path, err)
+	}
+	n := w.reserve()
+	img := pdfImage{name: fmt.Sprintf("Im%d", n), obj: n, width: cfg.Width, height: cfg.Height}
+	dims := fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /BitsPerComponent 8", cfg.Width, cfg.Height)
+
+	if format == "jpeg" && cfg.ColorModel != color.CMYKModel {
+		space := "/DeviceRGB"
+		if cfg.ColorModel == color.GrayModel {
+			space = "/DeviceGray"
+		}
+		w.stream(n, dims+" /ColorSpace "+space+" /Filter /DCTDecode", data)
+		return img, nil
+	}
+
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return pdfImage{}, err
+	}
+	b := decoded.Bounds()
+	rgb := make([]byte, 0, b.Dx()*b.Dy()*3)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, a := decoded.At(x, y).RGBA()
+			white := 0xFFFF - a // premultiplied: add white for the transparent part
+			rgb = append(rgb, uint8((r+white)>>8), uint8((g+white)>>8), uint8((bl+white)>>8))
+		}
+	}
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	zw.Write(rgb)
+	zw.Close()
+	w.stream(n, dims+" /ColorSpace /DeviceRGB /Filter /FlateDecode", z.Bytes())
+	return img, nil
+}
+
+// addPage adds a page with a content stream drawing the given images.
+func (w *writer) addPage(width, height float64, content []byte, images []pdfImage) {
+	contentObj := w.reserve()
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	zw.Write(content)
+	zw.Close()
+	w.stream(contentObj, "/Filter /FlateDecode", z.Bytes())
+
+	var xobjects strings.Builder
+	for _, img := range images {
+		fmt.Fprintf(&xobjects, "/%s %d 0 R ", img.name, img.obj)
+	}
+	pageObj := w.reserve()
+	w.object(pageObj, fmt.Sprintf(
+		"<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] /Contents %d 0 R /Resources << /Font << /F1 %d 0 R /F2 %d 0 R >> /XObject << %s>> >> >>",
+		pagesObj, num(width), num(height), contentObj, fontObj, boldObj, xobjects.String()))
+	w.pages = append(w.pages, pageObj)
+}
+
+// finish writes the page tree, catalog, info, cross-reference table and
+// trailer, and returns the document.
+func (w *writer) finish(title string) []byte {
+	var kids strings.Builder
+	for _, p := range w.pages {
+		fmt.Fprintf(&kids, "%d 0 R ", p)
+	}
+	w.object(pagesObj, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", kids.String(), len(w.pages)))
+	w.object(catalogObj, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesObj))
+	info := w.reserve()
+	infoDict := "<< /Producer (SlideForge)"
+	if title != "" {
+		infoDict += " /Title " + textString(title)
+	}
+	w.object(info, infoDict+" >>")
+
+	xref := w.buf.Len()
+	fmt.Fprintf(&w.buf, "xref\n0 %d\n0000000000 65535 f \n", len(w.offsets)+1)
+	for _, off := range w.offsets {
+		fmt.Fprintf(&w.buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&w.buf, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(w.offsets)+1, catalogObj, info, xref)
+	return w.buf.Bytes()
+}
+
+// content is a page content stream.
+type content struct {
+	bytes.Buffer
+}
+
+const (
+	fontRegular = "F1"
+	fontBold    = "F2"
+)
+
+func (c *content) image(img pdfImage, x, y, w, h float64) {
+	fmt.Fprintf(c, "q %s 0 0 %s %s %s cm /%s Do Q\n", num(w), num(h), num(x), num(y), img.name)
+}
+
+func (c *content) frame(x, y, w, h float64) {
+	fmt.Fprintf(c, "q 0.75 G 0.5 w %s %s %s %s re S Q\n", num(x), num(y), num(w), num(h))
+}
+
+func (c *content) rule(x, y, w float64) {
+	fmt.Fprintf(c, "q 0.85 G 0.5 w %s %s m %s %s l S Q\n", num(x), num(y), num(x+w), num(y))
+}
+
+func (c *content) text(font string, size, x, y float64, s string) {
+	fmt.Fprintf(c, "BT /%s %s Tf %s %s Td (%s) Tj ET\n", font, num(size), num(x), num(y), encodeText(s))
+}
+
+// num formats a coordinate without trailing zeros.
+func num(f float64) string {
+	s := fmt.Sprintf("%.2f", f)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "" || s == "-" {
+		return "0"
+	}
+	return s
+}
+
+// textString encodes a document info string as UTF-16BE.
+func textString(s string) string {
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, u := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04X", u)
+	}
+	b.WriteString(">")
+	return b.String()
+}
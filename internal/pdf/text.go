@@ -0,0 +1,130 @@
+package pdf
+
+import (
+	"strings"
+	"unicode"
+)
+
+// fontEncoding is WinAnsiEncoding with the Hungarian double-acute letters,
+// which WinAnsi lacks, on four of its unused codes.
+const fontEncoding = "<< /Type /Encoding /BaseEncoding /WinAnsiEncoding /Differences [129 /ohungarumlaut 141 /uhungarumlaut 143 /Ohungarumlaut 144 /Uhungarumlaut] >>"
+
+// winAnsi maps the runes outside Latin-1 that the encoding has.
+var winAnsi = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87,
+	'ˆ': 0x88, '‰': 0x89, 'Š': 0x8A, '‹': 0x8B, 'Œ': 0x8C, 'Ž': 0x8E,
+	'‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97,
+	'˜': 0x98, '™': 0x99, 'š': 0x9A, '›': 0x9B, 'œ': 0x9C, 'ž': 0x9E, 'Ÿ': 0x9F,
+	'ő': 0x81, 'ű': 0x8D, 'Ő': 0x8F, 'Ű': 0x90,
+}
+
+// encodeText converts text to the font encoding and escapes it for a PDF
+// string literal. Characters the encoding lacks become '?'.
+func encodeText(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		var c byte
+		switch {
+		case r == '\t':
+			c = ' '
+		case r >= 0x20 && r < 0x7F, r >= 0xA0 && r <= 0xFF:
+			c = byte(r)
+		default:
+			var ok bool
+			if c, ok = winAnsi[r]; !ok {
+				c = '?'
+			}
+		}
+		if c == '(' || c == ')' || c == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// helveticaWidths are the advance widths of ASCII 0x20-0x7E in Helvetica,
+// in 1/1000 em. Bold text is measured with them too; it is only used for
+// short captions.
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278, // space - /
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556, // 0 - ?
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778, // @ - O
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556, // P - _
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556, // ` - o
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584, // p - ~
+}
+
+// runeWidth returns the width of a rune in 1/1000 em, estimated outside
+// ASCII.
+func runeWidth(r rune) int {
+	if r >= 0x20 && r <= 0x7E {
+		return helveticaWidths[r-0x20]
+	}
+	if unicode.IsUpper(r) {
+		return 722
+	}
+	return 556
+}
+
+// textWidth returns the width of text in points at a font size.
+func textWidth(s string, size float64) float64 {
+	w := 0
+	for _, r := range s {
+		w += runeWidth(r)
+	}
+	return float64(w) * size / 1000
+}
+
+// wrapText breaks text into lines no wider than width points, at spaces
+// where possible. Line breaks in the text are kept.
+func wrapText(text string, size, width float64) []string {
+	var lines []string
+	for _, para := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(para) {
+			for textWidth(word, size) > width {
+				if line != "" {
+					lines = append(lines, line)
+					line = ""
+				}
+				cut := fitRunes(word, size, width)
+				lines = append(lines, word[:cut])
+				word = word[cut:]
+			}
+			switch {
+			case line == "":
+				line = word
+			case textWidth(line+" "+word, size) <= width:
+				line += " " + word
+			default:
+				lines = append(lines, line)
+				line = word
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// fitRunes returns the byte length of the longest prefix of s that fits in
+// width points (at least one rune).
+func fitRunes(s string, size, width float64) int {
+	w := 0.0
+	for i, r := range s {
+		w += float64(runeWidth(r)) * size / 1000
+		if w > width && i > 0 {
+			return i
+		}
+	}
+	return len(s)
+}
+
+// truncate shortens a line so that it fits in width points with an ellipsis.
+func truncate(line string, size, width float64) string {
+	const ellipsis = "…"
+	if textWidth(line+ellipsis, size) <= width {
+		return line + ellipsis
+	}
+	return line[:fitRunes(line, size, width-textWidth(ellipsis, size))] + ellipsis
+}
@@ -0,0 +1,246 @@
+package pptx
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Chart is a chart graphic frame with the data cached in its chart part.
+type Chart struct {
+	Name       string        `json:"name,omitempty"`
+	Type       string        `json:"type"`                // type of the first plot: bar, line, pie, ...
+	Direction  string        `json:"direction,omitempty"` // bar charts: "col" (vertical) or "bar"
+	Title      string        `json:"title,omitempty"`
+	Categories []string      `json:"categories,omitempty"`
+	Series     []ChartSeries `json:"series"`
+}
+
+type ChartSeries struct {
+	Name    string     `json:"name"`
+	Type    string     `json:"type"`               // plot type, differs from Chart.Type in combo charts
+	Values  []*float64 `json:"values"`             // nil where the cache has no point
+	XValues []*float64 `json:"x_values,omitempty"` // scatter and bubble charts
+}
+
+// chartTypes maps plot elements of c:plotArea to chart type names.
+var chartTypes = map[string]string{
+	"barChart":       "bar",
+	"bar3DChart":     "bar",
+	"lineChart":      "line",
+	"line3DChart":    "line",
+	"pieChart":       "pie",
+	"pie3DChart":     "pie",
+	"ofPieChart":     "pie",
+	"doughnutChart":  "doughnut",
+	"areaChart":      "area",
+	"area3DChart":    "area",
+	"scatterChart":   "scatter",
+	"bubbleChart":    "bubble",
+	"radarChart":     "radar",
+	"stockChart":     "stock",
+	"surfaceChart":   "surface",
+	"surface3DChart": "surface",
+}
+
+type chartSpaceXML struct {
+	Title struct {
+		Texts []string `xml:"tx>rich>p>r>t"`
+	} `xml:"chart>title"`
+	PlotArea struct {
+		Plots []chartPlotXML `xml:",any"`
+	} `xml:"chart>plotArea"`
+}
+
+type chartPlotXML struct {
+	XMLName xml.Name
+	BarDir  struct {
+		Val string `xml:"val,attr"`
+	} `xml:"barDir"`
+	Series []chartSerXML `xml:"ser"`
+}
+
+type chartSerXML struct {
+	Tx   chartDataXML `xml:"tx"`
+	Cat  chartDataXML `xml:"cat"`
+	Val  chartDataXML `xml:"val"`
+	XVal chartDataXML `xml:"xVal"`
+	YVal chartDataXML `xml:"yVal"`
+}
+
+// chartDataXML covers the reference caches and literals a series can use.
+type chartDataXML struct {
+	V      string       `xml:"v"`
+	StrRef []chartPtXML `xml:"strRef>strCache>pt"`
+	NumRef []chartPtXML `xml:"numRef>numCache>pt"`
+	StrLit []chartPtXML `xml:"strLit>pt"`
+	NumLit []chartPtXML `xml:"numLit>pt"`
+	Multi  []chartPtXML `xml:"multiLvlStrRef>multiLvlStrCache>lvl>pt"`
+}
+
+type chartPtXML struct {
+	Idx int    `xml:"idx,attr"`
+	V   string `xml:"v"`
+}
+
+func (d chartDataXML) points() []chartPtXML {
+	for _, pts := range [][]chartPtXML{d.StrRef, d.NumRef, d.StrLit, d.NumLit, d.Multi} {
+		if len(pts) > 0 {
+			return pts
+		}
+	}
+	return nil
+}
+
+func (d chartDataXML) strings() []string {
+	pts := d.points()
+	if len(pts) == 0 {
+		return nil
+	}
+	out := make([]string, maxIdx(pts)+1)
+	for _, p := range pts {
+		// Multi-level categories list the innermost level first; keep it.
+		if out[p.Idx] == "" {
+			out[p.Idx] = p.V
+		}
+	}
+	return out
+}
+
+func (d chartDataXML) numbers() []*float64 {
+	pts := d.points()
+	if len(pts) == 0 {
+		return nil
+	}
+	out := make([]*float64, maxIdx(pts)+1)
+	for _, p := range pts {
+		if v, err := strconv.ParseFloat(strings.TrimSpace(p.V), 64); err == nil {
+			out[p.Idx] = &v
+		}
+	}
+	return out
+}
+
+func (d chartDataXML) text() string {
+	if d.V != "" {
+		return d.V
+	}
+	return strings.Join(d.strings(), " ")
+}
+
+func maxIdx(pts []chartPtXML) int {
+	m := 0
+	for _, p := range pts {
+		if p.Idx > m {
+			m = p.Idx
+		}
+	}
+	return m
+}
+
+// parseChart reads the type, title, categories and cached series values of a chart part.
+func parseChart(data []byte) (*Chart, error) {
+	var cs chartSpaceXML
+	if err := xml.Unmarshal(data, &cs); err != nil {
+		return nil, err
+	}
+
+	chart := &Chart{Title: strings.TrimSpace(strings.Join(cs.Title.Texts, ""))}
+	for _, plot := range cs.PlotArea.Plots {
+		typ, ok := chartTypes[plot.XMLName.Local]
+		if !ok {
+			continue // axes, layout, shape properties
+		}
+		if chart.Type == "" {
+			chart.Type, chart.Direction = typ, plot.BarDir.Val
+		}
+		for _, ser := range plot.Series {
+			s := ChartSeries{Name: ser.Tx.text(), Type: typ}
+			cat, val := ser.Cat, ser.Val
+			if len(ser.YVal.points()) > 0 {
+				s.XValues = ser.XVal.numbers()
+				cat, val = ser.XVal, ser.YVal
+			}
+			s.Values = val.numbers()
+			if len(chart.Categories) == 0 && s.XValues == nil {
+				chart.Categories = cat.strings()
+			}
+			chart.Series = append(chart.Series, s)
+		}
+	}
+	if chart.Type == "" {
+		return nil, fmt.Errorf("no supported plot in chart")
+	}
+	return chart, nil
+}
+
+var frameNameRegex = regexp.MustCompile(`<p:cNvPr\b[^>]*?\bname="([^"]*)"`)
+
+// extractCharts parses the charts referenced from the graphic frames of a slide, in slide order.
+func extractCharts(pkg *sourcePackage, slidePart string, body []byte) []Chart {
+	rels, err := pkg.rels(slidePart)
+	if err != nil {
+		return nil
+	}
+	targets := make(map[string]string)
+	for _, rel := range rels {
+		if rel.Type == relTypeChart && !rel.isExternal() {
+			targets[rel.ID] = resolveTarget(slidePart, rel.Target)
+		}
+	}
+
+	var charts []Chart
+	for _, frame := range graphicFrameRegex.FindAll(body, -1) {
+		ref := chartRefRegex.FindSubmatch(frame)
+		if ref == nil {
+			continue
+		}
+		part, ok := targets[string(ref[1])]
+		if !ok {
+			continue
+		}
+		data, err := pkg.read(part)
+		if err != nil {
+			continue
+		}
+		chart, err := parseChart(data)
+		if err != nil {
+			continue
+		}
+		if m := frameNameRegex.FindSubmatch(frame); m != nil {
+			chart.Name = html.UnescapeString(string(m[1]))
+		}
+		charts = append(charts, *chart)
+	}
+	return charts
+}
+
+// Text renders the chart as a sentence for search and AI summaries, e.g.
+// "Revenue bar chart. 2025: Q1 12, Q2 15."
+func (c *Chart) Text() string {
+	var sb strings.Builder
+	if c.Title != "" {
+		sb.WriteString(c.Title + " ")
+	}
+	sb.WriteString(c.Type + " chart.")
+	for _, s := range c.Series {
+		sb.WriteString(" " + s.Name + ":")
+		for i, v := range s.Values {
+			if v == nil {
+				continue
+			}
+			if i > 0 {
+				sb.WriteString(",")
+			}
+			if i < len(c.Categories) && c.Categories[i] != "" {
+				sb.WriteString(" " + c.Categories[i])
+			}
+			sb.WriteString(" " + strconv.FormatFloat(*v, 'f', -1, 64))
+		}
+		sb.WriteString(".")
+	}
+	return sb.String()
+}
@@ -0,0 +1,97 @@
+package pptx
+
+import (
+	"encoding/xml"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DocProps are the document properties of docProps/core.xml and docProps/app.xml.
+type DocProps struct {
+	Title          string     `json:"title,omitempty"`
+	Subject        string     `json:"subject,omitempty"`
+	Author         string     `json:"author,omitempty"` // dc:creator
+	LastModifiedBy string     `json:"last_modified_by,omitempty"`
+	Created        *time.Time `json:"created,omitempty"`
+	Modified       *time.Time `json:"modified,omitempty"`
+	Revision       int        `json:"revision,omitempty"`
+	Company        string     `json:"company,omitempty"`
+	SlideCount     int        `json:"slide_count,omitempty"` // as saved by the application
+	Application    string     `json:"application,omitempty"`
+	AppVersion     string     `json:"app_version,omitempty"`
+}
+
+type corePropsXML struct {
+	Title          string `xml:"title"`
+	Subject        string `xml:"subject"`
+	Creator        string `xml:"creator"`
+	LastModifiedBy string `xml:"lastModifiedBy"`
+	Revision       string `xml:"revision"`
+	Created        string `xml:"created"`
+	Modified       string `xml:"modified"`
+}
+
+type appPropsXML struct {
+	Application string `xml:"Application"`
+	AppVersion  string `xml:"AppVersion"`
+	Company     string `xml:"Company"`
+	Slides      int    `xml:"Slides"`
+}
+
+// ExtractDocProps reads the core and extended properties of a PPTX file.
+// Missing property parts are not an error; their fields stay empty.
+func ExtractDocProps(pptxPath string) (*DocProps, error) {
+	pkg, err := openPackage(pptxPath)
+	if err != nil {
+		return nil, err
+	}
+	defer pkg.Close()
+
+	props := &DocProps{}
+	if data, err := pkg.read(propsPart(pkg, relTypeCoreProps, "docProps/core.xml")); err == nil {
+		var core corePropsXML
+		if err := xml.Unmarshal(data, &core); err != nil {
+			return nil, err
+		}
+		props.Title = strings.TrimSpace(core.Title)
+		props.Subject = strings.TrimSpace(core.Subject)
+		props.Author = strings.TrimSpace(core.Creator)
+		props.LastModifiedBy = strings.TrimSpace(core.LastModifiedBy)
+		props.Revision, _ = strconv.Atoi(strings.TrimSpace(core.Revision))
+		props.Created = parseW3CDTF(core.Created)
+		props.Modified = parseW3CDTF(core.Modified)
+	}
+	if data, err := pkg.read(propsPart(pkg, relTypeExtProps, "docProps/app.xml")); err == nil {
+		var app appPropsXML
+		if err := xml.Unmarshal(data, &app); err != nil {
+			return nil, err
+		}
+		props.Application = strings.TrimSpace(app.Application)
+		props.AppVersion = strings.TrimSpace(app.AppVersion)
+		props.Company = strings.TrimSpace(app.Company)
+		props.SlideCount = app.Slides
+	}
+	return props, nil
+}
+
+// propsPart finds a property part through the package relationships.
+func propsPart(pkg *sourcePackage, relType, fallback string) string {
+	if part := pkg.relTarget("", relType); part != "" {
+		return part
+	}
+	return fallback
+}
+
+// parseW3CDTF parses the dcterms:W3CDTF dates of core.xml, which may be
+// truncated to the day, month or year.
+func parseW3CDTF(s string) *time.Time {
+	s = strings.TrimSpace(s)
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02", "2006-01", "2006"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			t = t.UTC()
+			return &t
+		}
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package pptx
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strconv"
+)
+
+// Layout is a slide layout of a deck with the master it belongs to.
+type Layout struct {
+	Part         string              `json:"part"` // e.g. ppt/slideLayouts/slideLayout2.xml
+	Name         string              `json:"name"` // p:cSld/@name, e.g. "Title and Content"
+	Type         string              `json:"type"` // p:sldLayout/@type, e.g. titleAndBody, cust
+	Master       string              `json:"master"`
+	MasterName   string              `json:"master_name,omitempty"`
+	Placeholders []LayoutPlaceholder `json:"placeholders"`
+}
+
+// LayoutPlaceholder is a placeholder offered by a layout.
+type LayoutPlaceholder struct {
+	Type string `json:"type"` // obj when the ph has no type
+	Idx  int    `json:"idx"`
+	Name string `json:"name,omitempty"`
+}
+
+// cSldXML is the common slide data element; only its name is needed.
+type cSldXML struct {
+	Name string `xml:"name,attr"`
+}
+
+type masterLayoutsXML struct {
+	CSld      cSldXML `xml:"cSld"`
+	LayoutIDs []struct {
+		Attrs []xml.Attr `xml:",any,attr"`
+	} `xml:"sldLayoutIdLst>sldLayoutId"`
+}
+
+type layoutXML struct {
+	Type string  `xml:"type,attr"`
+	CSld cSldXML `xml:"cSld"`
+}
+
+// ExtractLayouts lists the layouts of every slide master of a deck, masters in
+// presentation order and layouts in the order of each master's layout list.
+func ExtractLayouts(pptxPath string) ([]Layout, error) {
+	pkg, err := openPackage(pptxPath)
+	if err != nil {
+		return nil, err
+	}
+	defer pkg.Close()
+
+	var layouts []Layout
+	for _, master := range pkg.masterParts() {
+		data, err := pkg.read(master)
+		if err != nil {
+			continue
+		}
+		var m masterLayoutsXML
+		if err := xml.Unmarshal(data, &m); err != nil {
+			continue
+		}
+		targets := make(map[string]string)
+		rels, _ := pkg.rels(master)
+		for _, rel := range rels {
+			if rel.Type == relTypeSlideLayout && !rel.isExternal() {
+				targets[rel.ID] = resolveTarget(master, rel.Target)
+			}
+		}
+		for _, id := range m.LayoutIDs {
+			part, ok := targets[attrValue(id.Attrs, relsAttrNamespace, "id")]
+			if !ok {
+				continue
+			}
+			data, err := pkg.read(part)
+			if err != nil {
+				continue
+			}
+			var l layoutXML
+			if err := xml.Unmarshal(data, &l); err != nil {
+				continue
+			}
+			layouts = append(layouts, Layout{
+				Part:         part,
+				Name:         l.CSld.Name,
+				Type:         l.Type,
+				Master:       master,
+				MasterName:   m.CSld.Name,
+				Placeholders: layoutPlaceholders(data),
+			})
+		}
+	}
+	return layouts, nil
+}
+
+// masterParts returns the slide masters listed in p:sldMasterIdLst.
+func (p *sourcePackage) masterParts() []string {
+	data, err := p.read(presentationPart)
+	if err != nil {
+		return nil
+	}
+	var pres presentationXML
+	if err := xml.Unmarshal(data, &pres); err != nil {
+		return nil
+	}
+	rels, _ := p.rels(presentationPart)
+	targets := make(map[string]string)
+	for _, rel := range rels {
+		targets[rel.ID] = resolveTarget(presentationPart, rel.Target)
+	}
+	var masters []string
+	for _, m := range pres.MasterIDs {
+		if target, ok := targets[attrValue(m.Attrs, relsAttrNamespace, "id")]; ok && p.has(target) {
+			masters = append(masters, target)
+		}
+	}
+	return masters
+}
+
+// layoutPlaceholders returns the placeholders of a layout part, including
+// those inside groups, with the name of the shape that holds each.
+func layoutPlaceholders(data []byte) []LayoutPlaceholder {
+	placeholders := []LayoutPlaceholder{}
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	name := ""
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		el, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch el.Name.Local {
+		case "cNvPr":
+			name = attrValue(el.Attr, "", "name")
+		case "ph":
+			ph := LayoutPlaceholder{Type: attrValue(el.Attr, "", "type"), Name: name}
+			if ph.Type == "" {
+				ph.Type = "obj"
+			}
+			ph.Idx, _ = strconv.Atoi(attrValue(el.Attr, "", "idx"))
+			placeholders = append(placeholders, ph)
+		}
+	}
+	return placeholders
+}
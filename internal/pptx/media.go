@@ -0,0 +1,113 @@
+package pptx
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// Media relationship types of a slide. Videos and audio are referenced twice:
+// by the legacy video/audio relationship and by the Office 2010 media one.
+const (
+	relTypeVideo = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/video"
+	relTypeAudio = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/audio"
+	relTypeMedia = "http://schemas.microsoft.com/office/2007/relationships/media"
+)
+
+// Media is a picture, video or audio part used by a slide.
+type Media struct {
+	Part        string `json:"part"`   // e.g. ppt/media/image3.png
+	RelID       string `json:"rel_id"` // first relationship of the slide pointing at the part
+	Kind        string `json:"kind"`   // image | video | audio
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	SHA256      string `json:"sha256"`
+	Width       int    `json:"width,omitempty"` // pixels, images only
+	Height      int    `json:"height,omitempty"`
+}
+
+// extractMedia lists the media parts referenced from the relationships of a
+// slide. Each part appears once; linked (external) media is skipped.
+func extractMedia(pkg *sourcePackage, slidePart string) []Media {
+	rels, err := pkg.rels(slidePart)
+	if err != nil {
+		return nil
+	}
+
+	var media []Media
+	seen := make(map[string]bool)
+	for _, rel := range rels {
+		if rel.isExternal() {
+			continue
+		}
+		kind := ""
+		switch rel.Type {
+		case relTypeImage:
+			kind = "image"
+		case relTypeVideo:
+			kind = "video"
+		case relTypeAudio:
+			kind = "audio"
+		case relTypeMedia:
+			kind = "" // decided by the content type below
+		default:
+			continue
+		}
+
+		part := resolveTarget(slidePart, rel.Target)
+		if seen[part] {
+			continue
+		}
+		data, err := pkg.read(part)
+		if err != nil {
+			continue
+		}
+		seen[part] = true
+
+		contentType := pkg.contentType(part)
+		if contentType == contentTypeXML || contentType == "" {
+			contentType = http.DetectContentType(data)
+		}
+		if kind == "" {
+			kind = mediaKind(contentType, part)
+		}
+
+		sum := sha256.Sum256(data)
+		m := Media{
+			Part:        part,
+			RelID:       rel.ID,
+			Kind:        kind,
+			ContentType: contentType,
+			Size:        int64(len(data)),
+			SHA256:      hex.EncodeToString(sum[:]),
+		}
+		if kind == "image" {
+			if cfg, _, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+				m.Width, m.Height = cfg.Width, cfg.Height
+			}
+		}
+		media = append(media, m)
+	}
+	return media
+}
+
+func mediaKind(contentType, part string) string {
+	switch {
+	case strings.HasPrefix(contentType, "audio/"):
+		return "audio"
+	case strings.HasPrefix(contentType, "image/"):
+		return "image"
+	}
+	switch strings.ToLower(path.Ext(part)) {
+	case ".mp3", ".wav", ".m4a", ".wma", ".aac":
+		return "audio"
+	}
+	return "video"
+}
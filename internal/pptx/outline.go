@@ -0,0 +1,126 @@
+package pptx
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Markdown renders the slide as a Markdown outline: the title placeholder
+// becomes a level-2 heading, body text a (nested) list, SmartArt a list,
+// tables pipe tables and charts a sentence with their numbers.
+func (s *JSONSlide) Markdown() string {
+	var title string
+	var body strings.Builder
+
+	var walk func(shapes []Shape)
+	walk = func(shapes []Shape) {
+		for _, sh := range shapes {
+			switch {
+			case sh.Type == "title" && title == "":
+				title = shapeText(sh)
+			case len(sh.Paragraphs) > 0:
+				writeParagraphs(&body, sh)
+			case len(sh.SmartArt) > 0:
+				for _, t := range sh.SmartArt {
+					fmt.Fprintf(&body, "- %s\n", strings.ReplaceAll(t, "\n", " "))
+				}
+				body.WriteString("\n")
+			}
+			walk(sh.Children)
+		}
+	}
+	walk(s.Shapes)
+
+	for _, t := range s.Tables {
+		writeTable(&body, t)
+	}
+	for _, c := range s.Charts {
+		body.WriteString(c.Text() + "\n\n")
+	}
+
+	if title == "" {
+		title = fmt.Sprintf("Slide %d", s.Index)
+	}
+	return "## " + title + "\n\n" + body.String()
+}
+
+// shapeText is the unformatted text of a shape, paragraphs joined by spaces.
+func shapeText(sh Shape) string {
+	var parts []string
+	for _, p := range sh.Paragraphs {
+		var line strings.Builder
+		for _, r := range p.Runs {
+			line.WriteString(r.Text)
+		}
+		parts = append(parts, strings.TrimSpace(line.String()))
+	}
+	return strings.Join(parts, " ")
+}
+
+// writeParagraphs writes a text shape as a list. Body placeholders are
+// bulleted unless a paragraph turns bullets off; other shapes only where a
+// paragraph sets a bullet of its own.
+func writeParagraphs(b *strings.Builder, sh Shape) {
+	inList := false
+	for _, p := range sh.Paragraphs {
+		text := runsMarkdown(p.Runs)
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		bullet := p.Bullet == "char" || (sh.Type == "body" && p.Bullet == "")
+		switch {
+		case p.Bullet == "number":
+			fmt.Fprintf(b, "%s1. %s\n", strings.Repeat("   ", p.Level), text)
+			inList = true
+		case bullet:
+			fmt.Fprintf(b, "%s- %s\n", strings.Repeat("  ", p.Level), text)
+			inList = true
+		default:
+			if inList {
+				b.WriteString("\n")
+			}
+			fmt.Fprintf(b, "%s\n\n", text)
+			inList = false
+		}
+	}
+	if inList {
+		b.WriteString("\n")
+	}
+}
+
+func runsMarkdown(runs []TextRun) string {
+	var sb strings.Builder
+	for _, r := range runs {
+		text := r.Text
+		if trimmed := strings.TrimSpace(text); r.Bold && trimmed != "" {
+			// Spaces stay outside the markers, or they would not close
+			lead := text[:strings.Index(text, trimmed)]
+			text = lead + "**" + trimmed + "**" + text[len(lead)+len(trimmed):]
+		}
+		if r.Link != "" {
+			text = "[" + text + "](" + r.Link + ")"
+		}
+		sb.WriteString(text)
+	}
+	return sb.String()
+}
+
+func writeTable(b *strings.Builder, t Table) {
+	if len(t.Rows) == 0 {
+		return
+	}
+	cell := func(s string) string {
+		return strings.ReplaceAll(strings.ReplaceAll(s, "|", `\|`), "\n", "<br>")
+	}
+	for i, row := range t.Rows {
+		cells := make([]string, len(row))
+		for j, c := range row {
+			cells[j] = cell(c.Text)
+		}
+		b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+		if i == 0 {
+			b.WriteString(strings.Repeat("| --- ", len(row)) + "|\n")
+		}
+	}
+	b.WriteString("\n")
+}
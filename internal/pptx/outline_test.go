@@ -0,0 +1,111 @@
+package pptx
+
+import (
+	"strings"
+	"testing"
+)
+
+// para builds a paragraph of plain runs.
+func para(level int, bullet string, texts ...string) Paragraph {
+	p := Paragraph{Level: level, Bullet: bullet}
+	for _, t := range texts {
+		p.Runs = append(p.Runs, TextRun{Text: t})
+	}
+	return p
+}
+
+func TestSlideMarkdown(t *testing.T) {
+	title := Shape{Kind: "sp", Type: "title", Paragraphs: []Paragraph{para(0, "", "Quarterly"), para(0, "", "report")}}
+
+	tests := []struct {
+		name  string
+		slide JSONSlide
+		want  string
+	}{
+		{
+			name:  "title only",
+			slide: JSONSlide{Index: 3, Shapes: []Shape{title}},
+			want:  "## Quarterly report\n\n",
+		},
+		{
+			name:  "no title",
+			slide: JSONSlide{Index: 3},
+			want:  "## Slide 3\n\n",
+		},
+		{
+			name: "nested body levels",
+			slide: JSONSlide{Shapes: []Shape{title, {Kind: "sp", Type: "body", Paragraphs: []Paragraph{
+				para(0, "", "One"), para(1, "", "One a"), para(2, "", "One a i"), para(0, "", "Two"),
+			}}}},
+			want: "## Quarterly report\n\n- One\n  - One a\n    - One a i\n- Two\n\n",
+		},
+		{
+			name: "numbered bullets",
+			slide: JSONSlide{Shapes: []Shape{{Kind: "sp", Type: "body", Paragraphs: []Paragraph{
+				para(0, "number", "First"), para(1, "number", "Nested"), para(0, "number", "Second"),
+			}}}},
+			want: "## Slide 0\n\n1. First\n   1. Nested\n1. Second\n\n",
+		},
+		{
+			name: "bullets off and other shapes",
+			slide: JSONSlide{Shapes: []Shape{
+				{Kind: "sp", Type: "body", Paragraphs: []Paragraph{para(0, "", "Item"), para(0, "none", "Plain")}},
+				{Kind: "sp", Type: "other", Paragraphs: []Paragraph{para(0, "", "Caption"), para(0, "char", "Marked")}},
+			}},
+			want: "## Slide 0\n\n- Item\n\nPlain\n\nCaption\n\n- Marked\n\n",
+		},
+		{
+			name: "bold and links",
+			slide: JSONSlide{Shapes: []Shape{{Kind: "sp", Type: "other", Paragraphs: []Paragraph{{Runs: []TextRun{
+				{Text: "See "}, {Text: "the docs ", Bold: true}, {Text: "here", Link: "https://example.com/"}, {Text: " ", Bold: true},
+			}}}}}},
+			want: "## Slide 0\n\nSee **the docs** [here](https://example.com/) \n\n",
+		},
+		{
+			name: "smartart in a group",
+			slide: JSONSlide{Shapes: []Shape{{Kind: "grpSp", Children: []Shape{
+				{Kind: "graphicFrame", SmartArt: []string{"Plan", "Build\nTest"}},
+			}}}},
+			want: "## Slide 0\n\n- Plan\n- Build Test\n\n",
+		},
+		{
+			name: "table with pipes and line breaks",
+			slide: JSONSlide{Tables: []Table{{Rows: [][]TableCell{
+				{{Text: "Name"}, {Text: "A|B"}},
+				{{Text: "x"}, {Text: "1\n2"}},
+			}}}},
+			want: "## Slide 0\n\n| Name | A\\|B |\n| --- | --- |\n| x | 1<br>2 |\n\n",
+		},
+		{
+			name:  "chart",
+			slide: JSONSlide{Charts: []Chart{{Title: "Sales", Type: "bar", Categories: []string{"Q1"}, Series: []ChartSeries{{Name: "2025", Values: []*float64{new(float64)}}}}}},
+			want:  "## Slide 0\n\nSales bar chart. 2025: Q1 0.\n\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.slide.Markdown(); got != tt.want {
+				t.Errorf("Markdown() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlideMarkdownContentPlaceholder(t *testing.T) {
+	// The content placeholder of "Title and Content" has an idx but no type
+	shapes := `<p:sp><p:nvSpPr><p:cNvPr id="2" name="Content"/><p:cNvSpPr/><p:nvPr><p:ph idx="1"/></p:nvPr></p:nvSpPr>` +
+		`<p:txBody><a:p><a:r><a:t>First</a:t></a:r></a:p><a:p><a:pPr lvl="1"/><a:r><a:t>Second</a:t></a:r></a:p></p:txBody></p:sp>` +
+		`<p:sp><p:nvSpPr><p:cNvPr id="3" name="Subtitle"/><p:cNvSpPr/><p:nvPr><p:ph type="subTitle" idx="2"/></p:nvPr></p:nvSpPr>` +
+		`<p:txBody><a:p><a:r><a:t>Tagline</a:t></a:r></a:p></p:txBody></p:sp>`
+	slide, _, err := parseSlideXML(strings.NewReader(slideXML(shapes)), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := slide.Shapes[0].Type; got != "body" {
+		t.Errorf("content placeholder type = %q, want body", got)
+	}
+	if want := "## Slide 1\n\n- First\n  - Second\n\nTagline\n\n"; slide.Markdown() != want {
+		t.Errorf("Markdown() = %q, want %q", slide.Markdown(), want)
+	}
+}
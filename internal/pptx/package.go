@@ -0,0 +1,348 @@
+package pptx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// Relationship types used when walking the package graph.
+const (
+	relTypeSlide        = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/slide"
+	relTypeSlideLayout  = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/slideLayout"
+	relTypeSlideMaster  = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/slideMaster"
+	relTypeNotesSlide   = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/notesSlide"
+	relTypeNotesMaster  = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/notesMaster"
+	relTypeTheme        = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/theme"
+	relTypeOfficeDoc    = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument"
+	relTypeCoreProps    = "http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties"
+	relTypeExtProps     = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/extended-properties"
+	relTypePresProps    = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/presProps"
+	relTypeViewProps    = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/viewProps"
+	relTypeTableStyles  = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/tableStyles"
+	relTypeImage        = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/image"
+	relTypeChart        = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/chart"
+	relTypePackage      = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/package"
+	relsNamespace       = "http://schemas.openxmlformats.org/package/2006/relationships"
+	contentTypesPart    = "[Content_Types].xml"
+	presentationPart    = "ppt/presentation.xml"
+	contentTypeRels     = "application/vnd.openxmlformats-package.relationships+xml"
+	contentTypeXML      = "application/xml"
+	contentTypePresMain = "application/vnd.openxmlformats-officedocument.presentationml.presentation.main+xml"
+)
+
+// relationship is a single entry of a .rels part.
+type relationship struct {
+	ID         string `xml:"Id,attr"`
+	Type       string `xml:"Type,attr"`
+	Target     string `xml:"Target,attr"`
+	TargetMode string `xml:"TargetMode,attr,omitempty"`
+}
+
+// isExternal reports whether the relationship points outside the package.
+func (r relationship) isExternal() bool {
+	return r.TargetMode == "External"
+}
+
+type relationships struct {
+	XMLName xml.Name       `xml:"Relationships"`
+	Xmlns   string         `xml:"xmlns,attr"`
+	Items   []relationship `xml:"Relationship"`
+}
+
+type contentTypes struct {
+	Defaults []struct {
+		Extension   string `xml:"Extension,attr"`
+		ContentType string `xml:"ContentType,attr"`
+	} `xml:"Default"`
+	Overrides []struct {
+		PartName    string `xml:"PartName,attr"`
+		ContentType string `xml:"ContentType,attr"`
+	} `xml:"Override"`
+}
+
+// sourcePackage is an opened PPTX (OPC) package with indexed parts.
+type sourcePackage struct {
+	path      string
+	zr        *zip.ReadCloser
+	files     map[string]*zip.File
+	defaults  map[string]string   // extension -> content type
+	overrides map[string]string   // part name -> content type
+	slides    []presentationSlide // presentation order, read on first use
+}
+
+func openPackage(pptxPath string) (*sourcePackage, error) {
+	zr, err := zip.OpenReader(pptxPath)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg := &sourcePackage{
+		path:      pptxPath,
+		zr:        zr,
+		files:     make(map[string]*zip.File),
+		defaults:  make(map[string]string),
+		overrides: make(map[string]string),
+	}
+	for _, f := range zr.File {
+		pkg.files[f.Name] = f
+	}
+
+	if data, err := pkg.read(contentTypesPart); err == nil {
+		var ct contentTypes
+		if err := xml.Unmarshal(data, &ct); err != nil {
+			zr.Close()
+			return nil, fmt.Errorf("invalid content types in %s: %v", pptxPath, err)
+		}
+		for _, d := range ct.Defaults {
+			pkg.defaults[strings.ToLower(d.Extension)] = d.ContentType
+		}
+		for _, o := range ct.Overrides {
+			pkg.overrides[strings.TrimPrefix(o.PartName, "/")] = o.ContentType
+		}
+	}
+
+	return pkg, nil
+}
+
+func (p *sourcePackage) Close() error {
+	return p.zr.Close()
+}
+
+func (p *sourcePackage) has(part string) bool {
+	_, ok := p.files[part]
+	return ok
+}
+
+func (p *sourcePackage) read(part string) ([]byte, error) {
+	f, ok := p.files[part]
+	if !ok {
+		return nil, fmt.Errorf("part %s not found in %s", part, p.path)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// rels returns the relationships of a part (empty if the part has none).
+func (p *sourcePackage) rels(part string) ([]relationship, error) {
+	relsPart := relsPathFor(part)
+	if !p.has(relsPart) {
+		return nil, nil
+	}
+	data, err := p.read(relsPart)
+	if err != nil {
+		return nil, err
+	}
+	var r relationships
+	if err := xml.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("invalid relationships %s: %v", relsPart, err)
+	}
+	return r.Items, nil
+}
+
+// relTarget returns the resolved part name of the first relationship of the given type.
+func (p *sourcePackage) relTarget(part, relType string) string {
+	rels, err := p.rels(part)
+	if err != nil {
+		return ""
+	}
+	for _, rel := range rels {
+		if rel.Type == relType && !rel.isExternal() {
+			return resolveTarget(part, rel.Target)
+		}
+	}
+	return ""
+}
+
+func (p *sourcePackage) contentType(part string) string {
+	if ct, ok := p.overrides[part]; ok {
+		return ct
+	}
+	ext := strings.ToLower(strings.TrimPrefix(path.Ext(part), "."))
+	if ct, ok := p.defaults[ext]; ok {
+		return ct
+	}
+	return contentTypeXML
+}
+
+// slidePart maps a 1-based slide number (position in presentation order) to
+// its part name.
+func (p *sourcePackage) slidePart(slideNum int) (string, error) {
+	parts, err := p.slideParts()
+	if err != nil {
+		return "", err
+	}
+	if slideNum < 1 || slideNum > len(parts) {
+		return "", fmt.Errorf("slide %d not found in %s", slideNum, p.path)
+	}
+	return parts[slideNum-1], nil
+}
+
+const relsAttrNamespace = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+
+// presentationXML reads p:sldIdLst, p:sldMasterIdLst, p:sldSz and the p14
+// section list. Attributes of sldId are captured raw because encoding/xml
+// cannot tell the plain id from r:id by field tags alone.
+type presentationXML struct {
+	SlideIDs []struct {
+		Attrs []xml.Attr `xml:",any,attr"`
+	} `xml:"sldIdLst>sldId"`
+	MasterIDs []struct {
+		Attrs []xml.Attr `xml:",any,attr"`
+	} `xml:"sldMasterIdLst>sldMasterId"`
+	SlideSize struct {
+		CX int64 `xml:"cx,attr"`
+		CY int64 `xml:"cy,attr"`
+	} `xml:"sldSz"`
+	Sections []struct {
+		Name     string `xml:"name,attr"`
+		SlideIDs []struct {
+			ID string `xml:"id,attr"`
+		} `xml:"sldIdLst>sldId"`
+	} `xml:"extLst>ext>sectionLst>section"`
+}
+
+func attrValue(attrs []xml.Attr, space, local string) string {
+	for _, a := range attrs {
+		if a.Name.Space == space && a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// presentationSlide is a slide as listed in presentation.xml.
+type presentationSlide struct {
+	part    string
+	id      string // p:sldId/@id, referenced by sections
+	section string
+}
+
+// presentationSlides returns the slides in presentation order (p:sldIdLst)
+// with the name of the section each belongs to.
+func (p *sourcePackage) presentationSlides() ([]presentationSlide, error) {
+	if p.slides != nil {
+		return p.slides, nil
+	}
+	data, err := p.read(presentationPart)
+	if err != nil {
+		return nil, err
+	}
+	var pres presentationXML
+	if err := xml.Unmarshal(data, &pres); err != nil {
+		return nil, fmt.Errorf("invalid presentation.xml in %s: %v", p.path, err)
+	}
+	rels, err := p.rels(presentationPart)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := make(map[string]string)
+	for _, rel := range rels {
+		targets[rel.ID] = resolveTarget(presentationPart, rel.Target)
+	}
+	sections := make(map[string]string)
+	for _, sec := range pres.Sections {
+		for _, s := range sec.SlideIDs {
+			sections[s.ID] = sec.Name
+		}
+	}
+
+	slides := []presentationSlide{}
+	for _, s := range pres.SlideIDs {
+		target, ok := targets[attrValue(s.Attrs, relsAttrNamespace, "id")]
+		if !ok || !p.has(target) {
+			continue
+		}
+		id := attrValue(s.Attrs, "", "id")
+		slides = append(slides, presentationSlide{part: target, id: id, section: sections[id]})
+	}
+	p.slides = slides
+	return slides, nil
+}
+
+// slideParts returns the slide part names in presentation order.
+func (p *sourcePackage) slideParts() ([]string, error) {
+	slides, err := p.presentationSlides()
+	if err != nil {
+		return nil, err
+	}
+	parts := make([]string, len(slides))
+	for i, s := range slides {
+		parts[i] = s.part
+	}
+	return parts, nil
+}
+
+// Default slide size (16:9) in EMU, for packages without p:sldSz.
+const (
+	defaultSlideCX = 12192000
+	defaultSlideCY = 6858000
+)
+
+// slideSize returns the slide width and height in EMU.
+func (p *sourcePackage) slideSize() (int64, int64) {
+	data, err := p.read(presentationPart)
+	if err != nil {
+		return defaultSlideCX, defaultSlideCY
+	}
+	var pres presentationXML
+	if err := xml.Unmarshal(data, &pres); err != nil || pres.SlideSize.CX <= 0 || pres.SlideSize.CY <= 0 {
+		return defaultSlideCX, defaultSlideCY
+	}
+	return pres.SlideSize.CX, pres.SlideSize.CY
+}
+
+// relsPathFor returns the .rels part name belonging to a part, e.g.
+// ppt/slides/slide1.xml -> ppt/slides/_rels/slide1.xml.rels
+func relsPathFor(part string) string {
+	if part == "" {
+		return "_rels/.rels"
+	}
+	dir, file := path.Split(part)
+	return dir + "_rels/" + file + ".rels"
+}
+
+// resolveTarget resolves a relationship target against the part that owns it.
+func resolveTarget(source, target string) string {
+	if strings.HasPrefix(target, "/") {
+		return strings.TrimPrefix(path.Clean(target), "/")
+	}
+	return strings.TrimPrefix(path.Clean(path.Join(path.Dir(source), target)), "/")
+}
+
+// relativeTarget builds the relationship target pointing from one part to another.
+func relativeTarget(from, to string) string {
+	fromDir := strings.Split(path.Dir(from), "/")
+	if path.Dir(from) == "." {
+		fromDir = nil
+	}
+	toParts := strings.Split(to, "/")
+
+	i := 0
+	for i < len(fromDir) && i < len(toParts)-1 && fromDir[i] == toParts[i] {
+		i++
+	}
+
+	var b strings.Builder
+	for j := i; j < len(fromDir); j++ {
+		b.WriteString("../")
+	}
+	b.WriteString(strings.Join(toParts[i:], "/"))
+	return b.String()
+}
+
+func marshalRels(items []relationship) ([]byte, error) {
+	out, err := xml.Marshal(relationships{Xmlns: relsNamespace, Items: items})
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
@@ -2,73 +2,17 @@ package pptx
 
 import (
 	"archive/zip"
+	"bytes"
+	"encoding/csv"
 	"encoding/xml"
-	"fmt"
 	"io"
-	"os"
-	"os/exec"
-	"path/filepath"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
 )
 
-// ExtractSlidesToPNG converts a PPTX file to a series of PNG images using LibreOffice and pdftoppm.
-func ExtractSlidesToPNG(pptxPath, outputDir string) ([]string, error) {
-	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create output dir: %v", err)
-	}
-
-	tempPDFDir := filepath.Join(os.TempDir(), "slideforge_pdf")
-	os.MkdirAll(tempPDFDir, 0755)
-	defer os.RemoveAll(tempPDFDir)
-
-	// Step 1: PPTX to PDF using LibreOffice
-	cmd := exec.Command("libreoffice", "--headless", "--convert-to", "pdf", "--outdir", tempPDFDir, pptxPath)
-	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("libreoffice conversion failed: %v", err)
-	}
-
-	pdfName := filepath.Base(pptxPath)
-	pdfName = pdfName[:len(pdfName)-len(filepath.Ext(pdfName))] + ".pdf"
-	pdfPath := filepath.Join(tempPDFDir, pdfName)
-
-	if _, err := os.Stat(pdfPath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("pdf file not found after conversion: %v", pdfPath)
-	}
-
-	// Step 2: PDF to PNG using pdftoppm
-	outputBase := filepath.Join(outputDir, "slide")
-	cmd = exec.Command("pdftoppm", "-png", "-rx", "150", "-ry", "150", pdfPath, outputBase)
-	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("pdftoppm conversion failed: %v", err)
-	}
-
-	// Step 3: Rename slide-N.png to slide-000N.png for better sorting
-	files, err := filepath.Glob(filepath.Join(outputDir, "slide-*.png"))
-	if err != nil {
-		return nil, err
-	}
-
-	re := regexp.MustCompile(`slide-(\d+)\.png$`)
-	for _, f := range files {
-		matches := re.FindStringSubmatch(f)
-		if len(matches) > 1 {
-			num, _ := strconv.Atoi(matches[1])
-			newPath := filepath.Join(outputDir, fmt.Sprintf("slide-%04d.png", num))
-			os.Rename(f, newPath)
-		}
-	}
-
-	// Return numerically sorted file list
-	finalFiles, _ := filepath.Glob(filepath.Join(outputDir, "slide-*.png"))
-	sort.Strings(finalFiles)
-
-	return finalFiles, nil
-}
-
-// ExtractTags finds all {{tag}} patterns in the PPTX slides.
+// ExtractTags finds all {{tag}} patterns in the PPTX slides, including tags split across runs.
 func ExtractTags(pptxPath string) ([]string, error) {
 	r, err := zip.OpenReader(pptxPath)
 	if err != nil {
@@ -76,7 +20,6 @@ func ExtractTags(pptxPath string) ([]string, error) {
 	}
 	defer r.Close()
 
-	tagRegex := regexp.MustCompile(`{{(.*?)}}`)
 	tagMap := make(map[string]bool)
 
 	for _, f := range r.File {
@@ -91,10 +34,12 @@ func ExtractTags(pptxPath string) ([]string, error) {
 				continue
 			}
 
-			matches := tagRegex.FindAllStringSubmatch(string(content), -1)
-			for _, match := range matches {
-				if len(match) > 1 {
-					tagMap[match[1]] = true
+			// Scan paragraph text rather than raw XML: PowerPoint often splits a tag over several runs
+			for _, text := range paragraphTexts(content) {
+				for _, match := range tagRegex.FindAllStringSubmatch(text, -1) {
+					if len(match) > 1 {
+						tagMap[strings.TrimSpace(match[1])] = true
+					}
 				}
 			}
 		}
@@ -109,20 +54,108 @@ func ExtractTags(pptxPath string) ([]string, error) {
 
 // SlideData holds extracted text and style information for a slide.
 type SlideData struct {
-	SlideNumber int
+	SlideNumber int    // 1-based position in presentation order
+	Part        string // e.g. ppt/slides/slide7.xml
 	Text        string
+	Notes       string      // Speaker notes
+	Hidden      bool        // show="0": skipped in slide shows
+	Section     string      // name of the p14 section the slide belongs to
+	Layout      string      // slide layout part, e.g. ppt/slideLayouts/slideLayout2.xml
+	Media       []Media     // pictures, videos and audio used by the slide
 	Styles      interface{} // Changed to interface{} to support JSONSlide structure
 }
 
 // Structures for rich JSON extraction
 type JSONSlide struct {
-	Index  int     `json:"index"`
-	Shapes []Shape `json:"shapes"`
+	Index  int         `json:"index"`
+	Shapes []Shape     `json:"shapes"`
+	Tables []Table     `json:"tables,omitempty"`
+	Charts []Chart     `json:"charts,omitempty"`
+	Links  []Hyperlink `json:"links,omitempty"`
+}
+
+// Table is an <a:tbl> graphic frame. Rows keep the full grid: cells covered
+// by a merge are present with HMerge/VMerge set and no text.
+type Table struct {
+	Name   string        `json:"name,omitempty"`
+	Header []string      `json:"header"` // text of the first row
+	Rows   [][]TableCell `json:"rows"`
 }
 
+type TableCell struct {
+	Text     string `json:"text"`
+	GridSpan int    `json:"grid_span,omitempty"` // columns spanned by a merge origin
+	RowSpan  int    `json:"row_span,omitempty"`  // rows spanned by a merge origin
+	HMerge   bool   `json:"h_merge,omitempty"`   // continuation of a horizontal merge
+	VMerge   bool   `json:"v_merge,omitempty"`   // continuation of a vertical merge
+}
+
+// WriteCSV writes the table grid as CSV. Merged areas keep their text in the
+// origin cell; covered cells are empty.
+func (t *Table) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	for _, row := range t.Rows {
+		record := make([]string, len(row))
+		for i, c := range row {
+			record[i] = c.Text
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Shape is a node of the slide's shape tree (p:spTree). Group members are
+// nested in Children, in z-order like the top-level shapes.
 type Shape struct {
-	Type string    `json:"type"` // title | body | other
-	Runs []TextRun `json:"runs"`
+	ID         int         `json:"id,omitempty"`
+	Name       string      `json:"name,omitempty"`
+	Kind       string      `json:"kind"`              // sp | pic | grpSp | graphicFrame | cxnSp
+	Type       string      `json:"type"`              // placeholder role: title | body | other
+	Graphic    string      `json:"graphic,omitempty"` // graphic frames: table | chart | smartart | ole
+	AltText    string      `json:"alt_text,omitempty"`
+	Bounds     *Rect       `json:"bounds,omitempty"`
+	Link       string      `json:"link,omitempty"`  // click action of the whole shape
+	Image      string      `json:"image,omitempty"` // picture part of a pic or picture fill
+	Runs       []TextRun   `json:"runs,omitempty"`
+	Paragraphs []Paragraph `json:"paragraphs,omitempty"`
+	SmartArt   []string    `json:"smartart,omitempty"` // text of the diagram nodes
+	Children   []Shape     `json:"children,omitempty"`
+
+	diagramRel string // r:dm of a SmartArt frame, resolved by resolveSlideRels
+	linkRel    string // r:id of the shape's hlinkClick
+	imageRel   string // r:embed of the shape's a:blip
+
+	// Inputs of styleResolver
+	ph       *placeholderRef
+	lstStyle *listStyleXML
+	fontRef  *fontRefXML
+}
+
+type placeholderRef struct {
+	typ, idx string
+}
+
+// Paragraph is an a:p of a text shape. Bullet and alignment are only set when
+// the paragraph overrides them; otherwise they come from the layout or master.
+type Paragraph struct {
+	Level      int       `json:"level,omitempty"`       // a:pPr lvl, 0-8
+	Bullet     string    `json:"bullet,omitempty"`      // char | number | none
+	BulletChar string    `json:"bullet_char,omitempty"` // a:buChar char
+	Numbering  string    `json:"numbering,omitempty"`   // a:buAutoNum type, e.g. arabicPeriod
+	Align      string    `json:"align,omitempty"`       // l | ctr | r | just | dist
+	Runs       []TextRun `json:"runs"`
+}
+
+// Hyperlink is a click action found anywhere on the slide (runs, shapes and
+// table cells). Links to other slides use the slide part name as URL.
+type Hyperlink struct {
+	Text string `json:"text,omitempty"`
+	URL  string `json:"url"`
+
+	rel string
 }
 
 type TextRun struct {
@@ -131,60 +164,152 @@ type TextRun struct {
 	Size  int    `json:"size,omitempty"` // pt
 	Font  string `json:"font,omitempty"`
 	Color string `json:"color,omitempty"`
+	Link  string `json:"link,omitempty"` // hyperlink target
+
+	linkRel string
+	props   *runPropsXML // explicit a:rPr
 }
 
-// ExtractSlideContent extracts text and rich structure info from all slides in a PPTX.
+// ExtractSlideContent extracts text, speaker notes and rich structure info from
+// all slides in a PPTX. Slides are numbered by their position in
+// presentation.xml, which is also the page order of the rendered PDF.
 func ExtractSlideContent(pptxPath string) (map[int]SlideData, error) {
-	r, err := zip.OpenReader(pptxPath)
+	pkg, err := openPackage(pptxPath)
 	if err != nil {
 		return nil, err
 	}
-	defer r.Close()
+	defer pkg.Close()
+
+	slides, err := pkg.presentationSlides()
+	if err != nil || len(slides) == 0 {
+		slides = filenameOrder(pkg)
+	}
 
 	result := make(map[int]SlideData)
+	styles := newStyleResolver(pkg)
 
-	for _, f := range r.File {
-		// Proper check for slide files: starts with ppt/slides/slide and ends with .xml
-		if strings.HasPrefix(f.Name, "ppt/slides/slide") && strings.HasSuffix(f.Name, ".xml") {
-			// Extract index from filename, e.g., ppt/slides/slide1.xml -> 1
-			baseName := filepath.Base(f.Name)
-			numStr := strings.TrimSuffix(strings.TrimPrefix(baseName, "slide"), ".xml")
-			slideNum, err := strconv.Atoi(numStr)
-			if err != nil {
-				continue
-			}
+	for i, ps := range slides {
+		slideNum := i + 1
 
-			rc, err := f.Open()
-			if err != nil {
-				continue
-			}
+		body, err := pkg.read(ps.part)
+		if err != nil {
+			continue
+		}
 
-			jsonSlide, plainText, err := parseSlideXML(rc, slideNum)
-			rc.Close()
-			if err != nil {
-				continue // skip on error
-			}
+		jsonSlide, plainText, err := parseSlideXML(bytes.NewReader(body), slideNum)
+		if err != nil {
+			continue // skip on error
+		}
 
-			result[slideNum] = SlideData{
-				SlideNumber: slideNum,
-				Text:        strings.TrimSpace(plainText),
-				Styles:      jsonSlide, // Store the rich structure here
-			}
+		plainText += resolveSlideRels(pkg, ps.part, jsonSlide)
+		styles.apply(ps.part, jsonSlide)
+
+		// Charts contribute their cached numbers to the searchable text.
+		jsonSlide.Charts = extractCharts(pkg, ps.part, body)
+		for _, c := range jsonSlide.Charts {
+			plainText += "\n" + c.Text()
+		}
+
+		result[slideNum] = SlideData{
+			SlideNumber: slideNum,
+			Part:        ps.part,
+			Text:        strings.TrimSpace(plainText),
+			Notes:       extractNotes(pkg, ps.part),
+			Hidden:      hiddenSlideRegex.Match(body),
+			Section:     ps.section,
+			Layout:      pkg.relTarget(ps.part, relTypeSlideLayout),
+			Media:       extractMedia(pkg, ps.part),
+			Styles:      jsonSlide, // Store the rich structure here
 		}
 	}
 
 	return result, nil
 }
 
+var (
+	slideFileRegex   = regexp.MustCompile(`^ppt/slides/slide(\d+)\.xml$`)
+	hiddenSlideRegex = regexp.MustCompile(`<p:sld\b[^>]*?\bshow="(0|false)"`)
+)
+
+// filenameOrder lists the slide parts by the number in their file name, for
+// packages whose presentation.xml cannot be read.
+func filenameOrder(pkg *sourcePackage) []presentationSlide {
+	nums := make(map[string]int)
+	var slides []presentationSlide
+	for _, f := range pkg.zr.File {
+		if m := slideFileRegex.FindStringSubmatch(f.Name); m != nil {
+			nums[f.Name], _ = strconv.Atoi(m[1])
+			slides = append(slides, presentationSlide{part: f.Name})
+		}
+	}
+	sort.Slice(slides, func(i, j int) bool { return nums[slides[i].part] < nums[slides[j].part] })
+	return slides
+}
+
+// SlideThumbnails pairs rendered pages with slide numbers. Pages normally map
+// one to one; when the renderer skipped hidden slides (fewer pages, one per
+// visible slide) the pages are assigned to the visible slides in order.
+// Slides without a page are missing from the result.
+func SlideThumbnails(slides map[int]SlideData, pngFiles []string) map[int]string {
+	visible := 0
+	for _, s := range slides {
+		if !s.Hidden {
+			visible++
+		}
+	}
+	skipHidden := len(pngFiles) < len(slides) && len(pngFiles) == visible
+
+	thumbs := make(map[int]string)
+	page := 0
+	for n := 1; page < len(pngFiles); n++ {
+		if s, ok := slides[n]; ok && skipHidden && s.Hidden {
+			continue
+		}
+		thumbs[n] = pngFiles[page]
+		page++
+	}
+	return thumbs
+}
+
+var placeholderTypeRegex = regexp.MustCompile(`<p:ph\b[^>]*?\btype="([^"]*)"`)
+
+// extractNotes returns the speaker notes of a slide: the text of the body
+// placeholder of the notes slide linked from the slide's relationships.
+func extractNotes(pkg *sourcePackage, slidePart string) string {
+	notesPart := pkg.relTarget(slidePart, relTypeNotesSlide)
+	if notesPart == "" {
+		return ""
+	}
+	body, err := pkg.read(notesPart)
+	if err != nil {
+		return ""
+	}
+
+	var lines []string
+	for _, sp := range shapeRegex.FindAll(body, -1) {
+		ph := placeholderTypeRegex.FindSubmatch(sp)
+		if ph == nil || string(ph[1]) != "body" {
+			continue // slide image, slide number, header/footer
+		}
+		lines = append(lines, paragraphTexts(sp)...)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
 func parseSlideXML(r io.Reader, index int) (*JSONSlide, string, error) {
 	dec := xml.NewDecoder(r)
 
 	slide := &JSONSlide{Index: index}
 	var textBuilder strings.Builder
 
-	var currentShape *Shape
+	var stack []*shapeNode // open shapes, innermost last
+	var xfrm *shapeNode    // shape whose xfrm is being read
+	var currentPara *Paragraph
 	var currentRun *TextRun
-	var placeholderType string
+
+	var currentTable *Table
+	var currentCell *TableCell
+	var cellText strings.Builder
 
 	for {
 		tok, err := dec.Token()
@@ -195,58 +320,221 @@ func parseSlideXML(r io.Reader, index int) (*JSONSlide, string, error) {
 			return nil, "", err
 		}
 
+		var top *shapeNode
+		if len(stack) > 0 {
+			top = stack[len(stack)-1]
+		}
+
 		switch el := tok.(type) {
 
 		case xml.StartElement:
+			if shapeElements[el.Name.Local] {
+				stack = append(stack, &shapeNode{shape: &Shape{Kind: el.Name.Local, Type: "other"}})
+				continue
+			}
+
 			switch el.Name.Local {
 
+			case "Fallback": // mc:AlternateContent repeats the content for older readers
+				if err := dec.Skip(); err != nil {
+					return nil, "", err
+				}
+
+			case "cNvPr": // id, name and alt text of the enclosing shape
+				if top != nil && !top.named {
+					top.named = true
+					for _, a := range el.Attr {
+						switch a.Name.Local {
+						case "id":
+							top.shape.ID, _ = strconv.Atoi(a.Value)
+						case "name":
+							top.shape.Name = a.Value
+						case "descr":
+							top.shape.AltText = a.Value
+						case "title":
+							if top.shape.AltText == "" {
+								top.shape.AltText = a.Value
+							}
+						}
+					}
+				}
+
 			case "ph": // placeholder (title/body)
-				for _, a := range el.Attr {
-					if a.Name.Local == "type" {
-						placeholderType = a.Value
+				if top != nil {
+					top.shape.ph = &placeholderRef{}
+					for _, a := range el.Attr {
+						switch a.Name.Local {
+						case "type":
+							top.shape.ph.typ = a.Value
+						case "idx":
+							top.shape.ph.idx = a.Value
+						}
 					}
+					top.shape.Type = normalizePlaceholder(top.shape.ph.typ)
 				}
 
-			case "sp": // shape
-				currentShape = &Shape{
-					Type: normalizePlaceholder(placeholderType),
+			case "lstStyle": // list style of a text shape
+				if top != nil && currentCell == nil {
+					top.shape.lstStyle = &listStyleXML{}
+					if err := dec.DecodeElement(top.shape.lstStyle, &el); err != nil {
+						return nil, "", err
+					}
 				}
 
-			case "r": // text run
-				currentRun = &TextRun{}
+			case "style": // p:style of a shape; the font reference styles its text
+				if top != nil {
+					var style struct {
+						FontRef *fontRefXML `xml:"fontRef"`
+					}
+					if err := dec.DecodeElement(&style, &el); err != nil {
+						return nil, "", err
+					}
+					top.shape.fontRef = style.FontRef
+				}
 
-			case "rPr": // run formatting
-				if currentRun != nil {
+			case "xfrm": // position of the enclosing shape
+				if top != nil && !top.placed {
+					xfrm = top
+				}
+
+			case "off", "ext", "chOff", "chExt":
+				if xfrm != nil {
+					xfrm.readTransform(el)
+				}
+
+			case "graphicData":
+				if top != nil {
+					for _, a := range el.Attr {
+						if a.Name.Local == "uri" {
+							top.shape.Graphic = graphicKinds[a.Value]
+						}
+					}
+				}
+
+			case "blip": // picture of a pic, or picture fill of a shape
+				if top != nil && currentCell == nil {
+					top.shape.imageRel = attrValue(el.Attr, relsAttrNamespace, "embed")
+				}
+
+			case "relIds": // SmartArt data, layout, style and color parts
+				if top != nil {
+					for _, a := range el.Attr {
+						if a.Name.Local == "dm" {
+							top.shape.diagramRel = a.Value
+						}
+					}
+				}
+
+			case "hlinkClick": // in cNvPr: click action of the whole shape (runs: see rPr)
+				var rel, action string
+				for _, a := range el.Attr {
+					switch a.Name.Local {
+					case "id":
+						rel = a.Value
+					case "action":
+						action = a.Value
+					}
+				}
+				switch {
+				case top != nil && rel+action != "":
+					top.shape.linkRel, top.shape.Link = rel, action
+					slide.Links = append(slide.Links, Hyperlink{Text: top.shape.Name, URL: action, rel: rel})
+				}
+
+			case "p": // paragraph of a text shape
+				if top != nil && currentCell == nil {
+					currentPara = &Paragraph{Runs: []TextRun{}}
+				}
+
+			case "pPr": // paragraph properties
+				if currentPara != nil {
 					for _, a := range el.Attr {
 						switch a.Name.Local {
-						case "b":
-							currentRun.Bold = a.Value == "1"
-						case "sz":
-							if sz, err := strconv.Atoi(a.Value); err == nil {
-								currentRun.Size = sz / 100 // 1/100 pt
-							}
+						case "lvl":
+							currentPara.Level, _ = strconv.Atoi(a.Value)
+						case "algn":
+							currentPara.Align = a.Value
 						}
 					}
 				}
 
-			case "latin": // font family
-				if currentRun != nil {
+			case "buChar":
+				if currentPara != nil {
+					currentPara.Bullet = "char"
 					for _, a := range el.Attr {
-						if a.Name.Local == "typeface" {
-							currentRun.Font = a.Value
+						if a.Name.Local == "char" {
+							currentPara.BulletChar = a.Value
 						}
 					}
 				}
 
-			case "srgbClr": // color
-				if currentRun != nil {
+			case "buAutoNum":
+				if currentPara != nil {
+					currentPara.Bullet = "number"
 					for _, a := range el.Attr {
-						if a.Name.Local == "val" {
-							currentRun.Color = "#" + a.Value
+						if a.Name.Local == "type" {
+							currentPara.Numbering = a.Value
 						}
 					}
 				}
 
+			case "buNone":
+				if currentPara != nil {
+					currentPara.Bullet = "none"
+				}
+
+			case "tbl": // table
+				currentTable = &Table{}
+				if top != nil {
+					currentTable.Name = top.shape.Name
+				}
+
+			case "tr": // table row
+				if currentTable != nil {
+					currentTable.Rows = append(currentTable.Rows, nil)
+				}
+
+			case "tc": // table cell
+				if currentTable != nil {
+					currentCell = &TableCell{}
+					cellText.Reset()
+					for _, a := range el.Attr {
+						switch a.Name.Local {
+						case "gridSpan":
+							currentCell.GridSpan, _ = strconv.Atoi(a.Value)
+						case "rowSpan":
+							currentCell.RowSpan, _ = strconv.Atoi(a.Value)
+						case "hMerge":
+							currentCell.HMerge = a.Value == "1" || a.Value == "true"
+						case "vMerge":
+							currentCell.VMerge = a.Value == "1" || a.Value == "true"
+						}
+					}
+				}
+
+			case "r": // text run
+				currentRun = &TextRun{}
+
+			case "rPr": // run formatting; inherited values are filled in by styleResolver
+				if currentRun != nil {
+					props := &runPropsXML{}
+					if err := dec.DecodeElement(props, &el); err != nil {
+						return nil, "", err
+					}
+					currentRun.props = props
+					currentRun.Bold = props.Bold == "1" || props.Bold == "true"
+					currentRun.Size = props.Size / 100 // 1/100 pt
+					if props.Latin != nil {
+						currentRun.Font = props.Latin.Typeface
+					}
+					if c := props.Fill.color(); c != nil && c.XMLName.Local == "srgbClr" {
+						currentRun.Color = "#" + c.Val
+					}
+					if props.Hlink != nil {
+						currentRun.linkRel, currentRun.Link = props.Hlink.ID, props.Hlink.Action
+					}
+				}
+
 			case "t": // actual text
 				if currentRun != nil {
 					var text string
@@ -257,26 +545,84 @@ func parseSlideXML(r io.Reader, index int) (*JSONSlide, string, error) {
 			}
 
 		case xml.EndElement:
+			if shapeElements[el.Name.Local] && top != nil {
+				stack = stack[:len(stack)-1]
+				if top.shape.AltText != "" {
+					textBuilder.WriteString(top.shape.AltText)
+					textBuilder.WriteString(" ")
+				}
+				if len(stack) > 0 {
+					parent := stack[len(stack)-1].shape
+					parent.Children = append(parent.Children, *top.shape)
+				} else {
+					slide.Shapes = append(slide.Shapes, *top.shape)
+				}
+				continue
+			}
+
 			switch el.Name.Local {
 
+			case "xfrm":
+				if xfrm != nil {
+					var group *shapeNode
+					if len(stack) > 1 {
+						group = stack[len(stack)-2]
+					}
+					xfrm.place(group)
+					xfrm = nil
+				}
+
 			case "r":
-				if currentShape != nil &&
-					currentRun != nil {
+				if currentRun != nil && currentRun.Text != "" && currentRun.linkRel+currentRun.Link != "" {
+					slide.Links = append(slide.Links, Hyperlink{Text: currentRun.Text, URL: currentRun.Link, rel: currentRun.linkRel})
+				}
+				if currentCell != nil && currentRun != nil {
+					cellText.WriteString(currentRun.Text)
+					if currentRun.Text != "" {
+						textBuilder.WriteString(currentRun.Text)
+						textBuilder.WriteString(" ")
+					}
+				} else if top != nil && currentRun != nil {
 					// Append text to builder regardless of shape separation, adding space for separation
 					if currentRun.Text != "" {
-						currentShape.Runs = append(currentShape.Runs, *currentRun)
+						top.shape.Runs = append(top.shape.Runs, *currentRun)
+						if currentPara != nil {
+							currentPara.Runs = append(currentPara.Runs, *currentRun)
+						}
 						textBuilder.WriteString(currentRun.Text)
 						textBuilder.WriteString(" ")
 					}
 				}
 				currentRun = nil
 
-			case "sp":
-				if currentShape != nil && len(currentShape.Runs) > 0 {
-					slide.Shapes = append(slide.Shapes, *currentShape)
+			case "p":
+				if currentCell != nil && cellText.Len() > 0 {
+					// paragraph break inside a table cell
+					cellText.WriteString("\n")
+				} else if currentPara != nil && top != nil {
+					if len(currentPara.Runs) > 0 {
+						top.shape.Paragraphs = append(top.shape.Paragraphs, *currentPara)
+					}
+					currentPara = nil
+				}
+
+			case "tc":
+				if currentCell != nil && currentTable != nil && len(currentTable.Rows) > 0 {
+					currentCell.Text = strings.TrimSpace(cellText.String())
+					last := len(currentTable.Rows) - 1
+					currentTable.Rows[last] = append(currentTable.Rows[last], *currentCell)
 				}
-				currentShape = nil
-				placeholderType = ""
+				currentCell = nil
+
+			case "tbl":
+				if currentTable != nil && len(currentTable.Rows) > 0 {
+					currentTable.Header = []string{}
+					for _, c := range currentTable.Rows[0] {
+						currentTable.Header = append(currentTable.Header, c.Text)
+					}
+					slide.Tables = append(slide.Tables, *currentTable)
+				}
+				currentTable = nil
 			}
 		}
 	}
@@ -284,12 +630,16 @@ func parseSlideXML(r io.Reader, index int) (*JSONSlide, string, error) {
 	return slide, textBuilder.String(), nil
 }
 
+// normalizePlaceholder maps a placeholder type to the role of its shape. A
+// placeholder without a type is the content placeholder of e.g. "Title and
+// Content", a body; subtitles are styled like one but shown without bullets.
 func normalizePlaceholder(ph string) string {
-	switch ph {
-	case "title", "ctrTitle":
-		return "title"
-	case "body":
-		return "body"
+	if ph == "subTitle" {
+		return "other"
+	}
+	switch family := placeholderFamily(ph); family {
+	case "title", "body":
+		return family
 	default:
 		return "other"
 	}
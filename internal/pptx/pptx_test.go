@@ -0,0 +1,202 @@
+package pptx
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const shapesDeck = "testdata/shapes.pptx"
+
+// slideXML wraps shape tree children in a slide part.
+func slideXML(shapes string) string {
+	return `<p:sld xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships" ` +
+		`xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main">` +
+		`<p:cSld><p:spTree>` + shapes + `</p:spTree></p:cSld></p:sld>`
+}
+
+// tableXML builds a table graphic frame from rows of <a:tc> elements.
+func tableXML(name string, rows ...string) string {
+	var sb strings.Builder
+	sb.WriteString(`<p:graphicFrame><p:nvGraphicFramePr><p:cNvPr id="4" name="` + name + `"/></p:nvGraphicFramePr>`)
+	sb.WriteString(`<a:graphic><a:graphicData uri="http://schemas.openxmlformats.org/drawingml/2006/table"><a:tbl>`)
+	for _, r := range rows {
+		sb.WriteString("<a:tr>" + r + "</a:tr>")
+	}
+	sb.WriteString(`</a:tbl></a:graphicData></a:graphic></p:graphicFrame>`)
+	return sb.String()
+}
+
+// tc builds a table cell with one paragraph per line of text.
+func tc(attrs, text string) string {
+	var sb strings.Builder
+	sb.WriteString("<a:tc" + attrs + "><a:txBody>")
+	for _, line := range strings.Split(text, "\n") {
+		if line == "" {
+			sb.WriteString("<a:p/>")
+			continue
+		}
+		sb.WriteString("<a:p><a:r><a:t>" + line + "</a:t></a:r></a:p>")
+	}
+	sb.WriteString("</a:txBody></a:tc>")
+	return sb.String()
+}
+
+func TestParseSlideXMLTables(t *testing.T) {
+	tests := []struct {
+		name     string
+		shapes   string
+		want     []Table
+		wantText string
+	}{
+		{
+			name:   "simple table",
+			shapes: tableXML("Prices", tc("", "Item")+tc("", "Price"), tc("", "Tea")+tc("", "2")),
+			want: []Table{{
+				Name:   "Prices",
+				Header: []string{"Item", "Price"},
+				Rows:   [][]TableCell{{{Text: "Item"}, {Text: "Price"}}, {{Text: "Tea"}, {Text: "2"}}},
+			}},
+			wantText: "Item Price Tea 2",
+		},
+		{
+			name: "merged cells",
+			shapes: tableXML("Merged",
+				tc(` gridSpan="2"`, "Both")+tc(` hMerge="1"`, "")+tc(` rowSpan="2"`, "Tall"),
+				tc("", "a")+tc("", "b")+tc(` vMerge="true"`, ""),
+			),
+			want: []Table{{
+				Name:   "Merged",
+				Header: []string{"Both", "", "Tall"},
+				Rows: [][]TableCell{
+					{{Text: "Both", GridSpan: 2}, {HMerge: true}, {Text: "Tall", RowSpan: 2}},
+					{{Text: "a"}, {Text: "b"}, {VMerge: true}},
+				},
+			}},
+			wantText: "Both Tall a b",
+		},
+		{
+			name:   "paragraphs in a cell",
+			shapes: tableXML("Lines", tc("", "one\ntwo\n\nfour")),
+			want: []Table{{
+				Name:   "Lines",
+				Header: []string{"one\ntwo\n\nfour"},
+				Rows:   [][]TableCell{{{Text: "one\ntwo\n\nfour"}}},
+			}},
+			wantText: "one two four",
+		},
+		{
+			name: "table in a group",
+			shapes: `<p:grpSp><p:nvGrpSpPr><p:cNvPr id="2" name="Group"/></p:nvGrpSpPr>` +
+				tableXML("Grouped", tc("", "x")) + `</p:grpSp>`,
+			want:     []Table{{Name: "Grouped", Header: []string{"x"}, Rows: [][]TableCell{{{Text: "x"}}}}},
+			wantText: "x",
+		},
+		{
+			name:   "two tables",
+			shapes: tableXML("A", tc("", "1")) + tableXML("B", tc("", "2")),
+			want: []Table{
+				{Name: "A", Header: []string{"1"}, Rows: [][]TableCell{{{Text: "1"}}}},
+				{Name: "B", Header: []string{"2"}, Rows: [][]TableCell{{{Text: "2"}}}},
+			},
+			wantText: "1 2",
+		},
+		{
+			name:   "empty table",
+			shapes: tableXML("Empty"),
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slide, text, err := parseSlideXML(strings.NewReader(slideXML(tt.shapes)), 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(slide.Tables, tt.want) {
+				t.Errorf("tables = %+v\nwant     %+v", slide.Tables, tt.want)
+			}
+			if got := strings.Join(strings.Fields(text), " "); got != tt.wantText {
+				t.Errorf("text = %q, want %q", got, tt.wantText)
+			}
+			// Cell text belongs to the table, not to the graphic frame shape.
+			for _, s := range slide.Shapes {
+				if len(s.Runs) > 0 || len(s.Paragraphs) > 0 {
+					t.Errorf("shape %q has runs %+v", s.Name, s.Runs)
+				}
+			}
+		})
+	}
+}
+
+func TestExtractSlideContentTable(t *testing.T) {
+	slides, err := ExtractSlideContent(shapesDeck)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slide := slides[1].Styles.(*JSONSlide)
+	if len(slide.Tables) != 1 {
+		t.Fatalf("got %d tables, want 1", len(slide.Tables))
+	}
+
+	want := Table{
+		Name:   "Sales",
+		Header: []string{"Region", "Q1", "Q2"},
+		Rows: [][]TableCell{
+			{{Text: "Region"}, {Text: "Q1"}, {Text: "Q2"}},
+			{{Text: "North and South", GridSpan: 2}, {HMerge: true}, {Text: "7"}},
+			{{Text: "East", RowSpan: 2}, {Text: "1\n2"}, {Text: "3"}},
+			{{VMerge: true}, {Text: "4"}, {Text: `5, "6"`}},
+		},
+	}
+	if !reflect.DeepEqual(slide.Tables[0], want) {
+		t.Errorf("table = %+v\nwant    %+v", slide.Tables[0], want)
+	}
+	if !strings.Contains(slides[1].Text, "North and South 7 East") {
+		t.Errorf("table text missing from the slide text: %q", slides[1].Text)
+	}
+}
+
+func TestTableWriteCSV(t *testing.T) {
+	tests := []struct {
+		name  string
+		table Table
+		want  string
+	}{
+		{
+			name: "merged cells stay empty",
+			table: Table{Rows: [][]TableCell{
+				{{Text: "Both", GridSpan: 2}, {HMerge: true}},
+				{{Text: "a"}, {Text: "b"}},
+			}},
+			want: "Both,\na,b\n",
+		},
+		{
+			name: "quoting",
+			table: Table{Rows: [][]TableCell{
+				{{Text: `5, "6"`}, {Text: "1\n2"}},
+			}},
+			want: "\"5, \"\"6\"\"\",\"1\n2\"\n",
+		},
+		{
+			name:  "no rows",
+			table: Table{},
+			want:  "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.table.WriteCSV(&buf); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("csv = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
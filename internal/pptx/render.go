@@ -0,0 +1,295 @@
+package pptx
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/gnemet/SlideForge/internal/renderer"
+)
+
+// DefaultDPI is the resolution of slide thumbnails.
+const DefaultDPI = 150
+
+// PDFConverter exports a PPTX file as PDF into outDir, e.g. a renderer.Pool.
+type PDFConverter interface {
+	ConvertToPDF(ctx context.Context, pptxPath, outDir string) (string, error)
+}
+
+// RenderOptions select the pages of a render and where the PDF is cached.
+type RenderOptions struct {
+	Converter PDFConverter // default renderer.Default()
+	CacheDir  string       // intermediate PDFs by checksum; default <tmp>/slideforge/pdf
+	FirstPage int          // 1-based; 0 = first page
+	LastPage  int          // 0 = last page
+	DPI       int          // 0 = DefaultDPI
+}
+
+var renderedPageRegex = regexp.MustCompile(`-(\d+)\.png$`)
+
+// Renderer turns the slides of a deck into images. Page N is slide N, hidden
+// slides included.
+type Renderer interface {
+	// Name identifies the engine in logs and responses.
+	Name() string
+	// RenderSlides renders a page range (opts.FirstPage, opts.LastPage) to
+	// outputDir as slide-NNNN.png and returns the rendered files in page order.
+	RenderSlides(ctx context.Context, pptxPath, outputDir string, opts RenderOptions) ([]string, error)
+	// RenderRenditions renders the renditions of a page range into the
+	// existing thumbDir/renditions (see RenditionPath and the package-level
+	// RenderRenditions, which prepares the dir).
+	RenderRenditions(ctx context.Context, pptxPath, thumbDir string, opts RenderOptions, renditions []Rendition) ([]RenderedImage, error)
+}
+
+// Renderer engines, selected with NewRenderer.
+const (
+	EngineAuto        = "auto"
+	EngineLibreOffice = "libreoffice"
+	EngineSchematic   = "schematic"
+)
+
+// NewRenderer returns the renderer of an engine. "auto" (or "") picks
+// LibreOffice when soffice and pdftoppm are installed and the schematic
+// renderer otherwise.
+func NewRenderer(engine string) (Renderer, error) {
+	switch engine {
+	case "", EngineAuto:
+		if _, err := exec.LookPath("pdftoppm"); err == nil && renderer.Default().Available() {
+			return LibreOfficeRenderer{}, nil
+		}
+		return SchematicRenderer{}, nil
+	case EngineLibreOffice:
+		return LibreOfficeRenderer{}, nil
+	case EngineSchematic:
+		return SchematicRenderer{}, nil
+	}
+	return nil, fmt.Errorf("unknown renderer engine %q (want auto, libreoffice or schematic)", engine)
+}
+
+var (
+	defaultRendererMu sync.Mutex
+	defaultRenderer   Renderer
+)
+
+// DefaultRenderer returns the renderer set with SetDefaultRenderer, or the
+// one "auto" detects on first use.
+func DefaultRenderer() Renderer {
+	defaultRendererMu.Lock()
+	defer defaultRendererMu.Unlock()
+	if defaultRenderer == nil {
+		defaultRenderer, _ = NewRenderer(EngineAuto)
+	}
+	return defaultRenderer
+}
+
+// SetDefaultRenderer replaces the default renderer; call it once at startup.
+func SetDefaultRenderer(r Renderer) {
+	defaultRendererMu.Lock()
+	defaultRenderer = r
+	defaultRendererMu.Unlock()
+}
+
+// ExtractSlidesToPNG renders every slide of a PPTX file to outputDir as
+// slide-NNNN.png and returns all slide images of the directory, sorted.
+func ExtractSlidesToPNG(pptxPath, outputDir string) ([]string, error) {
+	return ExtractSlidesToPNGWithOptions(context.Background(), pptxPath, outputDir, RenderOptions{})
+}
+
+// ExtractSlidesToPNGWithOptions is ExtractSlidesToPNG with a PDF cache dir,
+// page range and resolution. Only the requested pages are rendered; the
+// other images in outputDir are kept, so a single slide can be re-rendered.
+func ExtractSlidesToPNGWithOptions(ctx context.Context, pptxPath, outputDir string, opts RenderOptions) ([]string, error) {
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output dir: %v", err)
+	}
+	if _, err := DefaultRenderer().RenderSlides(ctx, pptxPath, outputDir, opts); err != nil {
+		return nil, err
+	}
+
+	files, _ := filepath.Glob(filepath.Join(outputDir, "slide-*.png"))
+	sort.Strings(files)
+	return files, nil
+}
+
+// LibreOfficeRenderer exports the deck to PDF with LibreOffice (through the
+// PDF cache and the worker pool) and rasterizes the pages with pdftoppm.
+type LibreOfficeRenderer struct{}
+
+func (LibreOfficeRenderer) Name() string { return EngineLibreOffice }
+
+func (LibreOfficeRenderer) RenderSlides(ctx context.Context, pptxPath, outputDir string, opts RenderOptions) ([]string, error) {
+	pdfPath, err := ConvertToPDF(ctx, pptxPath, opts)
+	if err != nil {
+		return nil, err
+	}
+	return RenderPDFPages(ctx, pdfPath, outputDir, opts)
+}
+
+// ConvertToPDF returns the PDF export of a PPTX file, converting it with
+// LibreOffice only when the cache has no PDF for the file's checksum. Hidden
+// slides are exported too so that page N is slide N in presentation order.
+func ConvertToPDF(ctx context.Context, pptxPath string, opts RenderOptions) (string, error) {
+	cacheDir := opts.CacheDir
+	if cacheDir == "" {
+		cacheDir = filepath.Join(os.TempDir(), "slideforge", "pdf")
+	}
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create pdf cache dir: %v", err)
+	}
+	checksum, err := FileChecksum(pptxPath)
+	if err != nil {
+		return "", err
+	}
+	pdfPath := filepath.Join(cacheDir, checksum+".pdf")
+	if _, err := os.Stat(pdfPath); err == nil {
+		return pdfPath, nil
+	}
+
+	// Every conversion gets its own work dir, so concurrent jobs never share
+	// output files.
+	workDir, err := os.MkdirTemp("", "slideforge-render-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(workDir)
+
+	converter := opts.Converter
+	if converter == nil {
+		converter = renderer.Default()
+	}
+	converted, err := converter.ConvertToPDF(ctx, pptxPath, workDir)
+	if err != nil {
+		return "", err
+	}
+
+	// Publish atomically: another job may be converting the same file.
+	tmp, err := os.CreateTemp(cacheDir, checksum+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+	src, err := os.Open(converted)
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	_, err = io.Copy(tmp, src)
+	src.Close()
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), pdfPath)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("failed to cache pdf: %v", err)
+	}
+	return pdfPath, nil
+}
+
+// RenderPDFPages rasterizes a page range of a PDF with pdftoppm into
+// outputDir as slide-NNNN.png (NNNN = page number), replacing existing
+// images of those pages. It returns the rendered files in page order.
+func RenderPDFPages(ctx context.Context, pdfPath, outputDir string, opts RenderOptions) ([]string, error) {
+	dpi := opts.DPI
+	if dpi <= 0 {
+		dpi = DefaultDPI
+	}
+
+	workDir, err := os.MkdirTemp("", "slideforge-pages-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(workDir)
+
+	args := []string{"-png", "-r", strconv.Itoa(dpi)}
+	if opts.FirstPage > 0 {
+		args = append(args, "-f", strconv.Itoa(opts.FirstPage))
+	}
+	if opts.LastPage > 0 {
+		args = append(args, "-l", strconv.Itoa(opts.LastPage))
+	}
+	args = append(args, pdfPath, filepath.Join(workDir, "page"))
+	if out, err := exec.CommandContext(ctx, "pdftoppm", args...).CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("pdftoppm conversion failed: %v: %s", err, out)
+	}
+
+	// pdftoppm pads page numbers to the width of the page count; normalize
+	// to slide-000N.png for sorting.
+	pages, _ := filepath.Glob(filepath.Join(workDir, "page-*.png"))
+	var files []string
+	for _, p := range pages {
+		m := renderedPageRegex.FindStringSubmatch(p)
+		if m == nil {
+			continue
+		}
+		num, _ := strconv.Atoi(m[1])
+		dest := filepath.Join(outputDir, fmt.Sprintf("slide-%04d.png", num))
+		if err := moveFile(p, dest); err != nil {
+			return files, err
+		}
+		files = append(files, dest)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// ThumbnailPage returns the page number of a slide-NNNN.png thumbnail, 0 if
+// the name has none.
+func ThumbnailPage(path string) int {
+	m := renderedPageRegex.FindStringSubmatch(path)
+	if m == nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(m[1])
+	return n
+}
+
+// FileChecksum returns the hex SHA-256 of a file.
+func FileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// moveFile renames a file, copying it when source and destination are on
+// different file systems (the work dir is in the system temp dir).
+func moveFile(src, dest string) error {
+	if err := os.Rename(src, dest); err == nil {
+		return nil
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
@@ -0,0 +1,230 @@
+package pptx
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// Rendition is an image variant rendered for every slide, e.g. a small WebP
+// thumbnail for trees and a large preview for modals.
+type Rendition struct {
+	Name    string   // thumb, preview, full
+	Width   int      // pixels; 0 renders at DPI
+	DPI     int      // resolution when Width is 0 (default DefaultDPI)
+	Formats []string // webp, jpeg, png; in order of preference
+	Quality int      // jpeg and webp quality (default 80)
+}
+
+// DefaultRenditions are used when none are configured.
+var DefaultRenditions = []Rendition{
+	{Name: "thumb", Width: 320, Formats: []string{"webp", "jpeg"}, Quality: 80},
+	{Name: "preview", Width: 1280, Formats: []string{"webp", "jpeg"}, Quality: 85},
+	{Name: "full", DPI: 300, Formats: []string{"png"}},
+}
+
+// RenderedImage is one rendition of one slide.
+type RenderedImage struct {
+	Page   int // = slide number
+	Name   string
+	Format string
+	Path   string
+	Width  int
+	Height int
+	Size   int64
+	SHA256 string
+}
+
+// RenditionsDir is the subdirectory of a deck's thumbnail dir holding the
+// renditions, named slide-NNNN.<rendition>.<ext>.
+const RenditionsDir = "renditions"
+
+var formatExt = map[string]string{"webp": "webp", "jpeg": "jpg", "png": "png"}
+
+// RenditionPath returns the file of a slide rendition below a thumbnail dir.
+func RenditionPath(thumbDir string, page int, name, format string) string {
+	return filepath.Join(thumbDir, RenditionsDir, fmt.Sprintf("slide-%04d.%s.%s", page, name, formatExt[format]))
+}
+
+var rasterPageRegex = regexp.MustCompile(`-(\d+)\.(?:png|jpg)$`)
+
+// RenderRenditions renders the configured renditions of a page range
+// (opts.FirstPage, opts.LastPage) with the default renderer. WebP needs cwebp
+// on the PATH; without it the WebP variants are skipped and clients get the
+// next format.
+func RenderRenditions(ctx context.Context, pptxPath, thumbDir string, opts RenderOptions, renditions []Rendition) ([]RenderedImage, error) {
+	if len(renditions) == 0 {
+		renditions = DefaultRenditions
+	}
+	if err := os.MkdirAll(filepath.Join(thumbDir, RenditionsDir), 0755); err != nil {
+		return nil, err
+	}
+	return DefaultRenderer().RenderRenditions(ctx, pptxPath, thumbDir, opts, renditions)
+}
+
+// RenderRenditions rasterizes the renditions from the cached PDF of the deck,
+// one pdftoppm run per rendition and raster format.
+func (LibreOfficeRenderer) RenderRenditions(ctx context.Context, pptxPath, thumbDir string, opts RenderOptions, renditions []Rendition) ([]RenderedImage, error) {
+	pdfPath, err := ConvertToPDF(ctx, pptxPath, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	workDir, err := os.MkdirTemp("", "slideforge-renditions-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(workDir)
+
+	_, cwebpErr := exec.LookPath("cwebp")
+
+	var images []RenderedImage
+	for _, r := range renditions {
+		want := make(map[string]bool)
+		for _, f := range r.Formats {
+			want[f] = true
+		}
+		quality := r.Quality
+		if quality <= 0 {
+			quality = 80
+		}
+
+		// One pdftoppm run per raster format; WebP is encoded from the PNG.
+		if want["jpeg"] {
+			pages, err := rasterize(ctx, pdfPath, filepath.Join(workDir, r.Name+"-jpeg"), r, opts, "-jpeg", "-jpegopt", "quality="+strconv.Itoa(quality))
+			if err != nil {
+				return images, err
+			}
+			for page, src := range pages {
+				img, err := publishRendition(src, RenditionPath(thumbDir, page, r.Name, "jpeg"), page, r.Name, "jpeg")
+				if err != nil {
+					return images, err
+				}
+				images = append(images, img)
+			}
+		}
+		if want["png"] || (want["webp"] && cwebpErr == nil) {
+			pages, err := rasterize(ctx, pdfPath, filepath.Join(workDir, r.Name+"-png"), r, opts, "-png")
+			if err != nil {
+				return images, err
+			}
+			for page, src := range pages {
+				if want["webp"] && cwebpErr == nil {
+					dest := RenditionPath(thumbDir, page, r.Name, "webp")
+					if err := encodeWebP(ctx, src, dest, quality); err != nil {
+						return images, err
+					}
+					img, err := describeRendition(dest, page, r.Name, "webp")
+					if err != nil {
+						return images, err
+					}
+					// Go has no WebP decoder in the standard library: take the
+					// size from the PNG it was encoded from.
+					if cfg, err := decodeImageConfig(src); err == nil {
+						img.Width, img.Height = cfg.Width, cfg.Height
+					}
+					images = append(images, img)
+				}
+				if want["png"] {
+					img, err := publishRendition(src, RenditionPath(thumbDir, page, r.Name, "png"), page, r.Name, "png")
+					if err != nil {
+						return images, err
+					}
+					images = append(images, img)
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(images, func(i, j int) bool { return images[i].Page < images[j].Page })
+	return images, nil
+}
+
+// rasterize runs pdftoppm for one rendition into dir and returns the output
+// files by page number.
+func rasterize(ctx context.Context, pdfPath, dir string, r Rendition, opts RenderOptions, format ...string) (map[int]string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	args := append([]string{}, format...)
+	if r.Width > 0 {
+		args = append(args, "-scale-to-x", strconv.Itoa(r.Width), "-scale-to-y", "-1")
+	} else {
+		dpi := r.DPI
+		if dpi <= 0 {
+			dpi = DefaultDPI
+		}
+		args = append(args, "-r", strconv.Itoa(dpi))
+	}
+	if opts.FirstPage > 0 {
+		args = append(args, "-f", strconv.Itoa(opts.FirstPage))
+	}
+	if opts.LastPage > 0 {
+		args = append(args, "-l", strconv.Itoa(opts.LastPage))
+	}
+	args = append(args, pdfPath, filepath.Join(dir, "page"))
+	if out, err := exec.CommandContext(ctx, "pdftoppm", args...).CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("pdftoppm failed for rendition %s: %v: %s", r.Name, err, out)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "page-*"))
+	pages := make(map[int]string)
+	for _, f := range files {
+		if m := rasterPageRegex.FindStringSubmatch(f); m != nil {
+			n, _ := strconv.Atoi(m[1])
+			pages[n] = f
+		}
+	}
+	return pages, nil
+}
+
+// encodeWebP converts an image to WebP with cwebp.
+func encodeWebP(ctx context.Context, src, dest string, quality int) error {
+	if out, err := exec.CommandContext(ctx, "cwebp", "-quiet", "-q", strconv.Itoa(quality), src, "-o", dest).CombinedOutput(); err != nil {
+		return fmt.Errorf("cwebp failed: %v: %s", err, out)
+	}
+	return nil
+}
+
+// publishRendition moves a rendered file into place and describes it.
+func publishRendition(src, dest string, page int, name, format string) (RenderedImage, error) {
+	if err := moveFile(src, dest); err != nil {
+		return RenderedImage{}, err
+	}
+	img, err := describeRendition(dest, page, name, format)
+	if err != nil {
+		return img, err
+	}
+	if cfg, err := decodeImageConfig(dest); err == nil {
+		img.Width, img.Height = cfg.Width, cfg.Height
+	}
+	return img, nil
+}
+
+func describeRendition(path string, page int, name, format string) (RenderedImage, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return RenderedImage{}, err
+	}
+	sum, err := FileChecksum(path)
+	if err != nil {
+		return RenderedImage{}, err
+	}
+	return RenderedImage{Page: page, Name: name, Format: format, Path: path, Size: info.Size(), SHA256: sum}, nil
+}
+
+func decodeImageConfig(path string) (image.Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return image.Config{}, err
+	}
+	defer f.Close()
+	cfg, _, err := image.DecodeConfig(f)
+	return cfg, err
+}
@@ -0,0 +1,475 @@
+package pptx
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// SchematicRenderer draws slide previews from the extracted slide structure
+// (JSONSlide) in pure Go, for machines without LibreOffice: shape outlines,
+// text in a built-in bitmap font, embedded pictures and labelled boxes for
+// tables, charts and SmartArt. Fills, real fonts and effects are not drawn;
+// the images are meant to recognize a slide, not to replace a real render.
+type SchematicRenderer struct{}
+
+func (SchematicRenderer) Name() string { return EngineSchematic }
+
+func (SchematicRenderer) RenderSlides(ctx context.Context, pptxPath, outputDir string, opts RenderOptions) ([]string, error) {
+	deck, err := openSchematicDeck(pptxPath)
+	if err != nil {
+		return nil, err
+	}
+	defer deck.Close()
+
+	dpi := opts.DPI
+	if dpi <= 0 {
+		dpi = DefaultDPI
+	}
+	var files []string
+	for _, page := range deck.pages(opts) {
+		if err := ctx.Err(); err != nil {
+			return files, err
+		}
+		dest := filepath.Join(outputDir, fmt.Sprintf("slide-%04d.png", page))
+		if err := writeImage(dest, deck.draw(page, deck.widthAt(dpi)), "png", 0); err != nil {
+			return files, err
+		}
+		files = append(files, dest)
+	}
+	return files, nil
+}
+
+func (SchematicRenderer) RenderRenditions(ctx context.Context, pptxPath, thumbDir string, opts RenderOptions, renditions []Rendition) ([]RenderedImage, error) {
+	deck, err := openSchematicDeck(pptxPath)
+	if err != nil {
+		return nil, err
+	}
+	defer deck.Close()
+
+	workDir, err := os.MkdirTemp("", "slideforge-renditions-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(workDir)
+
+	_, cwebpErr := exec.LookPath("cwebp")
+
+	var images []RenderedImage
+	for _, page := range deck.pages(opts) {
+		for _, r := range renditions {
+			if err := ctx.Err(); err != nil {
+				return images, err
+			}
+			width := r.Width
+			if width <= 0 {
+				dpi := r.DPI
+				if dpi <= 0 {
+					dpi = DefaultDPI
+				}
+				width = deck.widthAt(dpi)
+			}
+			quality := r.Quality
+			if quality <= 0 {
+				quality = 80
+			}
+			img := deck.draw(page, width)
+
+			for _, format := range r.Formats {
+				dest := RenditionPath(thumbDir, page, r.Name, format)
+				switch format {
+				case "png", "jpeg":
+					err = writeImage(dest, img, format, quality)
+				case "webp":
+					if cwebpErr != nil {
+						continue
+					}
+					src := filepath.Join(workDir, fmt.Sprintf("page-%d.%s.png", page, r.Name))
+					if err = writeImage(src, img, "png", 0); err == nil {
+						err = encodeWebP(ctx, src, dest, quality)
+					}
+				default:
+					continue
+				}
+				if err != nil {
+					return images, err
+				}
+				ri, err := describeRendition(dest, page, r.Name, format)
+				if err != nil {
+					return images, err
+				}
+				ri.Width, ri.Height = img.Bounds().Dx(), img.Bounds().Dy()
+				images = append(images, ri)
+			}
+		}
+	}
+	return images, nil
+}
+
+// schematicDeck is a deck opened for drawing: the slide structure plus the
+// package the pictures are read from.
+type schematicDeck struct {
+	pkg    *sourcePackage
+	cx, cy int64 // slide size in EMU
+	slides map[int]*JSONSlide
+	count  int
+}
+
+func openSchematicDeck(pptxPath string) (*schematicDeck, error) {
+	content, err := ExtractSlideContent(pptxPath)
+	if err != nil {
+		return nil, err
+	}
+	pkg, err := openPackage(pptxPath)
+	if err != nil {
+		return nil, err
+	}
+	d := &schematicDeck{pkg: pkg, slides: make(map[int]*JSONSlide)}
+	d.cx, d.cy = pkg.slideSize()
+	for n, s := range content {
+		if js, ok := s.Styles.(*JSONSlide); ok {
+			d.slides[n] = js
+		}
+		if n > d.count {
+			d.count = n
+		}
+	}
+	return d, nil
+}
+
+func (d *schematicDeck) Close() error {
+	return d.pkg.Close()
+}
+
+// pages returns the page numbers of the requested range.
+func (d *schematicDeck) pages(opts RenderOptions) []int {
+	first, last := opts.FirstPage, opts.LastPage
+	if first < 1 {
+		first = 1
+	}
+	if last < 1 || last > d.count {
+		last = d.count
+	}
+	var pages []int
+	for n := first; n <= last; n++ {
+		pages = append(pages, n)
+	}
+	return pages
+}
+
+// widthAt returns the image width of a slide at a resolution.
+func (d *schematicDeck) widthAt(dpi int) int {
+	return int(d.cx * int64(dpi) / emuPerInch)
+}
+
+const (
+	emuPerInch = 914400
+	emuPerPt   = 12700
+)
+
+var (
+	schematicOutline = color.RGBA{0xC8, 0xC8, 0xC8, 0xFF}
+	schematicFrame   = color.RGBA{0xF2, 0xF2, 0xF2, 0xFF}
+	schematicText    = color.RGBA{0x40, 0x40, 0x40, 0xFF}
+	schematicMissing = color.RGBA{0x99, 0x99, 0x99, 0xFF}
+)
+
+// draw renders a slide at the given width; a page without slide data is
+// drawn blank so that page N stays slide N.
+func (d *schematicDeck) draw(page, width int) *image.RGBA {
+	if width < 1 {
+		width = 1
+	}
+	height := int(int64(width) * d.cy / d.cx)
+	img := image.NewRGBA(image.Rect(0, 0, width, max(height, 1)))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+
+	if slide, ok := d.slides[page]; ok {
+		scale := float64(width) / float64(d.cx)
+		for i := range slide.Shapes {
+			d.drawShape(img, &slide.Shapes[i], scale)
+		}
+	}
+	return img
+}
+
+func (d *schematicDeck) drawShape(img *image.RGBA, s *Shape, scale float64) {
+	if s.Bounds != nil {
+		r := image.Rect(
+			int(float64(s.Bounds.X)*scale), int(float64(s.Bounds.Y)*scale),
+			int(float64(s.Bounds.X+s.Bounds.CX)*scale), int(float64(s.Bounds.Y+s.Bounds.CY)*scale))
+
+		switch {
+		case s.Image != "":
+			if !d.drawPicture(img, s.Image, r) {
+				fillRect(img, r, schematicFrame)
+				strokeRect(img, r, schematicMissing)
+				drawLine(img, r.Min, r.Max, schematicMissing)
+				drawLine(img, image.Pt(r.Min.X, r.Max.Y), image.Pt(r.Max.X, r.Min.Y), schematicMissing)
+			}
+		case s.Kind == "cxnSp":
+			drawLine(img, r.Min, r.Max, schematicText)
+		case s.Kind == "graphicFrame":
+			fillRect(img, r, schematicFrame)
+			strokeRect(img, r, schematicOutline)
+			if label := s.Graphic; label != "" {
+				unit := max(1, r.Dy()/40)
+				drawString(img, label, r.Min.X+(r.Dx()-textWidth(label, unit))/2, r.Min.Y+(r.Dy()-7*unit)/2, unit, schematicMissing, false)
+			}
+		case s.Kind == "sp":
+			strokeRect(img, r, schematicOutline)
+		}
+
+		if s.Kind == "sp" {
+			drawShapeText(img, s, r, scale)
+		}
+	}
+	for i := range s.Children {
+		d.drawShape(img, &s.Children[i], scale)
+	}
+}
+
+// drawPicture scales a picture part into r (nearest neighbour).
+func (d *schematicDeck) drawPicture(img *image.RGBA, part string, r image.Rectangle) bool {
+	data, err := d.pkg.read(part)
+	if err != nil {
+		return false
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil || r.Dx() <= 0 || r.Dy() <= 0 {
+		return false
+	}
+	sb := src.Bounds()
+	clip := r.Intersect(img.Bounds())
+	for y := clip.Min.Y; y < clip.Max.Y; y++ {
+		sy := sb.Min.Y + (y-r.Min.Y)*sb.Dy()/r.Dy()
+		for x := clip.Min.X; x < clip.Max.X; x++ {
+			sx := sb.Min.X + (x-r.Min.X)*sb.Dx()/r.Dx()
+			img.Set(x, y, over(img.RGBAAt(x, y), src.At(sx, sy)))
+		}
+	}
+	return true
+}
+
+// drawShapeText lays out the paragraphs of a text shape inside its bounds,
+// wrapping at word boundaries.
+func drawShapeText(img *image.RGBA, s *Shape, r image.Rectangle, scale float64) {
+	paragraphs := s.Paragraphs
+	if len(paragraphs) == 0 && len(s.Runs) > 0 {
+		paragraphs = []Paragraph{{Runs: s.Runs}}
+	}
+	inset := int(0.1 * emuPerInch * scale)
+	y := r.Min.Y + inset
+	number := 0
+
+	for _, p := range paragraphs {
+		if y >= img.Bounds().Max.Y {
+			return
+		}
+		var text strings.Builder
+		switch p.Bullet {
+		case "char":
+			text.WriteString("- ")
+			number = 0
+		case "number":
+			number++
+			text.WriteString(strconv.Itoa(number) + ". ")
+		default:
+			number = 0
+		}
+		size, col, bold := 18, schematicText, false
+		for i, run := range p.Runs {
+			text.WriteString(run.Text)
+			if i == 0 {
+				if run.Size > 0 {
+					size = run.Size
+				}
+				if c, ok := parseHexColor(run.Color); ok {
+					col = c
+				}
+				bold = run.Bold
+			}
+		}
+
+		// The 7 rows of a glyph are roughly the cap height, 0.7 of the size.
+		px := float64(size*emuPerPt) * scale
+		unit := max(1, int(px*0.7/7+0.5))
+		lineHeight := max(9*unit, int(px*1.2))
+		indent := int(float64(p.Level) * 0.375 * emuPerInch * scale)
+
+		left := r.Min.X + inset + indent
+		avail := r.Max.X - inset - left
+		for _, line := range wrapText(glyphFold.Replace(text.String()), max(1, avail/(6*unit))) {
+			x := left
+			switch p.Align {
+			case "ctr":
+				x += (avail - textWidth(line, unit)) / 2
+			case "r":
+				x += avail - textWidth(line, unit)
+			}
+			drawString(img, line, x, y, unit, col, bold)
+			y += lineHeight
+		}
+	}
+}
+
+// wrapText breaks text into lines of at most width characters, at spaces
+// where possible.
+func wrapText(text string, width int) []string {
+	var lines []string
+	for _, para := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(para) {
+			for len([]rune(word)) > width {
+				if line != "" {
+					lines = append(lines, line)
+					line = ""
+				}
+				rs := []rune(word)
+				lines = append(lines, string(rs[:width]))
+				word = string(rs[width:])
+			}
+			switch {
+			case line == "":
+				line = word
+			case len([]rune(line))+1+len([]rune(word)) <= width:
+				line += " " + word
+			default:
+				lines = append(lines, line)
+				line = word
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func textWidth(s string, unit int) int {
+	return len([]rune(s)) * 6 * unit
+}
+
+// drawString draws text with its top-left corner at (x, y), each font pixel
+// a unit x unit square. Bold text is drawn twice, one unit apart.
+func drawString(img *image.RGBA, s string, x, y, unit int, c color.RGBA, bold bool) {
+	for _, r := range s {
+		g := glyph(r)
+		for col := 0; col < 5; col++ {
+			for row := 0; row < 7; row++ {
+				if g[col]&(1<<row) == 0 {
+					continue
+				}
+				px := image.Rect(x+col*unit, y+row*unit, x+(col+1)*unit, y+(row+1)*unit)
+				if bold {
+					px.Max.X += max(1, unit/2)
+				}
+				fillRect(img, px, c)
+			}
+		}
+		x += 6 * unit
+	}
+}
+
+func fillRect(img *image.RGBA, r image.Rectangle, c color.RGBA) {
+	draw.Draw(img, r, &image.Uniform{C: c}, image.Point{}, draw.Src)
+}
+
+func strokeRect(img *image.RGBA, r image.Rectangle, c color.RGBA) {
+	fillRect(img, image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Min.Y+1), c)
+	fillRect(img, image.Rect(r.Min.X, r.Max.Y-1, r.Max.X, r.Max.Y), c)
+	fillRect(img, image.Rect(r.Min.X, r.Min.Y, r.Min.X+1, r.Max.Y), c)
+	fillRect(img, image.Rect(r.Max.X-1, r.Min.Y, r.Max.X, r.Max.Y), c)
+}
+
+// drawLine draws a 1 pixel line (Bresenham).
+func drawLine(img *image.RGBA, a, b image.Point, c color.RGBA) {
+	dx, dy := abs(b.X-a.X), -abs(b.Y-a.Y)
+	sx, sy := 1, 1
+	if a.X > b.X {
+		sx = -1
+	}
+	if a.Y > b.Y {
+		sy = -1
+	}
+	e := dx + dy
+	for {
+		if (image.Point{a.X, a.Y}).In(img.Bounds()) {
+			img.SetRGBA(a.X, a.Y, c)
+		}
+		if a == b {
+			return
+		}
+		if e2 := 2 * e; e2 >= dy {
+			e += dy
+			a.X += sx
+		} else {
+			e += dx
+			a.Y += sy
+		}
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// over composites a picture pixel onto the background.
+func over(dst color.RGBA, src color.Color) color.RGBA {
+	r, g, b, a := src.RGBA()
+	if a == 0xFFFF {
+		return color.RGBA{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8), 0xFF}
+	}
+	blend := func(s uint32, d uint8) uint8 {
+		return uint8((s + uint32(d)*0x101*(0xFFFF-a)/0xFFFF) >> 8)
+	}
+	return color.RGBA{blend(r, dst.R), blend(g, dst.G), blend(b, dst.B), 0xFF}
+}
+
+// parseHexColor parses the #RRGGBB colors of resolved text runs.
+func parseHexColor(s string) (color.RGBA, bool) {
+	if len(s) != 7 || s[0] != '#' {
+		return color.RGBA{}, false
+	}
+	v, err := strconv.ParseUint(s[1:], 16, 32)
+	if err != nil {
+		return color.RGBA{}, false
+	}
+	return color.RGBA{uint8(v >> 16), uint8(v >> 8), uint8(v), 0xFF}, true
+}
+
+// writeImage encodes an image as png or jpeg and publishes it atomically, as
+// thumbnails may be served while they are re-rendered.
+func writeImage(path string, img image.Image, format string, quality int) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if format == "jpeg" {
+		err = jpeg.Encode(tmp, img, &jpeg.Options{Quality: quality})
+	} else {
+		err = png.Encode(tmp, img)
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write %s: %v", filepath.Base(path), err)
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package pptx
+
+import (
+	"encoding/xml"
+	"strconv"
+	"strings"
+)
+
+// Rect is a position and size in EMU (914400 per inch), in slide coordinates.
+type Rect struct {
+	X  int64 `json:"x"`
+	Y  int64 `json:"y"`
+	CX int64 `json:"cx"`
+	CY int64 `json:"cy"`
+}
+
+// shapeNode is a shape being parsed, with the raw transform of its xfrm.
+type shapeNode struct {
+	shape   *Shape
+	named   bool     // cNvPr seen; nested cNvPr belong to children
+	placed  bool     // xfrm seen
+	off     [2]int64 // a:off x, y
+	ext     [2]int64 // a:ext cx, cy
+	chOff   [2]int64 // groups: child coordinate space
+	chExt   [2]int64
+	hasXfrm bool
+}
+
+// shapeElements are the p:spTree children that become Shape nodes.
+var shapeElements = map[string]bool{"sp": true, "pic": true, "grpSp": true, "graphicFrame": true, "cxnSp": true}
+
+// graphicKinds maps a:graphicData URIs to Shape.Graphic.
+var graphicKinds = map[string]string{
+	"http://schemas.openxmlformats.org/drawingml/2006/table":    "table",
+	"http://schemas.openxmlformats.org/drawingml/2006/chart":    "chart",
+	"http://schemas.openxmlformats.org/drawingml/2006/diagram":  "smartart",
+	"http://schemas.openxmlformats.org/presentationml/2006/ole": "ole",
+}
+
+// readTransform stores the off/ext/chOff/chExt attributes of an xfrm child.
+func (n *shapeNode) readTransform(el xml.StartElement) {
+	var a, b int64
+	for _, attr := range el.Attr {
+		v, _ := strconv.ParseInt(attr.Value, 10, 64)
+		switch attr.Name.Local {
+		case "x", "cx":
+			a = v
+		case "y", "cy":
+			b = v
+		}
+	}
+	switch el.Name.Local {
+	case "off":
+		n.off = [2]int64{a, b}
+	case "ext":
+		n.ext = [2]int64{a, b}
+	case "chOff":
+		n.chOff = [2]int64{a, b}
+	case "chExt":
+		n.chExt = [2]int64{a, b}
+	}
+	n.hasXfrm = true
+}
+
+// place sets the slide-coordinate bounds of a shape once its xfrm is read.
+// Members of a group are positioned in the group's child space (chOff/chExt),
+// which is scaled onto the group's own bounds.
+func (n *shapeNode) place(group *shapeNode) {
+	n.placed = true
+	if !n.hasXfrm {
+		return
+	}
+	r := Rect{X: n.off[0], Y: n.off[1], CX: n.ext[0], CY: n.ext[1]}
+	if group != nil && group.shape.Bounds != nil {
+		g := group.shape.Bounds
+		sx, sy := 1.0, 1.0
+		if group.chExt[0] != 0 {
+			sx = float64(g.CX) / float64(group.chExt[0])
+		}
+		if group.chExt[1] != 0 {
+			sy = float64(g.CY) / float64(group.chExt[1])
+		}
+		r = Rect{
+			X:  g.X + int64(float64(r.X-group.chOff[0])*sx),
+			Y:  g.Y + int64(float64(r.Y-group.chOff[1])*sy),
+			CX: int64(float64(r.CX) * sx),
+			CY: int64(float64(r.CY) * sy),
+		}
+	}
+	n.shape.Bounds = &r
+}
+
+// diagramDataXML reads the points of a SmartArt data part (ppt/diagrams/dataN.xml).
+type diagramDataXML struct {
+	Points []struct {
+		Type       string `xml:"type,attr"`
+		Paragraphs []struct {
+			Runs []string `xml:"r>t"`
+		} `xml:"t>p"`
+	} `xml:"ptLst>pt"`
+}
+
+// diagramText returns the text of the content nodes of a SmartArt diagram, in
+// data order. Transitions and presentation points carry no text of their own.
+func diagramText(data []byte) []string {
+	var d diagramDataXML
+	if err := xml.Unmarshal(data, &d); err != nil {
+		return nil
+	}
+	var texts []string
+	for _, pt := range d.Points {
+		if pt.Type != "" && pt.Type != "node" && pt.Type != "asst" {
+			continue
+		}
+		var lines []string
+		for _, p := range pt.Paragraphs {
+			if line := strings.TrimSpace(strings.Join(p.Runs, "")); line != "" {
+				lines = append(lines, line)
+			}
+		}
+		if len(lines) > 0 {
+			texts = append(texts, strings.Join(lines, "\n"))
+		}
+	}
+	return texts
+}
+
+// resolveSlideRels resolves what the slide XML references by relationship id:
+// it fills the SmartArt text of diagram frames from their data parts and the
+// targets of hyperlinks. It returns the SmartArt text for the plain slide text.
+func resolveSlideRels(pkg *sourcePackage, slidePart string, slide *JSONSlide) string {
+	targets := make(map[string]string)
+	rels, _ := pkg.rels(slidePart)
+	for _, rel := range rels {
+		if rel.isExternal() {
+			targets[rel.ID] = rel.Target
+		} else {
+			targets[rel.ID] = resolveTarget(slidePart, rel.Target)
+		}
+	}
+	link := func(rel, fallback string) string {
+		if t, ok := targets[rel]; ok && rel != "" {
+			return t
+		}
+		return fallback
+	}
+
+	var sb strings.Builder
+	var walk func(shapes []Shape)
+	walk = func(shapes []Shape) {
+		for i := range shapes {
+			s := &shapes[i]
+			s.Link = link(s.linkRel, s.Link)
+			s.Image = link(s.imageRel, s.Image)
+			for j := range s.Runs {
+				s.Runs[j].Link = link(s.Runs[j].linkRel, s.Runs[j].Link)
+			}
+			for _, p := range s.Paragraphs {
+				for j := range p.Runs {
+					p.Runs[j].Link = link(p.Runs[j].linkRel, p.Runs[j].Link)
+				}
+			}
+			if s.diagramRel != "" {
+				if data, err := pkg.read(targets[s.diagramRel]); err == nil {
+					s.SmartArt = diagramText(data)
+					for _, t := range s.SmartArt {
+						sb.WriteString(t)
+						sb.WriteString(" ")
+					}
+				}
+			}
+			walk(s.Children)
+		}
+	}
+	walk(slide.Shapes)
+
+	for i := range slide.Links {
+		slide.Links[i].URL = link(slide.Links[i].rel, slide.Links[i].URL)
+	}
+	return sb.String()
+}
@@ -0,0 +1,218 @@
+package pptx
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// spXML builds a text shape at the given position.
+func spXML(id, name string, x, y, cx, cy int, text string) string {
+	return `<p:sp><p:nvSpPr><p:cNvPr id="` + id + `" name="` + name + `"/><p:cNvSpPr/><p:nvPr/></p:nvSpPr>` +
+		`<p:spPr><a:xfrm>` + offExt(x, y, cx, cy) + `</a:xfrm></p:spPr>` +
+		`<p:txBody><a:p><a:r><a:t>` + text + `</a:t></a:r></a:p></p:txBody></p:sp>`
+}
+
+// grpXML builds a group whose child space (chOff/chExt) is given separately.
+func grpXML(id string, x, y, cx, cy, chCX, chCY int, children string) string {
+	return `<p:grpSp><p:nvGrpSpPr><p:cNvPr id="` + id + `" name="Group ` + id + `"/><p:cNvGrpSpPr/><p:nvPr/></p:nvGrpSpPr>` +
+		`<p:grpSpPr><a:xfrm>` + offExt(x, y, cx, cy) +
+		`<a:chOff x="0" y="0"/><a:chExt cx="` + strconv.Itoa(chCX) + `" cy="` + strconv.Itoa(chCY) + `"/></a:xfrm></p:grpSpPr>` +
+		children + `</p:grpSp>`
+}
+
+func offExt(x, y, cx, cy int) string {
+	return `<a:off x="` + strconv.Itoa(x) + `" y="` + strconv.Itoa(y) + `"/><a:ext cx="` + strconv.Itoa(cx) + `" cy="` + strconv.Itoa(cy) + `"/>`
+}
+
+// shapeSummary flattens a shape tree to "kind:name" entries, children in brackets.
+func shapeSummary(shapes []Shape) string {
+	var parts []string
+	for _, s := range shapes {
+		p := s.Kind + ":" + s.Name
+		if len(s.Children) > 0 {
+			p += "[" + shapeSummary(s.Children) + "]"
+		}
+		parts = append(parts, p)
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestParseSlideXMLShapeTree(t *testing.T) {
+	tests := []struct {
+		name     string
+		shapes   string
+		want     string
+		wantText string
+	}{
+		{
+			name:     "flat shapes",
+			shapes:   spXML("2", "A", 0, 0, 1, 1, "one") + spXML("3", "B", 0, 0, 1, 1, "two"),
+			want:     "sp:A sp:B",
+			wantText: "one two",
+		},
+		{
+			name:     "nested groups",
+			shapes:   grpXML("2", 0, 0, 10, 10, 10, 10, grpXML("3", 0, 0, 5, 5, 5, 5, spXML("4", "Deep", 0, 0, 1, 1, "deep"))+spXML("5", "Side", 0, 0, 1, 1, "side")),
+			want:     "grpSp:Group 2[grpSp:Group 3[sp:Deep] sp:Side]",
+			wantText: "deep side",
+		},
+		{
+			name: "other shape kinds",
+			shapes: `<p:cxnSp><p:nvCxnSpPr><p:cNvPr id="2" name="Line"/></p:nvCxnSpPr></p:cxnSp>` +
+				`<p:pic><p:nvPicPr><p:cNvPr id="3" name="Pic" descr="A cat"/></p:nvPicPr><p:blipFill><a:blip r:embed="rId5"/></p:blipFill></p:pic>` +
+				`<p:graphicFrame><p:nvGraphicFramePr><p:cNvPr id="4" name="Chart"/></p:nvGraphicFramePr>` +
+				`<a:graphic><a:graphicData uri="http://schemas.openxmlformats.org/drawingml/2006/chart"/></a:graphic></p:graphicFrame>`,
+			want:     "cxnSp:Line pic:Pic graphicFrame:Chart",
+			wantText: "A cat",
+		},
+		{
+			name: "alternate content fallback skipped",
+			shapes: `<mc:AlternateContent xmlns:mc="http://schemas.openxmlformats.org/markup-compatibility/2006">` +
+				`<mc:Choice Requires="p14">` + spXML("2", "New", 0, 0, 1, 1, "new") + `</mc:Choice>` +
+				`<mc:Fallback>` + spXML("2", "Old", 0, 0, 1, 1, "old") + `</mc:Fallback></mc:AlternateContent>`,
+			want:     "sp:New",
+			wantText: "new",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slide, text, err := parseSlideXML(strings.NewReader(slideXML(tt.shapes)), 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := shapeSummary(slide.Shapes); got != tt.want {
+				t.Errorf("shapes = %s, want %s", got, tt.want)
+			}
+			if got := strings.Join(strings.Fields(text), " "); got != tt.wantText {
+				t.Errorf("text = %q, want %q", got, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestParseSlideXMLBounds(t *testing.T) {
+	// The outer group doubles its child space; the inner one, 400 EMU wide in
+	// slide coordinates for a child space of 50, scales by eight.
+	shapes := grpXML("2", 1000, 1000, 2000, 2000, 1000, 1000,
+		grpXML("3", 100, 100, 200, 200, 50, 50,
+			spXML("4", "Inner", 10, 20, 5, 5, "x"))+
+			spXML("5", "Direct", 500, 0, 100, 50, "y"))
+
+	slide, _, err := parseSlideXML(strings.NewReader(slideXML(shapes)), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outer := slide.Shapes[0]
+	inner := outer.Children[0]
+
+	tests := []struct {
+		name  string
+		shape Shape
+		want  Rect
+	}{
+		{"outer group", outer, Rect{X: 1000, Y: 1000, CX: 2000, CY: 2000}},
+		{"inner group", inner, Rect{X: 1200, Y: 1200, CX: 400, CY: 400}},
+		{"shape in inner group", inner.Children[0], Rect{X: 1280, Y: 1360, CX: 40, CY: 40}},
+		{"shape in outer group", outer.Children[1], Rect{X: 2000, Y: 1000, CX: 200, CY: 100}},
+	}
+
+	for _, tt := range tests {
+		if tt.shape.Bounds == nil {
+			t.Errorf("%s: no bounds", tt.name)
+			continue
+		}
+		if *tt.shape.Bounds != tt.want {
+			t.Errorf("%s: bounds = %+v, want %+v", tt.name, *tt.shape.Bounds, tt.want)
+		}
+	}
+}
+
+func TestParseSlideXMLAltText(t *testing.T) {
+	tests := []struct {
+		name string
+		nv   string
+		want string
+	}{
+		{"description", `<p:cNvPr id="2" name="Pic" descr="A cat" title="Cat"/>`, "A cat"},
+		{"title only", `<p:cNvPr id="2" name="Pic" title="Cat"/>`, "Cat"},
+		{"none", `<p:cNvPr id="2" name="Pic"/>`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shapes := `<p:pic><p:nvPicPr>` + tt.nv + `</p:nvPicPr></p:pic>`
+			slide, _, err := parseSlideXML(strings.NewReader(slideXML(shapes)), 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := slide.Shapes[0].AltText; got != tt.want {
+				t.Errorf("alt text = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiagramText(t *testing.T) {
+	data := `<dgm:dataModel xmlns:dgm="http://schemas.openxmlformats.org/drawingml/2006/diagram" xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main"><dgm:ptLst>` +
+		`<dgm:pt modelId="0" type="doc"><dgm:t><a:p><a:r><a:t>Document</a:t></a:r></a:p></dgm:t></dgm:pt>` +
+		`<dgm:pt modelId="1"><dgm:t><a:p><a:r><a:t>Plan</a:t></a:r><a:r><a:t>ning</a:t></a:r></a:p></dgm:t></dgm:pt>` +
+		`<dgm:pt modelId="2" type="parTrans"><dgm:t><a:p><a:r><a:t>edge</a:t></a:r></a:p></dgm:t></dgm:pt>` +
+		`<dgm:pt modelId="3" type="asst"><dgm:t><a:p><a:r><a:t>Build</a:t></a:r></a:p><a:p><a:r><a:t>Test</a:t></a:r></a:p></dgm:t></dgm:pt>` +
+		`<dgm:pt modelId="4" type="node"><dgm:t><a:p/></dgm:t></dgm:pt>` +
+		`</dgm:ptLst></dgm:dataModel>`
+
+	want := []string{"Planning", "Build\nTest"}
+	if got := diagramText([]byte(data)); !reflect.DeepEqual(got, want) {
+		t.Errorf("diagramText = %q, want %q", got, want)
+	}
+	if got := diagramText([]byte("not xml")); got != nil {
+		t.Errorf("diagramText of invalid data = %q", got)
+	}
+}
+
+func TestExtractSlideContentShapes(t *testing.T) {
+	slides, err := ExtractSlideContent(shapesDeck)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := slides[1]
+	slide := data.Styles.(*JSONSlide)
+
+	if got, want := shapeSummary(slide.Shapes), "sp:Title 1 grpSp:Group 9[sp:Inner] pic:Logo sp:Links graphicFrame:Sales"; got != want {
+		t.Fatalf("shapes = %s, want %s", got, want)
+	}
+	title, group, logo, links, table := slide.Shapes[0], slide.Shapes[1], slide.Shapes[2], slide.Shapes[3], slide.Shapes[4]
+
+	if title.Type != "title" || len(title.Paragraphs) != 1 || len(title.Paragraphs[0].Runs) != 2 {
+		t.Errorf("title = %+v", title)
+	} else if run := title.Paragraphs[0].Runs[1]; run.Text != "report" || !run.Bold || run.Size != 24 || run.Color != "#FF0000" {
+		t.Errorf("formatted run = %+v", run)
+	}
+	if b := group.Children[0].Bounds; b == nil || *b != (Rect{X: 2000, Y: 2000, CX: 1000, CY: 1000}) {
+		t.Errorf("group member bounds = %+v", b)
+	}
+	if logo.AltText != "Company logo" || logo.Image != "ppt/media/image1.png" {
+		t.Errorf("picture alt text %q, image %q", logo.AltText, logo.Image)
+	}
+	if len(links.Runs) != 1 || links.Runs[0].Link != "https://example.com/" {
+		t.Errorf("link runs = %+v", links.Runs)
+	}
+	if want := []Hyperlink{{Text: "example", URL: "https://example.com/", rel: "rId3"}}; !reflect.DeepEqual(slide.Links, want) {
+		t.Errorf("links = %+v", slide.Links)
+	}
+	if table.Graphic != "table" {
+		t.Errorf("graphic = %q, want table", table.Graphic)
+	}
+
+	for _, w := range []string{"Quarterly", "report", "Inside", "Company logo", "example"} {
+		if !strings.Contains(data.Text, w) {
+			t.Errorf("slide text lacks %q: %q", w, data.Text)
+		}
+	}
+	if data.Layout != "ppt/slideLayouts/slideLayout1.xml" {
+		t.Errorf("layout = %q", data.Layout)
+	}
+}
@@ -0,0 +1,495 @@
+package pptx
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	contentTypeSlideMaster = "application/vnd.openxmlformats-officedocument.presentationml.slideMaster+xml"
+	contentTypeNotesMaster = "application/vnd.openxmlformats-officedocument.presentationml.notesMaster+xml"
+
+	// Layout and master IDs share one number space that must start at 2^31.
+	firstMasterID = 2147483648
+	firstSlideID  = 256
+)
+
+// SlideRef identifies a slide inside a source PPTX file.
+type SlideRef struct {
+	PPTXPath    string
+	SlideNumber int
+}
+
+// StitchSlides builds a single PPTX from the referenced slides, in the given order.
+// Every slide keeps its own layout, master, theme and media; parts coming from
+// different source decks are renamed so they never collide.
+func StitchSlides(w io.Writer, refs []SlideRef) error {
+	if len(refs) == 0 {
+		return fmt.Errorf("no slides to stitch")
+	}
+
+	b := newDeckBuilder(w)
+	defer b.closeSources()
+
+	for _, ref := range refs {
+		src, err := b.source(ref.PPTXPath)
+		if err != nil {
+			return err
+		}
+		part, err := src.slidePart(ref.SlideNumber)
+		if err != nil {
+			return err
+		}
+		b.plan(src, part, nil)
+	}
+
+	return b.build()
+}
+
+// plannedSlide is one slide of the output deck. Body, when set, replaces the
+// XML of the source slide part; replaced holds new bodies for slide-owned
+// parts (charts, embeddings) and media adds pictures the source did not have.
+type plannedSlide struct {
+	src      *sourcePackage
+	part     string
+	body     []byte
+	replaced map[string][]byte
+	media    []slideMedia
+}
+
+// slideMedia is a new image referenced from a slide by relID.
+type slideMedia struct {
+	relID       string
+	data        []byte
+	ext         string
+	contentType string
+}
+
+// partKey identifies a source part. Slide-owned parts (the slide itself, its
+// notes, charts, embeddings) are scoped to the output slide so a slide used
+// twice gets two independent copies; shared parts use scope -1.
+type partKey struct {
+	src   *sourcePackage
+	part  string
+	scope int
+}
+
+// deckBuilder writes a new presentation package assembled from parts of
+// one or more source packages.
+type deckBuilder struct {
+	zw        *zip.Writer
+	sources   map[string]*sourcePackage
+	planned   []plannedSlide
+	parts     map[partKey]string
+	firstOut  map[partKey]string // source slide -> first output slide, for slide links
+	counters  map[string]int
+	overrides map[string]string // output part -> content type
+	defaults  map[string]string // extension -> content type
+	rels      map[string][]relationship
+
+	outSlides   []string
+	masters     []string
+	masterIDs   map[string]int
+	notesMaster string
+	nextID      int
+}
+
+func newDeckBuilder(w io.Writer) *deckBuilder {
+	return &deckBuilder{
+		zw:        zip.NewWriter(w),
+		sources:   make(map[string]*sourcePackage),
+		parts:     make(map[partKey]string),
+		firstOut:  make(map[partKey]string),
+		counters:  make(map[string]int),
+		overrides: make(map[string]string),
+		defaults:  map[string]string{"rels": contentTypeRels, "xml": contentTypeXML},
+		rels:      make(map[string][]relationship),
+		masterIDs: make(map[string]int),
+		nextID:    firstMasterID,
+	}
+}
+
+// source opens (once) a source package.
+func (b *deckBuilder) source(pptxPath string) (*sourcePackage, error) {
+	if src, ok := b.sources[pptxPath]; ok {
+		return src, nil
+	}
+	src, err := openPackage(pptxPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %v", pptxPath, err)
+	}
+	b.sources[pptxPath] = src
+	return src, nil
+}
+
+func (b *deckBuilder) closeSources() {
+	for _, src := range b.sources {
+		src.Close()
+	}
+}
+
+func (b *deckBuilder) plan(src *sourcePackage, part string, body []byte) {
+	b.planned = append(b.planned, plannedSlide{src: src, part: part, body: body})
+}
+
+// build copies all planned slides with their dependencies and finalizes the package.
+func (b *deckBuilder) build() error {
+	if len(b.planned) == 0 {
+		return fmt.Errorf("no slides to write")
+	}
+
+	// Reserve output names up front so slide-to-slide links can be resolved
+	// regardless of their direction.
+	for i, ps := range b.planned {
+		out := b.allocate(ps.part)
+		b.parts[partKey{ps.src, ps.part, i}] = out
+		if _, ok := b.firstOut[partKey{ps.src, ps.part, -1}]; !ok {
+			b.firstOut[partKey{ps.src, ps.part, -1}] = out
+		}
+		b.outSlides = append(b.outSlides, out)
+	}
+
+	for i, ps := range b.planned {
+		if err := b.writePart(ps.src, ps.part, i, b.outSlides[i], ps.body); err != nil {
+			return err
+		}
+		if err := b.addMedia(b.outSlides[i], ps.media); err != nil {
+			return err
+		}
+	}
+
+	return b.finish(b.planned[0].src)
+}
+
+// copyPart copies a source part (and, recursively, everything it references)
+// and returns its name in the output package.
+func (b *deckBuilder) copyPart(src *sourcePackage, part string, scope int) (string, error) {
+	if !slideOwned(part) {
+		scope = -1
+	}
+	key := partKey{src, part, scope}
+	if out, ok := b.parts[key]; ok {
+		return out, nil
+	}
+
+	// A presentation has exactly one notes master; notes from other decks share it.
+	if src.contentType(part) == contentTypeNotesMaster && b.notesMaster != "" {
+		b.parts[key] = b.notesMaster
+		return b.notesMaster, nil
+	}
+
+	var body []byte
+	if scope >= 0 {
+		body = b.planned[scope].replaced[part]
+	}
+
+	out := b.allocate(part)
+	b.parts[key] = out
+	return out, b.writePart(src, part, scope, out, body)
+}
+
+// addMedia writes new media parts and links them from the output slide.
+func (b *deckBuilder) addMedia(slide string, media []slideMedia) error {
+	for _, m := range media {
+		out := b.allocate("ppt/media/image." + m.ext)
+		if _, ok := b.defaults[m.ext]; !ok {
+			b.defaults[m.ext] = m.contentType
+		}
+		if b.defaults[m.ext] != m.contentType {
+			b.overrides[out] = m.contentType
+		}
+		if err := b.writeFile(out, m.data); err != nil {
+			return err
+		}
+		b.rels[slide] = append(b.rels[slide], relationship{
+			ID:     m.relID,
+			Type:   relTypeImage,
+			Target: relativeTarget(slide, out),
+		})
+	}
+	return nil
+}
+
+func (b *deckBuilder) writePart(src *sourcePackage, part string, scope int, out string, body []byte) error {
+	var err error
+	if body == nil {
+		if body, err = src.read(part); err != nil {
+			return err
+		}
+	}
+
+	ct := src.contentType(part)
+	switch ct {
+	case contentTypeSlideMaster:
+		b.masters = append(b.masters, out)
+	case contentTypeNotesMaster:
+		b.notesMaster = out
+	}
+
+	rels, err := src.rels(part)
+	if err != nil {
+		return err
+	}
+
+	var outRels []relationship
+	for _, rel := range rels {
+		target := resolveTarget(part, rel.Target)
+		if rel.isExternal() || !src.has(target) {
+			outRels = append(outRels, rel)
+			continue
+		}
+
+		var targetOut string
+		if rel.Type == relTypeSlide {
+			// Links between slides only survive if the target is part of the output.
+			if o, ok := b.parts[partKey{src, target, scope}]; ok {
+				targetOut = o
+			} else if o, ok := b.firstOut[partKey{src, target, -1}]; ok {
+				targetOut = o
+			} else {
+				body = stripHyperlinks(body, rel.ID)
+				continue
+			}
+		} else if targetOut, err = b.copyPart(src, target, scope); err != nil {
+			return err
+		}
+
+		rel.Target = relativeTarget(out, targetOut)
+		outRels = append(outRels, rel)
+	}
+
+	if ct == contentTypeSlideMaster {
+		body = b.renumberLayoutIDs(out, body)
+	}
+
+	b.rels[out] = outRels
+	b.registerContentType(src, part, out, ct)
+	return b.writeFile(out, body)
+}
+
+// allocate returns a fresh output name in the directory of the source part,
+// keeping its naming scheme (slide3.xml -> slideN.xml).
+func (b *deckBuilder) allocate(part string) string {
+	dir, file := path.Split(part)
+	ext := path.Ext(file)
+	stem := strings.TrimRight(strings.TrimSuffix(file, ext), "0123456789")
+
+	counterKey := dir + stem + ext
+	b.counters[counterKey]++
+	return fmt.Sprintf("%s%s%d%s", dir, stem, b.counters[counterKey], ext)
+}
+
+func (b *deckBuilder) registerContentType(src *sourcePackage, part, out, ct string) {
+	ext := strings.ToLower(strings.TrimPrefix(path.Ext(part), "."))
+	if def, ok := src.defaults[ext]; ok && def == ct {
+		if _, exists := b.defaults[ext]; !exists {
+			b.defaults[ext] = def
+		}
+	}
+	if b.defaults[ext] != ct {
+		b.overrides[out] = ct
+	}
+}
+
+func (b *deckBuilder) writeFile(name string, data []byte) error {
+	fw, err := b.zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = fw.Write(data)
+	return err
+}
+
+var sldLayoutIDRegex = regexp.MustCompile(`(<p:sldLayoutId\b[^>]*?\bid=")\d+(")`)
+
+// renumberLayoutIDs gives the master and its layouts IDs that are unique
+// across all masters of the output deck.
+func (b *deckBuilder) renumberLayoutIDs(master string, body []byte) []byte {
+	b.masterIDs[master] = b.nextID
+	b.nextID++
+	return sldLayoutIDRegex.ReplaceAllFunc(body, func(m []byte) []byte {
+		sub := sldLayoutIDRegex.FindSubmatch(m)
+		id := b.nextID
+		b.nextID++
+		return []byte(fmt.Sprintf("%s%d%s", sub[1], id, sub[2]))
+	})
+}
+
+// finish writes the presentation part, package relationships and content types.
+func (b *deckBuilder) finish(base *sourcePackage) error {
+	presRels, err := base.rels(presentationPart)
+	if err != nil {
+		return err
+	}
+	presXML, err := base.read(presentationPart)
+	if err != nil {
+		return err
+	}
+
+	var outRels []relationship
+	addRel := func(relType, target string) string {
+		id := fmt.Sprintf("rId%d", len(outRels)+1)
+		outRels = append(outRels, relationship{ID: id, Type: relType, Target: relativeTarget(presentationPart, target)})
+		return id
+	}
+
+	var masterList strings.Builder
+	for _, m := range b.masters {
+		masterList.WriteString(fmt.Sprintf(`<p:sldMasterId id="%d" r:id="%s"/>`, b.masterIDs[m], addRel(relTypeSlideMaster, m)))
+	}
+
+	var notesList string
+	if b.notesMaster != "" {
+		notesList = fmt.Sprintf(`<p:notesMasterIdLst><p:notesMasterId r:id="%s"/></p:notesMasterIdLst>`, addRel(relTypeNotesMaster, b.notesMaster))
+	}
+
+	var slideList strings.Builder
+	for i, s := range b.outSlides {
+		slideList.WriteString(fmt.Sprintf(`<p:sldId id="%d" r:id="%s"/>`, firstSlideID+i, addRel(relTypeSlide, s)))
+	}
+
+	// Presentation-level parts are taken from the first source deck.
+	for _, rel := range presRels {
+		if rel.isExternal() {
+			continue
+		}
+		target := resolveTarget(presentationPart, rel.Target)
+		switch rel.Type {
+		case relTypeTheme:
+			out, err := b.copyPart(base, target, -1)
+			if err != nil {
+				return err
+			}
+			addRel(rel.Type, out)
+		case relTypePresProps, relTypeViewProps, relTypeTableStyles:
+			data, err := base.read(target)
+			if err != nil {
+				continue
+			}
+			if err := b.writeFile(target, data); err != nil {
+				return err
+			}
+			b.registerContentType(base, target, target, base.contentType(target))
+			addRel(rel.Type, target)
+		}
+	}
+
+	presXML = rewritePresentation(presXML, masterList.String(), notesList, slideList.String())
+	if err := b.writeFile(presentationPart, presXML); err != nil {
+		return err
+	}
+	b.overrides[presentationPart] = contentTypePresMain
+	b.rels[presentationPart] = outRels
+
+	rootRels := []relationship{{ID: "rId1", Type: relTypeOfficeDoc, Target: presentationPart}}
+	if core := base.relTarget("", relTypeCoreProps); core != "" {
+		if data, err := base.read(core); err == nil {
+			if err := b.writeFile(core, data); err != nil {
+				return err
+			}
+			b.registerContentType(base, core, core, base.contentType(core))
+			rootRels = append(rootRels, relationship{ID: "rId2", Type: relTypeCoreProps, Target: core})
+		}
+	}
+	b.rels[""] = rootRels
+
+	for part, rels := range b.rels {
+		if len(rels) == 0 {
+			continue
+		}
+		data, err := marshalRels(rels)
+		if err != nil {
+			return err
+		}
+		if err := b.writeFile(relsPathFor(part), data); err != nil {
+			return err
+		}
+	}
+
+	if err := b.writeFile(contentTypesPart, b.contentTypesXML()); err != nil {
+		return err
+	}
+
+	return b.zw.Close()
+}
+
+func (b *deckBuilder) contentTypesXML() []byte {
+	var sb strings.Builder
+	sb.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>`)
+	sb.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+
+	exts := make([]string, 0, len(b.defaults))
+	for ext := range b.defaults {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+	for _, ext := range exts {
+		sb.WriteString(fmt.Sprintf(`<Default Extension="%s" ContentType="%s"/>`, ext, b.defaults[ext]))
+	}
+
+	parts := make([]string, 0, len(b.overrides))
+	for p := range b.overrides {
+		parts = append(parts, p)
+	}
+	sort.Strings(parts)
+	for _, p := range parts {
+		sb.WriteString(fmt.Sprintf(`<Override PartName="/%s" ContentType="%s"/>`, p, b.overrides[p]))
+	}
+
+	sb.WriteString(`</Types>`)
+	return []byte(sb.String())
+}
+
+// slideOwned reports whether a part belongs to a single slide and must be
+// duplicated when that slide is copied more than once.
+func slideOwned(part string) bool {
+	for _, prefix := range []string{"ppt/slides/", "ppt/notesSlides/", "ppt/charts/", "ppt/embeddings/", "ppt/diagrams/", "ppt/comments/"} {
+		if strings.HasPrefix(part, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// stripHyperlinks removes click actions pointing at a dropped relationship.
+func stripHyperlinks(body []byte, relID string) []byte {
+	re := regexp.MustCompile(`(?s)<a:hlinkClick\b[^>]*\br:id="` + regexp.QuoteMeta(relID) + `"[^>]*?(?:/>|>.*?</a:hlinkClick>)`)
+	return re.ReplaceAll(body, nil)
+}
+
+// presentationElement matches a (possibly self-closing) direct child of p:presentation.
+func presentationElement(name string) *regexp.Regexp {
+	return regexp.MustCompile(`(?s)<p:` + name + `\b[^>]*?(?:/>|>.*?</p:` + name + `>)`)
+}
+
+var (
+	sectionListExtRegex = regexp.MustCompile(`(?s)<p:ext uri="\{521415D9-36F7-43E2-AB2F-B90AF26B5E84\}">.*?</p:ext>`)
+	emptyExtListRegex   = regexp.MustCompile(`<p:extLst>\s*</p:extLst>`)
+)
+
+// rewritePresentation swaps the master, notes master and slide lists of a
+// presentation.xml and drops elements that reference parts we do not copy.
+func rewritePresentation(presXML []byte, masters, notes, slides string) []byte {
+	s := string(presXML)
+
+	for _, name := range []string{"notesMasterIdLst", "handoutMasterIdLst", "sldIdLst", "embeddedFontLst", "custShowLst"} {
+		s = presentationElement(name).ReplaceAllString(s, "")
+	}
+	s = sectionListExtRegex.ReplaceAllString(s, "")
+	s = emptyExtListRegex.ReplaceAllString(s, "")
+
+	lists := notes + "<p:sldIdLst>" + slides + "</p:sldIdLst>"
+	masterRe := presentationElement("sldMasterIdLst")
+	if loc := masterRe.FindStringIndex(s); loc != nil {
+		s = s[:loc[0]] + "<p:sldMasterIdLst>" + masters + "</p:sldMasterIdLst>" + lists + s[loc[1]:]
+	} else if loc := regexp.MustCompile(`<p:presentation\b[^>]*>`).FindStringIndex(s); loc != nil {
+		s = s[:loc[1]] + "<p:sldMasterIdLst>" + masters + "</p:sldMasterIdLst>" + lists + s[loc[1]:]
+	}
+
+	return []byte(s)
+}
@@ -0,0 +1,175 @@
+package pptx
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const reorderedDeck = "testdata/reordered.pptx" // slide3, slide1, slide2 in presentation order
+
+// writeDeck runs write into a temporary PPTX and opens the result.
+func writeDeck(t *testing.T, write func(w io.Writer) error) *sourcePackage {
+	t.Helper()
+	out := filepath.Join(t.TempDir(), "out.pptx")
+	f, err := os.Create(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := openPackage(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pkg.Close() })
+	return pkg
+}
+
+// slideTexts returns the paragraphs of every slide of pkg, in presentation order.
+func slideTexts(t *testing.T, pkg *sourcePackage) [][]string {
+	t.Helper()
+	parts, err := pkg.slideParts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var texts [][]string
+	for _, part := range parts {
+		body, err := pkg.read(part)
+		if err != nil {
+			t.Fatal(err)
+		}
+		texts = append(texts, paragraphTexts(body))
+	}
+	return texts
+}
+
+func firstParagraphs(t *testing.T, pkg *sourcePackage) []string {
+	t.Helper()
+	var titles []string
+	for _, texts := range slideTexts(t, pkg) {
+		if len(texts) == 0 {
+			titles = append(titles, "")
+			continue
+		}
+		titles = append(titles, texts[0])
+	}
+	return titles
+}
+
+func TestStitchSlidesOrder(t *testing.T) {
+	tests := []struct {
+		name   string
+		slides []int
+		want   []string
+	}{
+		{"whole deck", []int{1, 2, 3}, []string{"Gamma", "Alpha", "Beta"}},
+		{"subset", []int{3, 1}, []string{"Beta", "Gamma"}},
+		{"slide used twice", []int{2, 2}, []string{"Alpha", "Alpha"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var refs []SlideRef
+			for _, n := range tt.slides {
+				refs = append(refs, SlideRef{PPTXPath: reorderedDeck, SlideNumber: n})
+			}
+			pkg := writeDeck(t, func(w io.Writer) error { return StitchSlides(w, refs) })
+
+			got := firstParagraphs(t, pkg)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("slides = %v, want %v", got, tt.want)
+			}
+			parts, _ := pkg.slideParts()
+			if len(parts) != len(tt.slides) {
+				t.Fatalf("got %d slide parts, want %d", len(parts), len(tt.slides))
+			}
+			for i, part := range parts {
+				if want := fmt.Sprintf("ppt/slides/slide%d.xml", i+1); part != want {
+					t.Errorf("slide %d is %s, want %s", i+1, part, want)
+				}
+				if layout := pkg.relTarget(part, relTypeSlideLayout); !pkg.has(layout) {
+					t.Errorf("%s: layout %q missing", part, layout)
+				}
+			}
+		})
+	}
+}
+
+func TestStitchSlidesErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		refs []SlideRef
+	}{
+		{"no slides", nil},
+		{"slide out of range", []SlideRef{{PPTXPath: reorderedDeck, SlideNumber: 4}}},
+		{"slide zero", []SlideRef{{PPTXPath: reorderedDeck, SlideNumber: 0}}},
+		{"missing file", []SlideRef{{PPTXPath: "testdata/missing.pptx", SlideNumber: 1}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := StitchSlides(io.Discard, tt.refs); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestStitchSlidesLinks(t *testing.T) {
+	tests := []struct {
+		name     string
+		slides   []int
+		wantLink bool
+	}{
+		// Slide 2 (Alpha) links to slide 3 (Beta).
+		{"target kept", []int{2, 3}, true},
+		{"target kept before source", []int{3, 2}, true},
+		{"target dropped", []int{2, 1}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var refs []SlideRef
+			for _, n := range tt.slides {
+				refs = append(refs, SlideRef{PPTXPath: reorderedDeck, SlideNumber: n})
+			}
+			pkg := writeDeck(t, func(w io.Writer) error { return StitchSlides(w, refs) })
+
+			parts, _ := pkg.slideParts()
+			alpha := parts[0]
+			if tt.slides[0] != 2 {
+				alpha = parts[1]
+			}
+			body, err := pkg.read(alpha)
+			if err != nil {
+				t.Fatal(err)
+			}
+			target := ""
+			for i, n := range tt.slides {
+				if n == 3 {
+					target = parts[i]
+				}
+			}
+
+			hasLink := strings.Contains(string(body), "<a:hlinkClick")
+			if hasLink != tt.wantLink {
+				t.Errorf("hyperlink present = %v, want %v", hasLink, tt.wantLink)
+			}
+			if got := pkg.relTarget(alpha, relTypeSlide); got != target {
+				t.Errorf("slide link target = %q, want %q", got, target)
+			}
+			if !strings.Contains(strings.Join(paragraphTexts(body), "\n"), "Go to Beta") {
+				t.Error("link text was removed with the link")
+			}
+		})
+	}
+}
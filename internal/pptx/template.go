@@ -0,0 +1,178 @@
+package pptx
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	paragraphRegex = regexp.MustCompile(`(?s)<a:p(?:\s[^>]*)?>.*?</a:p>`)
+	textNodeRegex  = regexp.MustCompile(`(?s)(<a:t(?:\s[^>]*)?>)(.*?)</a:t>`)
+	tagRegex       = regexp.MustCompile(`{{(.*?)}}`)
+)
+
+// RenderTemplate fills the {{tag}} placeholders of a template PPTX with values
+// from data and writes the resulting PPTX to w. Dotted names (customer.name)
+// address nested objects; tags without a value are left untouched. Slides,
+// table rows and shapes are repeated or dropped according to their
+// {{#each}} / {{#if}} control tags; {{image:x}} and {{chart:x}} tags swap
+// picture media and chart data.
+func RenderTemplate(w io.Writer, templatePath string, data map[string]any) error {
+	b := newDeckBuilder(w)
+	defer b.closeSources()
+
+	src, err := b.source(templatePath)
+	if err != nil {
+		return err
+	}
+	parts, err := src.slideParts()
+	if err != nil {
+		return err
+	}
+
+	root := scope{data}
+	for _, part := range parts {
+		body, err := src.read(part)
+		if err != nil {
+			return err
+		}
+		for _, inst := range renderSlide(body, root) {
+			ps, err := renderAssets(src, part, inst)
+			if err != nil {
+				return err
+			}
+			b.planned = append(b.planned, ps)
+		}
+	}
+
+	if len(b.planned) == 0 {
+		return fmt.Errorf("template produced no slides for the given data")
+	}
+	return b.build()
+}
+
+// replaceTags substitutes tags paragraph by paragraph, so tags that PowerPoint
+// split over several runs are still found.
+func replaceTags(body []byte, resolve func(name string) (string, bool)) []byte {
+	return paragraphRegex.ReplaceAllFunc(body, func(p []byte) []byte {
+		return fillParagraph(p, resolve)
+	})
+}
+
+// fillParagraph replaces the tags of a single <a:p>. The value of a tag is
+// written into the run where the tag starts (keeping that run's formatting);
+// the remainder of the tag is removed from the following runs.
+func fillParagraph(p []byte, resolve func(name string) (string, bool)) []byte {
+	nodes := textNodeRegex.FindAllSubmatchIndex(p, -1)
+	if len(nodes) == 0 {
+		return p
+	}
+
+	// Concatenate the run texts, remembering which run owns each byte.
+	var full strings.Builder
+	var owner []int
+	for i, n := range nodes {
+		text := html.UnescapeString(string(p[n[4]:n[5]]))
+		full.WriteString(text)
+		for range len(text) {
+			owner = append(owner, i)
+		}
+	}
+	text := full.String()
+	if !strings.Contains(text, "{{") {
+		return p
+	}
+
+	runs := make([]strings.Builder, len(nodes))
+	pos := 0
+	changed := false
+	for _, m := range tagRegex.FindAllStringSubmatchIndex(text, -1) {
+		value, ok := resolve(strings.TrimSpace(text[m[2]:m[3]]))
+		if !ok {
+			continue
+		}
+		for ; pos < m[0]; pos++ {
+			runs[owner[pos]].WriteByte(text[pos])
+		}
+		runs[owner[m[0]]].WriteString(value)
+		pos = m[1]
+		changed = true
+	}
+	if !changed {
+		return p
+	}
+	for ; pos < len(text); pos++ {
+		runs[owner[pos]].WriteByte(text[pos])
+	}
+
+	var out bytes.Buffer
+	last := 0
+	for i, n := range nodes {
+		out.Write(p[last:n[3]])
+		xml.EscapeText(&out, []byte(runs[i].String()))
+		last = n[5]
+	}
+	out.Write(p[last:])
+	return out.Bytes()
+}
+
+// paragraphTexts returns the plain text of every paragraph in a part.
+func paragraphTexts(body []byte) []string {
+	var texts []string
+	for _, p := range paragraphRegex.FindAll(body, -1) {
+		var sb strings.Builder
+		for _, n := range textNodeRegex.FindAllSubmatch(p, -1) {
+			sb.WriteString(html.UnescapeString(string(n[2])))
+		}
+		texts = append(texts, sb.String())
+	}
+	return texts
+}
+
+// lookupValue resolves a dotted path (a.b.0.c) in a JSON-like value.
+func lookupValue(data any, name string) (any, bool) {
+	cur := data
+	for _, key := range strings.Split(name, ".") {
+		switch v := cur.(type) {
+		case map[string]any:
+			next, ok := v[key]
+			if !ok {
+				return nil, false
+			}
+			cur = next
+		case []any:
+			idx, err := strconv.Atoi(key)
+			if err != nil || idx < 0 || idx >= len(v) {
+				return nil, false
+			}
+			cur = v[idx]
+		default:
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+func formatValue(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case json.Number:
+		return val.String()
+	case bool:
+		return strconv.FormatBool(val)
+	default:
+		return fmt.Sprint(val)
+	}
+}
@@ -0,0 +1,471 @@
+package pptx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Asset tags:
+//
+//	{{image:logo}}     in a picture's alt text (or name): swap the picture's media,
+//	                   keeping its crop and size; in a text shape or placeholder:
+//	                   replace the shape with a picture of the same geometry
+//	{{chart:revenue}}  in a chart's alt text (or name): replace the series,
+//	                   categories and values of the chart and its embedded workbook
+//
+// Image values are base64 strings or data URLs (PNG, JPEG or GIF). Chart values
+// look like {"categories": ["Q1","Q2"], "series": [{"name": "2025", "values": [1,2]}]}.
+var (
+	assetTagRegex     = regexp.MustCompile(`{{\s*(image|chart):\s*([^}\s]+)\s*}}`)
+	pictureRegex      = regexp.MustCompile(`(?s)<p:pic(?:\s[^>]*)?>.*?</p:pic>`)
+	graphicFrameRegex = regexp.MustCompile(`(?s)<p:graphicFrame(?:\s[^>]*)?>.*?</p:graphicFrame>`)
+	cNvPrRegex        = regexp.MustCompile(`(?s)<p:cNvPr\b[^>]*?(?:/>|>.*?</p:cNvPr>)`)
+	nvPrRegex         = regexp.MustCompile(`(?s)<p:nvPr\b[^>]*?(?:/>|>.*?</p:nvPr>)`)
+	spPrRegex         = regexp.MustCompile(`(?s)<p:spPr\b[^>]*?(?:/>|>.*?</p:spPr>)`)
+	blipEmbedRegex    = regexp.MustCompile(`(<a:blip\b[^>]*?\br:embed=")[^"]*(")`)
+	chartRefRegex     = regexp.MustCompile(`<c:chart\b[^>]*?\br:id="([^"]*)"`)
+
+	seriesRegex     = regexp.MustCompile(`(?s)<c:ser>.*?</c:ser>`)
+	seriesIdxRegex  = regexp.MustCompile(`<c:idx val="\d+"/>`)
+	seriesOrdRegex  = regexp.MustCompile(`<c:order val="\d+"/>`)
+	formatCodeRegex = regexp.MustCompile(`<c:formatCode>(.*?)</c:formatCode>`)
+	chartExtRegex   = regexp.MustCompile(`(?s)<c:extLst>.*?</c:extLst>`)
+)
+
+func isAssetTag(name string) bool {
+	return strings.HasPrefix(name, "image:") || strings.HasPrefix(name, "chart:")
+}
+
+// assetTag returns the name of the first asset tag of the given kind in text.
+func assetTag(text []byte, kind string) string {
+	for _, m := range assetTagRegex.FindAllSubmatch(text, -1) {
+		if string(m[1]) == kind {
+			return string(m[2])
+		}
+	}
+	return ""
+}
+
+// assetRenderer applies the asset tags of one output slide.
+type assetRenderer struct {
+	src   *sourcePackage
+	part  string
+	sc    scope
+	ps    plannedSlide
+	nextR int
+	err   error
+}
+
+// renderAssets swaps pictures and chart data of a rendered slide and returns
+// the slide as planned for the deck builder.
+func renderAssets(src *sourcePackage, part string, inst slideInstance) (plannedSlide, error) {
+	r := &assetRenderer{
+		src:  src,
+		part: part,
+		sc:   inst.sc,
+		ps:   plannedSlide{src: src, part: part, replaced: make(map[string][]byte)},
+	}
+
+	body := pictureRegex.ReplaceAllFunc(inst.body, r.picture)
+	body = shapeRegex.ReplaceAllFunc(body, r.picturePlaceholder)
+	body = graphicFrameRegex.ReplaceAllFunc(body, r.chart)
+	if r.err != nil {
+		return plannedSlide{}, r.err
+	}
+	r.ps.body = body
+	return r.ps, nil
+}
+
+// image looks up and decodes an image value; ok is false when the data has none.
+func (r *assetRenderer) image(name string) (media slideMedia, ok bool) {
+	v, found := r.sc.lookup(name)
+	s, _ := v.(string)
+	if !found || s == "" || r.err != nil {
+		return slideMedia{}, false
+	}
+	data, ext, ct, err := decodeImage(s)
+	if err != nil {
+		r.err = fmt.Errorf("image %q: %v", name, err)
+		return slideMedia{}, false
+	}
+	r.nextR++
+	media = slideMedia{relID: fmt.Sprintf("rIdImg%d", r.nextR), data: data, ext: ext, contentType: ct}
+	r.ps.media = append(r.ps.media, media)
+	return media, true
+}
+
+// picture points a tagged picture at new media. The blip fill (crop, stretch)
+// and the shape properties are kept as they are.
+func (r *assetRenderer) picture(pic []byte) []byte {
+	nv := cNvPrRegex.Find(pic)
+	name := assetTag(nv, "image")
+	if name == "" {
+		return pic
+	}
+	media, ok := r.image(name)
+	if !ok {
+		return pic
+	}
+	pic = blipEmbedRegex.ReplaceAll(pic, []byte("${1}"+media.relID+"${2}"))
+	return bytes.Replace(pic, nv, assetTagRegex.ReplaceAll(nv, nil), 1)
+}
+
+// picturePlaceholder turns a shape whose text is an image tag into a picture
+// with the same placeholder binding and geometry.
+func (r *assetRenderer) picturePlaceholder(sp []byte) []byte {
+	name := assetTag([]byte(partText(sp)), "image")
+	cNvPr := cNvPrRegex.Find(sp)
+	if name == "" || cNvPr == nil {
+		// A picture without cNvPr is not valid; leave the shape alone
+		return sp
+	}
+	media, ok := r.image(name)
+	if !ok {
+		return sp
+	}
+
+	nv := assetTagRegex.ReplaceAll(cNvPr, nil)
+	nvPr := nvPrRegex.Find(sp)
+	if nvPr == nil {
+		nvPr = []byte("<p:nvPr/>")
+	}
+	spPr := spPrRegex.Find(sp)
+	if spPr == nil {
+		spPr = []byte("<p:spPr/>")
+	}
+
+	var out bytes.Buffer
+	out.WriteString("<p:pic><p:nvPicPr>")
+	out.Write(nv)
+	out.WriteString(`<p:cNvPicPr><a:picLocks noGrp="1" noChangeAspect="1"/></p:cNvPicPr>`)
+	out.Write(nvPr)
+	out.WriteString(`</p:nvPicPr><p:blipFill><a:blip r:embed="` + media.relID + `"/><a:stretch><a:fillRect/></a:stretch></p:blipFill>`)
+	out.Write(spPr)
+	out.WriteString("</p:pic>")
+	return out.Bytes()
+}
+
+// chart replaces the data of a tagged chart. The rewritten chart part and its
+// embedded workbook are stored as slide-scoped replacements.
+func (r *assetRenderer) chart(frame []byte) []byte {
+	nv := cNvPrRegex.Find(frame)
+	name := assetTag(nv, "chart")
+	if name == "" || r.err != nil {
+		return frame
+	}
+	v, ok := r.sc.lookup(name)
+	if !ok {
+		return frame
+	}
+	if err := r.fillChart(frame, v); err != nil {
+		r.err = fmt.Errorf("chart %q: %v", name, err)
+		return frame
+	}
+	return bytes.Replace(frame, nv, assetTagRegex.ReplaceAll(nv, nil), 1)
+}
+
+func (r *assetRenderer) fillChart(frame []byte, v any) error {
+	data, err := parseChartData(v)
+	if err != nil {
+		return err
+	}
+
+	ref := chartRefRegex.FindSubmatch(frame)
+	if ref == nil {
+		return fmt.Errorf("shape is not a chart")
+	}
+	rels, err := r.src.rels(r.part)
+	if err != nil {
+		return err
+	}
+	chartPart := ""
+	for _, rel := range rels {
+		if rel.ID == string(ref[1]) && rel.Type == relTypeChart && !rel.isExternal() {
+			chartPart = resolveTarget(r.part, rel.Target)
+		}
+	}
+	if chartPart == "" {
+		return fmt.Errorf("chart part not found")
+	}
+
+	body, err := r.src.read(chartPart)
+	if err != nil {
+		return err
+	}
+	if body, err = fillChartSeries(body, data); err != nil {
+		return err
+	}
+	r.ps.replaced[chartPart] = body
+
+	chartRels, err := r.src.rels(chartPart)
+	if err != nil {
+		return err
+	}
+	for _, rel := range chartRels {
+		if rel.Type != relTypePackage || rel.isExternal() {
+			continue
+		}
+		workbook, err := buildChartWorkbook(data)
+		if err != nil {
+			return err
+		}
+		r.ps.replaced[resolveTarget(chartPart, rel.Target)] = workbook
+	}
+	return nil
+}
+
+// decodeImage accepts base64 or a data URL and returns the image bytes, the
+// file extension and the content type.
+func decodeImage(value string) ([]byte, string, string, error) {
+	if strings.HasPrefix(value, "data:") {
+		if i := strings.Index(value, ","); i >= 0 {
+			value = value[i+1:]
+		}
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(value), ""))
+	if err != nil {
+		return nil, "", "", fmt.Errorf("invalid base64 data")
+	}
+	switch ct := http.DetectContentType(data); ct {
+	case "image/png":
+		return data, "png", ct, nil
+	case "image/jpeg":
+		return data, "jpeg", ct, nil
+	case "image/gif":
+		return data, "gif", ct, nil
+	default:
+		return nil, "", "", fmt.Errorf("unsupported image type %s", ct)
+	}
+}
+
+type chartData struct {
+	Categories []string
+	Series     []chartSeries
+}
+
+type chartSeries struct {
+	Name   string
+	Values []float64
+}
+
+func parseChartData(v any) (chartData, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return chartData{}, err
+	}
+	var in struct {
+		Categories []any `json:"categories"`
+		Series     []struct {
+			Name   any       `json:"name"`
+			Values []float64 `json:"values"`
+		} `json:"series"`
+	}
+	if err := json.Unmarshal(raw, &in); err != nil {
+		return chartData{}, fmt.Errorf("expected {categories, series}: %v", err)
+	}
+	if len(in.Categories) == 0 || len(in.Series) == 0 {
+		return chartData{}, fmt.Errorf("categories and series are required")
+	}
+
+	var data chartData
+	for _, c := range in.Categories {
+		data.Categories = append(data.Categories, formatValue(c))
+	}
+	for _, s := range in.Series {
+		data.Series = append(data.Series, chartSeries{Name: formatValue(s.Name), Values: s.Values})
+	}
+	for _, s := range data.Series {
+		if len(s.Values) != len(data.Categories) {
+			return chartData{}, fmt.Errorf("series %q has %d values for %d categories", s.Name, len(s.Values), len(data.Categories))
+		}
+	}
+	return data, nil
+}
+
+// fillChartSeries rewrites the series of the first plot of a chart. Existing
+// series keep their formatting; extra series are cloned from the last one and
+// surplus series are removed.
+func fillChartSeries(body []byte, data chartData) ([]byte, error) {
+	locs := seriesRegex.FindAllIndex(body, -1)
+	if len(locs) == 0 {
+		return nil, fmt.Errorf("chart has no series")
+	}
+	group := locs[:1]
+	for i := 1; i < len(locs); i++ {
+		if len(bytes.TrimSpace(body[locs[i-1][1]:locs[i][0]])) != 0 {
+			break
+		}
+		group = append(group, locs[i])
+	}
+
+	var out bytes.Buffer
+	out.Write(body[:group[0][0]])
+	for i, s := range data.Series {
+		loc := group[min(i, len(group)-1)]
+		ser := body[loc[0]:loc[1]]
+		if i >= len(group) {
+			ser = chartExtRegex.ReplaceAll(ser, nil)
+		}
+		out.Write(fillSeries(ser, i, s, data.Categories))
+	}
+	out.Write(body[group[len(group)-1][1]:])
+	return out.Bytes(), nil
+}
+
+// fillSeries rewrites the name, categories and values of one <c:ser>. The
+// references point at the layout written by buildChartWorkbook.
+func fillSeries(ser []byte, idx int, s chartSeries, categories []string) []byte {
+	col := columnName(idx + 1)
+	last := len(categories) + 1
+
+	ser = replaceFirst(seriesIdxRegex, ser, fmt.Sprintf(`<c:idx val="%d"/>`, idx))
+	ser = replaceFirst(seriesOrdRegex, ser, fmt.Sprintf(`<c:order val="%d"/>`, idx))
+
+	tx := fmt.Sprintf(`<c:tx><c:strRef><c:f>Sheet1!$%s$1</c:f><c:strCache><c:ptCount val="1"/><c:pt idx="0"><c:v>%s</c:v></c:pt></c:strCache></c:strRef></c:tx>`,
+		col, escapeXML(s.Name))
+	if loc := seriesOrdRegex.FindIndex(ser); loc != nil {
+		rest := ser[loc[1]:]
+		trimmed := bytes.TrimLeft(rest, " \t\r\n")
+		if bytes.HasPrefix(trimmed, []byte("<c:tx>")) {
+			end := bytes.Index(trimmed, []byte("</c:tx>")) + len("</c:tx>")
+			rest = trimmed[end:]
+		}
+		ser = concat(ser[:loc[1]], []byte(tx), rest)
+	}
+
+	catTag, valTag := "c:cat", "c:val"
+	if bytes.Contains(ser, []byte("<c:yVal>")) {
+		catTag, valTag = "c:xVal", "c:yVal"
+	}
+
+	var cat strings.Builder
+	fmt.Fprintf(&cat, `<%s><c:strRef><c:f>Sheet1!$A$2:$A$%d</c:f><c:strCache><c:ptCount val="%d"/>`, catTag, last, len(categories))
+	for i, c := range categories {
+		fmt.Fprintf(&cat, `<c:pt idx="%d"><c:v>%s</c:v></c:pt>`, i, escapeXML(c))
+	}
+	fmt.Fprintf(&cat, `</c:strCache></c:strRef></%s>`, catTag)
+
+	format := "General"
+	if m := formatCodeRegex.FindSubmatch(ser); m != nil {
+		format = string(m[1])
+	}
+	var val strings.Builder
+	fmt.Fprintf(&val, `<%s><c:numRef><c:f>Sheet1!$%s$2:$%s$%d</c:f><c:numCache><c:formatCode>%s</c:formatCode><c:ptCount val="%d"/>`,
+		valTag, col, col, last, format, len(s.Values))
+	for i, v := range s.Values {
+		fmt.Fprintf(&val, `<c:pt idx="%d"><c:v>%s</c:v></c:pt>`, i, strconv.FormatFloat(v, 'f', -1, 64))
+	}
+	fmt.Fprintf(&val, `</c:numCache></c:numRef></%s>`, valTag)
+
+	ser = replaceElement(ser, catTag, cat.String(), "<"+valTag+">")
+	return replaceElement(ser, valTag, val.String(), "</c:ser>")
+}
+
+// replaceElement replaces the first <tag>...</tag> of b, or inserts repl
+// before the before marker when the element is missing.
+func replaceElement(b []byte, tag, repl, before string) []byte {
+	start := bytes.Index(b, []byte("<"+tag+">"))
+	if start >= 0 {
+		closing := "</" + tag + ">"
+		end := bytes.Index(b[start:], []byte(closing))
+		if end >= 0 {
+			return concat(b[:start], []byte(repl), b[start+end+len(closing):])
+		}
+	}
+	if at := bytes.Index(b, []byte(before)); at >= 0 {
+		return concat(b[:at], []byte(repl), b[at:])
+	}
+	return b
+}
+
+func replaceFirst(re *regexp.Regexp, b []byte, repl string) []byte {
+	loc := re.FindIndex(b)
+	if loc == nil {
+		return b
+	}
+	return concat(b[:loc[0]], []byte(repl), b[loc[1]:])
+}
+
+func concat(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+func escapeXML(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+// columnName converts a zero-based column index to its spreadsheet letters.
+func columnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+// buildChartWorkbook writes a minimal XLSX holding the chart data: categories
+// in column A, one column per series, series names in row 1.
+func buildChartWorkbook(data chartData) ([]byte, error) {
+	var sheet strings.Builder
+	sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData><row r="1">`)
+	for i, s := range data.Series {
+		fmt.Fprintf(&sheet, `<c r="%s1" t="inlineStr"><is><t>%s</t></is></c>`, columnName(i+1), escapeXML(s.Name))
+	}
+	sheet.WriteString(`</row>`)
+	for row, c := range data.Categories {
+		r := row + 2
+		fmt.Fprintf(&sheet, `<row r="%d"><c r="A%d" t="inlineStr"><is><t>%s</t></is></c>`, r, r, escapeXML(c))
+		for i, s := range data.Series {
+			fmt.Fprintf(&sheet, `<c r="%s%d"><v>%s</v></c>`, columnName(i+1), r, strconv.FormatFloat(s.Values[row], 'f', -1, 64))
+		}
+		sheet.WriteString(`</row>`)
+	}
+	sheet.WriteString(`</sheetData></worksheet>`)
+
+	files := []struct{ name, body string }{
+		{contentTypesPart, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+			`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="` + contentTypeRels + `"/>` +
+			`<Default Extension="xml" ContentType="` + contentTypeXML + `"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+			`</Types>`},
+		{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+			`<Relationships xmlns="` + relsNamespace + `">` +
+			`<Relationship Id="rId1" Type="` + relTypeOfficeDoc + `" Target="xl/workbook.xml"/></Relationships>`},
+		{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+			`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+			`<Relationships xmlns="` + relsNamespace + `">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`},
+		{"xl/worksheets/sheet1.xml", sheet.String()},
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range files {
+		fw, err := zw.Create(f.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := fw.Write([]byte(f.body)); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,300 @@
+package pptx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+)
+
+const assetsDeck = "testdata/assets.pptx"
+
+// encodeImage returns a 2x2 image in the given format.
+func encodeImage(t *testing.T, format string) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case "png":
+		err = png.Encode(&buf, img)
+	case "jpeg":
+		err = jpeg.Encode(&buf, img, nil)
+	case "gif":
+		err = gif.Encode(&buf, img, nil)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecodeImage(t *testing.T) {
+	pngData, jpegData, gifData := encodeImage(t, "png"), encodeImage(t, "jpeg"), encodeImage(t, "gif")
+	b64 := base64.StdEncoding.EncodeToString
+
+	tests := []struct {
+		name    string
+		value   string
+		wantExt string
+		wantCT  string
+		wantErr bool
+	}{
+		{"base64 png", b64(pngData), "png", "image/png", false},
+		{"data url jpeg", "data:image/jpeg;base64," + b64(jpegData), "jpeg", "image/jpeg", false},
+		{"wrapped gif", b64(gifData)[:10] + "\n  " + b64(gifData)[10:], "gif", "image/gif", false},
+		{"not base64", "not an image!", "", "", true},
+		{"not an image", b64([]byte("plain text")), "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ext, ct, err := decodeImage(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if ext != tt.wantExt || ct != tt.wantCT {
+				t.Errorf("got %s %s, want %s %s", ext, ct, tt.wantExt, tt.wantCT)
+			}
+		})
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	for i, want := range map[int]string{0: "A", 1: "B", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"} {
+		if got := columnName(i); got != want {
+			t.Errorf("columnName(%d) = %s, want %s", i, got, want)
+		}
+	}
+}
+
+// slideRel returns the relationship of an output slide with the given id.
+func slideRel(t *testing.T, pkg *sourcePackage, part, id string) relationship {
+	t.Helper()
+	rels, err := pkg.rels(part)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, rel := range rels {
+		if rel.ID == id {
+			return rel
+		}
+	}
+	t.Fatalf("%s has no relationship %s", part, id)
+	return relationship{}
+}
+
+func TestRenderTemplateImages(t *testing.T) {
+	logo, photo := encodeImage(t, "jpeg"), encodeImage(t, "gif")
+	data := map[string]any{
+		"logo":  "data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(logo),
+		"photo": base64.StdEncoding.EncodeToString(photo),
+	}
+	pkg := writeDeck(t, func(w io.Writer) error { return RenderTemplate(w, assetsDeck, data) })
+
+	parts, _ := pkg.slideParts()
+	slide := parts[0]
+	body, err := pkg.read(slide)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pics := pictureRegex.FindAll(body, -1)
+	if len(pics) != 2 {
+		t.Fatalf("got %d pictures, want 2 (tagged picture and picture placeholder)", len(pics))
+	}
+
+	tests := []struct {
+		name    string
+		pic     []byte
+		relID   string
+		data    []byte
+		ext     string
+		ct      string
+		keepXML string
+	}{
+		{"tagged picture", pics[0], "rIdImg1", logo, "jpeg", "image/jpeg", `<a:srcRect l="10000"/>`},
+		{"picture placeholder", pics[1], "rIdImg2", photo, "gif", "image/gif", `<a:off x="1000" y="2000"/><a:ext cx="3000" cy="4000"/>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !bytes.Contains(tt.pic, []byte(`r:embed="`+tt.relID+`"`)) {
+				t.Errorf("picture does not embed %s:\n%s", tt.relID, tt.pic)
+			}
+			if !bytes.Contains(tt.pic, []byte(tt.keepXML)) {
+				t.Errorf("picture lost %s:\n%s", tt.keepXML, tt.pic)
+			}
+			if bytes.Contains(tt.pic, []byte("{{image:")) {
+				t.Errorf("image tag left in the picture:\n%s", tt.pic)
+			}
+
+			rel := slideRel(t, pkg, slide, tt.relID)
+			if rel.Type != relTypeImage {
+				t.Errorf("relationship type = %s", rel.Type)
+			}
+			media := resolveTarget(slide, rel.Target)
+			if !strings.HasPrefix(media, "ppt/media/") || !strings.HasSuffix(media, "."+tt.ext) {
+				t.Errorf("media part = %s, want ppt/media/*.%s", media, tt.ext)
+			}
+			got, err := pkg.read(media)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.data) {
+				t.Error("media part does not hold the image")
+			}
+			if ct := pkg.contentType(media); ct != tt.ct {
+				t.Errorf("content type = %s, want %s", ct, tt.ct)
+			}
+			if pkg.defaults[tt.ext] != tt.ct {
+				t.Errorf("no Default content type for .%s", tt.ext)
+			}
+		})
+	}
+
+	// The untagged text shape stays a shape.
+	if !strings.Contains(partText(body), "Plain text") {
+		t.Error("untagged shape was changed")
+	}
+}
+
+func TestRenderTemplateChart(t *testing.T) {
+	data := map[string]any{"revenue": map[string]any{
+		"categories": []any{"Q1", "Q2", 3.0},
+		"series": []any{
+			map[string]any{"name": "2025", "values": []any{1.0, 2.5, 3.0}},
+			map[string]any{"name": "R&D", "values": []any{4.0, 5.0, 6.0}},
+		},
+	}}
+	pkg := writeDeck(t, func(w io.Writer) error { return RenderTemplate(w, assetsDeck, data) })
+
+	parts, _ := pkg.slideParts()
+	slide := parts[0]
+	body, _ := pkg.read(slide)
+	if bytes.Contains(body, []byte("{{chart:")) {
+		t.Error("chart tag left in the alt text")
+	}
+
+	chartPart := pkg.relTarget(slide, relTypeChart)
+	if ct := pkg.contentType(chartPart); ct != "application/vnd.openxmlformats-officedocument.drawingml.chart+xml" {
+		t.Errorf("chart content type = %q", ct)
+	}
+	chart, err := pkg.read(chartPart)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	series := seriesRegex.FindAll(chart, -1)
+	if len(series) != 2 {
+		t.Fatalf("got %d series, want 2", len(series))
+	}
+	wantSeries := [][]string{
+		{`<c:idx val="0"/>`, `<c:order val="0"/>`, `<c:f>Sheet1!$B$1</c:f>`, `<c:v>2025</c:v>`, `<c:f>Sheet1!$B$2:$B$4</c:f>`, `<c:v>2.5</c:v>`},
+		{`<c:idx val="1"/>`, `<c:order val="1"/>`, `<c:f>Sheet1!$C$1</c:f>`, `<c:v>R&amp;D</c:v>`, `<c:f>Sheet1!$C$2:$C$4</c:f>`, `<c:v>6</c:v>`},
+	}
+	for i, want := range wantSeries {
+		for _, w := range append(want,
+			`<c:f>Sheet1!$A$2:$A$4</c:f>`, `<c:v>Q1</c:v>`, `<c:v>3</c:v>`, `<c:ptCount val="3"/>`,
+			`<c:formatCode>0.0</c:formatCode>`, `<a:srgbClr val="4472C4"/>`) {
+			if !bytes.Contains(series[i], []byte(w)) {
+				t.Errorf("series %d lacks %s", i, w)
+			}
+		}
+	}
+	if bytes.Contains(chart, []byte("<c:v>Old</c:v>")) {
+		t.Error("old series name left in the chart")
+	}
+
+	workbookPart := pkg.relTarget(chartPart, relTypePackage)
+	if !strings.HasPrefix(workbookPart, "ppt/embeddings/") {
+		t.Fatalf("workbook part = %q", workbookPart)
+	}
+	workbook, err := pkg.read(workbookPart)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(workbook), int64(len(workbook)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sheet []byte
+	for _, f := range zr.File {
+		if f.Name == "xl/worksheets/sheet1.xml" {
+			rc, _ := f.Open()
+			sheet, _ = io.ReadAll(rc)
+			rc.Close()
+		}
+	}
+	for _, w := range []string{
+		`<c r="B1" t="inlineStr"><is><t>2025</t></is></c>`,
+		`<c r="C1" t="inlineStr"><is><t>R&amp;D</t></is></c>`,
+		`<c r="A4" t="inlineStr"><is><t>3</t></is></c>`,
+		`<c r="C4"><v>6</v></c>`,
+	} {
+		if !bytes.Contains(sheet, []byte(w)) {
+			t.Errorf("workbook lacks %s", w)
+		}
+	}
+}
+
+func TestRenderTemplateAssetsWithoutData(t *testing.T) {
+	pkg := writeDeck(t, func(w io.Writer) error { return RenderTemplate(w, assetsDeck, map[string]any{}) })
+
+	parts, _ := pkg.slideParts()
+	body, _ := pkg.read(parts[0])
+	for _, w := range []string{`r:embed="rId2"`, `{{image:logo}}`, `{{image:photo}}`, `{{chart:revenue}}`} {
+		if !bytes.Contains(body, []byte(w)) {
+			t.Errorf("slide lacks %s", w)
+		}
+	}
+	chart, _ := pkg.read(pkg.relTarget(parts[0], relTypeChart))
+	if !bytes.Contains(chart, []byte("<c:v>Old</c:v>")) {
+		t.Error("chart changed without data")
+	}
+}
+
+func TestRenderTemplateAssetErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data map[string]any
+		want string
+	}{
+		{"invalid image", map[string]any{"logo": "%%%"}, `image "logo"`},
+		{"chart is not an object", map[string]any{"revenue": "1,2,3"}, `chart "revenue"`},
+		{"chart without series", map[string]any{"revenue": map[string]any{"categories": []any{"Q1"}}}, "categories and series are required"},
+		{"values do not match categories", map[string]any{"revenue": map[string]any{
+			"categories": []any{"Q1", "Q2"},
+			"series":     []any{map[string]any{"name": "A", "values": []any{1.0}}},
+		}}, "has 1 values for 2 categories"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := RenderTemplate(io.Discard, assetsDeck, tt.data)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPicturePlaceholderWithoutCNvPr(t *testing.T) {
+	photo := base64.StdEncoding.EncodeToString(encodeImage(t, "png"))
+	r := &assetRenderer{sc: scope{map[string]any{"photo": photo}}}
+
+	sp := []byte(`<p:sp><p:nvSpPr><p:nvPr><p:ph idx="1"/></p:nvPr></p:nvSpPr><p:spPr/>` +
+		`<p:txBody><a:p><a:r><a:t>{{image:photo}}</a:t></a:r></a:p></p:txBody></p:sp>`)
+	if got := r.picturePlaceholder(sp); !bytes.Equal(got, sp) {
+		t.Errorf("shape was rewritten:\n%s", got)
+	}
+	if len(r.ps.media) != 0 || r.err != nil {
+		t.Errorf("media = %d, err = %v", len(r.ps.media), r.err)
+	}
+}
@@ -0,0 +1,389 @@
+package pptx
+
+import (
+	"bytes"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Control tags:
+//
+//	{{#each items}} ... {{/each}}   repeat a slide (or, inside a table, a row) per element
+//	{{#if flag}} ... {{/if}}        keep a slide, shape or table row only if flag is truthy
+//
+// A text shape that contains nothing but control tags is a slide directive:
+// it applies to the whole slide and is removed from the output.
+var (
+	shapeRegex    = regexp.MustCompile(`(?s)<p:sp(?:\s[^>]*)?>.*?</p:sp>`)
+	tableRowRegex = regexp.MustCompile(`(?s)<a:tr(?:\s[^>]*)?>.*?</a:tr>`)
+	rowIDExtRegex = regexp.MustCompile(`(?s)<a:extLst>\s*<a:ext uri="\{0D108BD9-81ED-4DB2-BD59-A6C34878D82A\}">.*?</a:ext>\s*</a:extLst>`)
+)
+
+type controlTag struct {
+	kind string // each | if
+	name string
+}
+
+// controlTags returns the opening #each / #if tags found in text.
+func controlTags(text string) []controlTag {
+	var tags []controlTag
+	for _, m := range tagRegex.FindAllStringSubmatch(text, -1) {
+		fields := strings.Fields(m[1])
+		if len(fields) != 2 {
+			continue
+		}
+		switch fields[0] {
+		case "#each":
+			tags = append(tags, controlTag{kind: "each", name: fields[1]})
+		case "#if":
+			tags = append(tags, controlTag{kind: "if", name: fields[1]})
+		}
+	}
+	return tags
+}
+
+func isControlTag(name string) bool {
+	return strings.HasPrefix(name, "#") || strings.HasPrefix(name, "/")
+}
+
+// onlyControlTags reports whether text consists of control tags and whitespace only.
+func onlyControlTags(text string) bool {
+	found := false
+	rest := tagRegex.ReplaceAllStringFunc(text, func(tag string) string {
+		if isControlTag(strings.TrimSpace(tag[2 : len(tag)-2])) {
+			found = true
+			return ""
+		}
+		return tag
+	})
+	return found && strings.TrimSpace(rest) == ""
+}
+
+// valueTags returns the sorted, unique non-control tag names in text.
+func valueTags(text string) []string {
+	seen := make(map[string]bool)
+	for _, m := range tagRegex.FindAllStringSubmatch(text, -1) {
+		name := strings.TrimSpace(m[1])
+		if name != "" && !isControlTag(name) && !isAssetTag(name) {
+			seen[name] = true
+		}
+	}
+	return sortedKeys(seen)
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func partText(body []byte) string {
+	return strings.Join(paragraphTexts(body), "\n")
+}
+
+// scope is the chain of data objects visible to a tag, innermost last.
+type scope []any
+
+func (s scope) with(v any) scope {
+	next := make(scope, len(s), len(s)+1)
+	copy(next, s)
+	return append(next, v)
+}
+
+// lookup resolves a name against the innermost object first. "this" (or ".")
+// is the current loop element itself.
+func (s scope) lookup(name string) (any, bool) {
+	if len(s) == 0 {
+		return nil, false
+	}
+	if name == "this" || name == "." {
+		return s[len(s)-1], true
+	}
+	for i := len(s) - 1; i >= 0; i-- {
+		if v, ok := lookupValue(s[i], name); ok {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// resolve is the replaceTags callback; control tags always resolve to "".
+func (s scope) resolve(name string) (string, bool) {
+	if isControlTag(name) {
+		return "", true
+	}
+	v, ok := s.lookup(name)
+	if !ok {
+		return "", false
+	}
+	return formatValue(v), true
+}
+
+func (s scope) truthy(name string) bool {
+	v, ok := s.lookup(name)
+	if !ok {
+		return false
+	}
+	switch val := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return val
+	case string:
+		return val != ""
+	case float64:
+		return val != 0
+	case []any:
+		return len(val) > 0
+	case map[string]any:
+		return len(val) > 0
+	default:
+		return true
+	}
+}
+
+func (s scope) items(name string) []any {
+	v, _ := s.lookup(name)
+	items, _ := v.([]any)
+	return items
+}
+
+// slideDirectives returns the slide-level #each and #if names of a slide and
+// the slide body without its directive shapes. An #each inside an ordinary
+// text shape also repeats the slide.
+func slideDirectives(body []byte) (each string, conds []string, cleaned []byte) {
+	cleaned = shapeRegex.ReplaceAllFunc(body, func(sp []byte) []byte {
+		text := partText(sp)
+		directive := onlyControlTags(text)
+		for _, c := range controlTags(text) {
+			switch {
+			case c.kind == "each" && each == "":
+				each = c.name
+			case c.kind == "if" && directive:
+				conds = append(conds, c.name)
+			}
+		}
+		if directive {
+			return nil
+		}
+		return sp
+	})
+	return each, conds, cleaned
+}
+
+// slideInstance is one output slide of a template slide and the data scope it
+// was rendered with.
+type slideInstance struct {
+	body []byte
+	sc   scope
+}
+
+// renderSlide expands one template slide into zero or more output slides.
+func renderSlide(body []byte, root scope) []slideInstance {
+	each, conds, body := slideDirectives(body)
+	for _, c := range conds {
+		if !root.truthy(c) {
+			return nil
+		}
+	}
+
+	scopes := []scope{root}
+	if each != "" {
+		scopes = nil
+		for _, item := range root.items(each) {
+			scopes = append(scopes, root.with(item))
+		}
+	}
+
+	var out []slideInstance
+	for _, sc := range scopes {
+		rendered := expandTableRows(body, sc)
+		rendered = applyShapeConditions(rendered, sc)
+		out = append(out, slideInstance{body: replaceTags(rendered, sc.resolve), sc: sc})
+	}
+	return out
+}
+
+// expandTableRows clones rows holding {{#each}} once per element and drops
+// rows whose {{#if}} is falsy.
+func expandTableRows(body []byte, sc scope) []byte {
+	return tableRowRegex.ReplaceAllFunc(body, func(row []byte) []byte {
+		each := ""
+		for _, c := range controlTags(partText(row)) {
+			switch c.kind {
+			case "if":
+				if !sc.truthy(c.name) {
+					return nil
+				}
+			case "each":
+				if each == "" {
+					each = c.name
+				}
+			}
+		}
+		if each == "" {
+			return row
+		}
+
+		// Cloned rows must not share the PowerPoint row id extension.
+		template := rowIDExtRegex.ReplaceAll(row, nil)
+		var out bytes.Buffer
+		for _, item := range sc.items(each) {
+			out.Write(replaceTags(template, sc.with(item).resolve))
+		}
+		return out.Bytes()
+	})
+}
+
+// applyShapeConditions removes text shapes whose {{#if}} is falsy.
+func applyShapeConditions(body []byte, sc scope) []byte {
+	return shapeRegex.ReplaceAllFunc(body, func(sp []byte) []byte {
+		for _, c := range controlTags(partText(sp)) {
+			if c.kind == "if" && !sc.truthy(c.name) {
+				return nil
+			}
+		}
+		return sp
+	})
+}
+
+// TemplateSchema describes the data a template expects.
+type TemplateSchema struct {
+	Tags         []string            `json:"tags"`
+	Loops        []TemplateLoop      `json:"loops,omitempty"`
+	Conditionals []TemplateCondition `json:"conditionals,omitempty"`
+	Images       []string            `json:"images,omitempty"`
+	Charts       []string            `json:"charts,omitempty"`
+
+	usage map[usageKey]*tagUsage // for JSONSchema
+}
+
+// TemplateLoop is an {{#each}} block and the tags used inside it.
+type TemplateLoop struct {
+	Name  string   `json:"name"`
+	Scope string   `json:"scope"` // slide | row
+	Slide int      `json:"slide"`
+	Tags  []string `json:"tags"`
+}
+
+// TemplateCondition is an {{#if}} block.
+type TemplateCondition struct {
+	Name  string `json:"name"`
+	Scope string `json:"scope"` // slide | shape | row
+	Slide int    `json:"slide"`
+}
+
+// IsEmpty reports whether the template has no tags at all.
+func (s *TemplateSchema) IsEmpty() bool {
+	return len(s.Tags) == 0 && len(s.Loops) == 0 && len(s.Conditionals) == 0 &&
+		len(s.Images) == 0 && len(s.Charts) == 0
+}
+
+// ExtractTemplateSchema scans the slides of a PPTX for value tags, loops and
+// conditionals, following the same rules RenderTemplate applies.
+func ExtractTemplateSchema(pptxPath string) (*TemplateSchema, error) {
+	pkg, err := openPackage(pptxPath)
+	if err != nil {
+		return nil, err
+	}
+	defer pkg.Close()
+
+	parts, err := pkg.slideParts()
+	if err != nil {
+		return nil, err
+	}
+
+	schema := &TemplateSchema{Tags: []string{}, usage: make(map[usageKey]*tagUsage)}
+	rootTags := make(map[string]bool)
+	assets := map[string]map[string]bool{"image": {}, "chart": {}}
+
+	for i, part := range parts {
+		slideNum := i + 1
+		body, err := pkg.read(part)
+		if err != nil {
+			continue
+		}
+
+		each, conds, body := slideDirectives(body)
+		slideCond := len(conds) > 0
+		for _, c := range conds {
+			schema.Conditionals = append(schema.Conditionals, TemplateCondition{Name: c, Scope: "slide", Slide: slideNum})
+			schema.use("", c, usageCondition, "", false)
+		}
+		if each != "" {
+			schema.use("", each, usageLoop, "", !slideCond)
+		}
+
+		// Asset tags live in alt text as well as in shape text.
+		for _, text := range [][]byte{body, []byte(partText(body))} {
+			for _, m := range assetTagRegex.FindAllSubmatch(text, -1) {
+				assets[string(m[1])][string(m[2])] = true
+				schema.use(each, string(m[2]), string(m[1]), "", !slideCond)
+			}
+		}
+
+		// rest is the slide without the rows and shapes whose usage has been recorded.
+		rest := body
+		for _, row := range tableRowRegex.FindAll(body, -1) {
+			text := partText(row)
+			rowEach, rowCond := "", slideCond
+			for _, c := range controlTags(text) {
+				switch c.kind {
+				case "if":
+					schema.Conditionals = append(schema.Conditionals, TemplateCondition{Name: c.name, Scope: "row", Slide: slideNum})
+					schema.use(each, c.name, usageCondition, "", false)
+					rowCond = true
+				case "each":
+					if rowEach == "" {
+						rowEach = c.name
+					}
+				}
+			}
+			rest = bytes.Replace(rest, row, nil, 1)
+			if rowEach != "" {
+				schema.Loops = append(schema.Loops, TemplateLoop{Name: rowEach, Scope: "row", Slide: slideNum, Tags: valueTags(text)})
+				schema.use(each, rowEach, usageLoop, "", !rowCond)
+				schema.useTags(nestedLoop(each, rowEach), row, rowCond)
+				body = bytes.Replace(body, row, nil, 1)
+				continue
+			}
+			schema.useTags(each, row, rowCond)
+		}
+
+		for _, sp := range shapeRegex.FindAll(rest, -1) {
+			shapeCond := slideCond
+			for _, c := range controlTags(partText(sp)) {
+				if c.kind == "if" {
+					schema.Conditionals = append(schema.Conditionals, TemplateCondition{Name: c.name, Scope: "shape", Slide: slideNum})
+					schema.use(each, c.name, usageCondition, "", false)
+					shapeCond = true
+				}
+			}
+			schema.useTags(each, sp, shapeCond)
+			rest = bytes.Replace(rest, sp, nil, 1)
+		}
+		schema.useTags(each, rest, slideCond)
+
+		tags := valueTags(partText(body))
+		if each != "" {
+			schema.Loops = append(schema.Loops, TemplateLoop{Name: each, Scope: "slide", Slide: slideNum, Tags: tags})
+			continue
+		}
+		for _, t := range tags {
+			rootTags[t] = true
+		}
+	}
+
+	schema.Tags = sortedKeys(rootTags)
+	if len(assets["image"]) > 0 {
+		schema.Images = sortedKeys(assets["image"])
+	}
+	if len(assets["chart"]) > 0 {
+		schema.Charts = sortedKeys(assets["chart"])
+	}
+	return schema, nil
+}
@@ -0,0 +1,205 @@
+package pptx
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOnlyControlTags(t *testing.T) {
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{"{{#each items}}", true},
+		{" {{#if flag}} {{/if}} ", true},
+		{"{{#if flag}}Note{{/if}}", false},
+		{"{{#each items}}{{name}}", false},
+		{"{{name}}", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := onlyControlTags(tt.text); got != tt.want {
+			t.Errorf("onlyControlTags(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestScopeTruthy(t *testing.T) {
+	sc := scope{map[string]any{
+		"yes": true, "no": false, "zero": 0.0, "one": 1.0, "empty": "", "text": "x",
+		"none": []any{}, "some": []any{1.0}, "obj": map[string]any{"a": 1.0}, "null": nil,
+	}}
+
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"yes", true}, {"no", false},
+		{"zero", false}, {"one", true},
+		{"empty", false}, {"text", true},
+		{"none", false}, {"some", true},
+		{"obj", true}, {"obj.a", true},
+		{"null", false}, {"missing", false},
+	}
+
+	for _, tt := range tests {
+		if got := sc.truthy(tt.name); got != tt.want {
+			t.Errorf("truthy(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestScopeLookup(t *testing.T) {
+	root := map[string]any{"name": "root", "title": "Report"}
+	item := map[string]any{"name": "item"}
+	sc := scope{root}.with(item)
+
+	tests := []struct {
+		name string
+		want any
+	}{
+		{"name", "item"},    // innermost first
+		{"title", "Report"}, // falls back to the root
+		{"this", item},
+		{".", item},
+	}
+
+	for _, tt := range tests {
+		got, ok := sc.lookup(tt.name)
+		if !ok || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("lookup(%q) = %v, %v; want %v", tt.name, got, ok, tt.want)
+		}
+	}
+}
+
+func TestRenderTemplateControl(t *testing.T) {
+	regions := []any{
+		map[string]any{
+			"name":      "North",
+			"showTotal": true,
+			"total":     8.0,
+			"products": []any{
+				map[string]any{"title": "Widget", "qty": 3.0},
+				map[string]any{"title": "Gadget", "qty": 5.0},
+			},
+		},
+		map[string]any{
+			"name":      "South",
+			"showTotal": false,
+			"total":     1.0,
+			"products":  []any{map[string]any{"title": "Bolt", "qty": 1.0}},
+		},
+	}
+	base := map[string]any{"customer": map[string]any{"name": "Ann"}, "dept": "Sales", "active": false}
+	with := func(extra map[string]any) map[string]any {
+		data := map[string]any{}
+		for k, v := range base {
+			data[k] = v
+		}
+		for k, v := range extra {
+			data[k] = v
+		}
+		return data
+	}
+	cover := []string{"Hello Ann!", "R&D: Sales / {{missing}}", "Active: false"}
+
+	tests := []struct {
+		name string
+		data map[string]any
+		want [][]string
+	}{
+		{
+			name: "loops and conditions off",
+			data: with(nil),
+			want: [][]string{cover},
+		},
+		{
+			name: "shape condition on",
+			data: with(map[string]any{"note": "call back"}),
+			want: [][]string{append(append([]string{}, cover...), "Note: call back")},
+		},
+		{
+			name: "nested each and row condition",
+			data: with(map[string]any{"regions": regions}),
+			want: [][]string{
+				cover,
+				{"Region North", "Product", "Qty", "Widget", "3", "Gadget", "5", "Total", "8"},
+				{"Region South", "Product", "Qty", "Bolt", "1"},
+			},
+		},
+		{
+			name: "empty loop drops the slide",
+			data: with(map[string]any{"regions": []any{}, "appendix": true}),
+			want: [][]string{cover, {"Appendix"}},
+		},
+		{
+			name: "empty row loop keeps the table",
+			data: with(map[string]any{"regions": []any{map[string]any{"name": "East"}}}),
+			want: [][]string{cover, {"Region East", "Product", "Qty"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkg := writeDeck(t, func(w io.Writer) error { return RenderTemplate(w, templateDeck, tt.data) })
+			if got := slideTexts(t, pkg); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("slides = %q\nwant     %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderTemplateRowIDs(t *testing.T) {
+	data := map[string]any{"regions": []any{map[string]any{
+		"name":      "North",
+		"showTotal": true,
+		"products":  []any{map[string]any{"title": "A"}, map[string]any{"title": "B"}},
+	}}}
+	pkg := writeDeck(t, func(w io.Writer) error { return RenderTemplate(w, templateDeck, data) })
+
+	parts, _ := pkg.slideParts()
+	body, err := pkg.read(parts[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Header and total rows keep their ids; cloned rows must not repeat one.
+	if got := strings.Count(string(body), "a16:rowId"); got != 2 {
+		t.Errorf("got %d row ids, want 2", got)
+	}
+	if got := strings.Count(string(body), "<a:tr "); got != 4 {
+		t.Errorf("got %d rows, want 4", got)
+	}
+	if strings.Contains(string(body), "{{#") || strings.Contains(string(body), "{{/") {
+		t.Errorf("control tags left in the slide:\n%s", body)
+	}
+}
+
+func TestExtractTemplateSchemaControl(t *testing.T) {
+	schema, err := ExtractTemplateSchema(templateDeck)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantTags := []string{"active", "customer.name", "dept", "missing", "note"}
+	if !reflect.DeepEqual(schema.Tags, wantTags) {
+		t.Errorf("tags = %v, want %v", schema.Tags, wantTags)
+	}
+	wantLoops := []TemplateLoop{
+		{Name: "products", Scope: "row", Slide: 2, Tags: []string{"qty", "title"}},
+		{Name: "regions", Scope: "slide", Slide: 2, Tags: []string{"name", "total"}},
+	}
+	if !reflect.DeepEqual(schema.Loops, wantLoops) {
+		t.Errorf("loops = %+v\nwant    %+v", schema.Loops, wantLoops)
+	}
+	wantConds := []TemplateCondition{
+		{Name: "note", Scope: "shape", Slide: 1},
+		{Name: "showTotal", Scope: "row", Slide: 2},
+		{Name: "appendix", Scope: "slide", Slide: 3},
+	}
+	if !reflect.DeepEqual(schema.Conditionals, wantConds) {
+		t.Errorf("conditionals = %+v\nwant           %+v", schema.Conditionals, wantConds)
+	}
+}
@@ -0,0 +1,438 @@
+package pptx
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Usage kinds recorded per tag during schema extraction.
+const (
+	usageValue     = "value"
+	usageImage     = "image"
+	usageChart     = "chart"
+	usageLoop      = "loop"
+	usageCondition = "condition"
+
+	maxUsageContexts = 5
+)
+
+// usageKey is a tag name, relative to the loop it appears in ("" for the root).
+// Nested loops are joined with loopSeparator, outermost first.
+type usageKey struct {
+	loop string
+	name string
+}
+
+// loopSeparator can join loop names because a control tag's name is a single
+// field and never contains whitespace.
+const loopSeparator = " "
+
+func nestedLoop(outer, inner string) string {
+	if outer == "" {
+		return inner
+	}
+	return outer + loopSeparator + inner
+}
+
+type tagUsage struct {
+	kinds    map[string]bool
+	required bool     // used at least once outside any {{#if}}
+	contexts []string // paragraph texts the tag appears in
+}
+
+func (s *TemplateSchema) use(loop, name, kind, context string, required bool) {
+	key := usageKey{loop: loop, name: name}
+	u, ok := s.usage[key]
+	if !ok {
+		u = &tagUsage{kinds: make(map[string]bool)}
+		s.usage[key] = u
+	}
+	u.kinds[kind] = true
+	u.required = u.required || required
+	if context != "" && len(u.contexts) < maxUsageContexts {
+		u.contexts = append(u.contexts, context)
+	}
+}
+
+// useTags records the value tags of a fragment paragraph by paragraph.
+func (s *TemplateSchema) useTags(loop string, fragment []byte, conditional bool) {
+	for _, p := range paragraphTexts(fragment) {
+		for _, name := range valueTags(p) {
+			s.use(loop, name, usageValue, p, !conditional)
+		}
+	}
+}
+
+// JSONSchema is the subset of JSON Schema (2020-12) inferred for templates.
+type JSONSchema struct {
+	Schema           string                 `json:"$schema,omitempty"`
+	Type             schemaTypes            `json:"type,omitempty"`
+	Format           string                 `json:"format,omitempty"`
+	ContentEncoding  string                 `json:"contentEncoding,omitempty"`
+	ContentMediaType string                 `json:"contentMediaType,omitempty"`
+	Properties       map[string]*JSONSchema `json:"properties,omitempty"`
+	Required         []string               `json:"required,omitempty"`
+	Items            *JSONSchema            `json:"items,omitempty"`
+}
+
+// schemaTypes marshals as a single type name or as a list of names.
+type schemaTypes []string
+
+func (t schemaTypes) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+func (t *schemaTypes) UnmarshalJSON(b []byte) error {
+	var one string
+	if err := json.Unmarshal(b, &one); err == nil {
+		*t = schemaTypes{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(b, &many); err != nil {
+		return err
+	}
+	*t = many
+	return nil
+}
+
+// The name heuristics match whole words of a tag name, so that e.g. summary,
+// country or candidate stay strings.
+var (
+	dateNameRegex   = regexp.MustCompile(`(^|_)(date|datum|deadline|birthday)s?(_|$)|_(at|on)$|^(created|updated|due|valid)(_|$)`)
+	numberNameRegex = regexp.MustCompile(`(^|_)(price|total|amount|qty|quantity|count|sum|percent|pct|rate|revenue|cost|budget|balance|score|osszeg)s?(_|$)|(^|_)(num|no)$`)
+	camelCaseRegex  = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+)
+
+// nameWords lowercases a tag name into words separated by "_": dueDate,
+// due-date and due_date all become due_date.
+func nameWords(name string) string {
+	name = camelCaseRegex.ReplaceAllString(name, "${1}_${2}")
+	return strings.ToLower(strings.ReplaceAll(name, "-", "_"))
+}
+
+// inferValueSchema guesses the type of a plain tag from its name and from the
+// text around it (currency or percent signs, "Date:" labels).
+func inferValueSchema(name string, contexts []string) *JSONSchema {
+	base := name[strings.LastIndex(name, ".")+1:]
+	tag := `\{\{\s*` + regexp.QuoteMeta(name) + `\s*}}`
+	numberContext := regexp.MustCompile(`(?i)([$€£¥]\s*` + tag + `|` + tag + `\s*(%|[$€£¥]|ft\b|huf\b|eur\b|usd\b))`)
+	dateContext := regexp.MustCompile(`(?i)\b(date|dátum)\s*:?\s*` + tag)
+
+	words := nameWords(base)
+	isNumber := numberNameRegex.MatchString(words)
+	isDate := dateNameRegex.MatchString(words)
+	for _, c := range contexts {
+		isNumber = isNumber || numberContext.MatchString(c)
+		isDate = isDate || dateContext.MatchString(c)
+	}
+
+	switch {
+	case isDate:
+		return &JSONSchema{Type: schemaTypes{"string"}, Format: "date"}
+	case isNumber:
+		return &JSONSchema{Type: schemaTypes{"number"}}
+	default:
+		return &JSONSchema{Type: schemaTypes{"string", "number", "boolean"}}
+	}
+}
+
+func imageSchema() *JSONSchema {
+	return &JSONSchema{Type: schemaTypes{"string"}, ContentEncoding: "base64", ContentMediaType: "image/*"}
+}
+
+func chartSchema() *JSONSchema {
+	return &JSONSchema{
+		Type: schemaTypes{"object"},
+		Properties: map[string]*JSONSchema{
+			"categories": {Type: schemaTypes{"array"}, Items: &JSONSchema{Type: schemaTypes{"string", "number", "boolean"}}},
+			"series": {Type: schemaTypes{"array"}, Items: &JSONSchema{
+				Type: schemaTypes{"object"},
+				Properties: map[string]*JSONSchema{
+					"name":   {Type: schemaTypes{"string", "number", "boolean"}},
+					"values": {Type: schemaTypes{"array"}, Items: &JSONSchema{Type: schemaTypes{"number"}}},
+				},
+				Required: []string{"name", "values"},
+			}},
+		},
+		Required: []string{"categories", "series"},
+	}
+}
+
+func (u *tagUsage) schema(name string) *JSONSchema {
+	switch {
+	case u.kinds[usageChart]:
+		return chartSchema()
+	case u.kinds[usageImage]:
+		return imageSchema()
+	case u.kinds[usageLoop]:
+		return &JSONSchema{Type: schemaTypes{"array"}, Items: &JSONSchema{Type: schemaTypes{"object"}}}
+	case u.kinds[usageValue]:
+		return inferValueSchema(name, u.contexts)
+	default:
+		// {{#if}} flags accept any value; only truthiness matters.
+		return &JSONSchema{}
+	}
+}
+
+// JSONSchema builds the JSON Schema of the render payload. Tags used outside
+// every {{#if}} are required; loops become arrays of objects built from the
+// tags inside them.
+func (s *TemplateSchema) JSONSchema() *JSONSchema {
+	root := &JSONSchema{
+		Schema:     "https://json-schema.org/draft/2020-12/schema",
+		Type:       schemaTypes{"object"},
+		Properties: map[string]*JSONSchema{},
+	}
+
+	keys := make([]usageKey, 0, len(s.usage))
+	for k := range s.usage {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].loop != keys[j].loop {
+			return keys[i].loop < keys[j].loop
+		}
+		return keys[i].name < keys[j].name
+	})
+
+	for _, k := range keys {
+		if k.loop == "" {
+			u := s.usage[k]
+			placeSchema(root, k.name, u.schema(k.name), u.required)
+		}
+	}
+	for _, k := range keys {
+		if k.loop == "" {
+			continue
+		}
+		if s.providedOutside(k) {
+			continue
+		}
+		u := s.usage[k]
+		arr := loopArray(root, k.loop)
+		if k.name == "this" || k.name == "." {
+			if len(arr.Items.Properties) == 0 {
+				arr.Items = u.schema(k.name)
+			}
+			continue
+		}
+		placeSchema(arr.Items, k.name, u.schema(k.name), u.required)
+	}
+	return root
+}
+
+// providedOutside reports whether the root or an enclosing loop always
+// provides the name of a loop tag; it resolves there through scope fallback.
+func (s *TemplateSchema) providedOutside(k usageKey) bool {
+	loops := strings.Split(k.loop, loopSeparator)
+	for i := range loops {
+		outer := strings.Join(loops[:i], loopSeparator)
+		if u, ok := s.usage[usageKey{loop: outer, name: k.name}]; ok && u.required {
+			return true
+		}
+	}
+	return false
+}
+
+// loopArray returns the array schema of a (possibly nested) loop, creating
+// the arrays of the loops that enclose it.
+func loopArray(root *JSONSchema, loop string) *JSONSchema {
+	obj, arr := root, root
+	for _, name := range strings.Split(loop, loopSeparator) {
+		arr = placeSchema(obj, name, &JSONSchema{Type: schemaTypes{"array"}}, false)
+		if arr.Items == nil {
+			arr.Items = &JSONSchema{Type: schemaTypes{"object"}}
+		}
+		obj = arr.Items
+	}
+	return arr
+}
+
+// placeSchema stores leaf at a dotted path below obj, creating intermediate
+// objects (or arrays, for numeric segments), and returns the node at the path.
+// An existing node with structure (properties or items) wins over a leaf.
+func placeSchema(obj *JSONSchema, dotted string, leaf *JSONSchema, required bool) *JSONSchema {
+	cur := obj
+	segs := strings.Split(dotted, ".")
+	for i, seg := range segs {
+		last := i == len(segs)-1
+
+		if _, err := strconv.Atoi(seg); err == nil {
+			if cur.Items == nil {
+				cur.Type, cur.Format, cur.Properties, cur.Required = schemaTypes{"array"}, "", nil, nil
+				cur.Items = &JSONSchema{}
+			}
+			if last && cur.Items.Properties == nil && cur.Items.Items == nil {
+				cur.Items = leaf
+			}
+			cur = cur.Items
+			required = false
+			continue
+		}
+
+		if cur.Items != nil {
+			// A named field of an array (loop) cannot be addressed; keep the array.
+			return cur
+		}
+		if cur.Properties == nil {
+			cur.Type, cur.Format = schemaTypes{"object"}, ""
+			cur.Properties = map[string]*JSONSchema{}
+		}
+		child, ok := cur.Properties[seg]
+		switch {
+		case !ok && last:
+			child = leaf
+		case !ok:
+			child = &JSONSchema{Type: schemaTypes{"object"}, Properties: map[string]*JSONSchema{}}
+		case last && child.Properties == nil && child.Items == nil:
+			child = leaf
+		}
+		cur.Properties[seg] = child
+		if required && !contains(cur.Required, seg) {
+			cur.Required = append(cur.Required, seg)
+		}
+		cur = child
+	}
+	return cur
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// FieldError is a payload field that does not match the template schema.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Validate checks a decoded JSON payload against the schema and returns the
+// missing or mistyped fields.
+func (s *JSONSchema) Validate(data any) []FieldError {
+	errs := []FieldError{}
+	s.validate("", data, &errs)
+	return errs
+}
+
+func (s *JSONSchema) validate(path string, v any, errs *[]FieldError) {
+	fail := func(field, format string, args ...any) {
+		*errs = append(*errs, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if len(s.Type) > 0 && !s.matchesType(v) {
+		fail(path, "expected %s, got %s", strings.Join(s.Type, " or "), jsonTypeName(v))
+		return
+	}
+
+	switch val := v.(type) {
+	case string:
+		if s.Format == "date" && !isDate(val) {
+			fail(path, "expected a date (%s), got %q", dateFormats, val)
+		}
+		if s.ContentEncoding == "base64" {
+			if _, _, _, err := decodeImage(val); err != nil {
+				fail(path, "%v", err)
+			}
+		}
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := val[name]; !ok {
+				fail(joinField(path, name), "is required")
+			}
+		}
+		names := make([]string, 0, len(s.Properties))
+		for name := range s.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if pv, ok := val[name]; ok {
+				s.Properties[name].validate(joinField(path, name), pv, errs)
+			}
+		}
+	case []any:
+		if s.Items != nil {
+			for i, item := range val {
+				s.Items.validate(joinField(path, strconv.Itoa(i)), item, errs)
+			}
+		}
+	}
+}
+
+func (s *JSONSchema) matchesType(v any) bool {
+	return contains(s.Type, jsonTypeName(v))
+}
+
+func jsonTypeName(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case float64, json.Number:
+		return "number"
+	case bool:
+		return "boolean"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+func joinField(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+var dateLayouts = []string{
+	"2006-01-02",
+	time.RFC3339,
+	"2006-01-02 15:04",
+	"2006.01.02.",
+	"2006.01.02",
+	"01/02/2006",
+	"02.01.2006",
+	"January 2, 2006",
+	"Jan 2, 2006",
+	"2 January 2006",
+}
+
+// dateFormats lists the accepted date formats for error messages, as
+// examples of each layout.
+var dateFormats = func() string {
+	example := time.Date(2025, time.March, 31, 14, 30, 0, 0, time.UTC)
+	formats := make([]string, len(dateLayouts))
+	for i, layout := range dateLayouts {
+		formats[i] = example.Format(layout)
+	}
+	return strings.Join(formats, ", ")
+}()
+
+func isDate(s string) bool {
+	s = strings.TrimSpace(s)
+	for _, layout := range dateLayouts {
+		if _, err := time.Parse(layout, s); err == nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,172 @@
+package pptx
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInferValueSchema(t *testing.T) {
+	tests := []struct {
+		name     string
+		contexts []string
+		want     JSONSchema
+	}{
+		{"title", nil, JSONSchema{Type: schemaTypes{"string", "number", "boolean"}}},
+		{"due_date", nil, JSONSchema{Type: schemaTypes{"string"}, Format: "date"}},
+		{"order.created_at", nil, JSONSchema{Type: schemaTypes{"string"}, Format: "date"}},
+		{"price", nil, JSONSchema{Type: schemaTypes{"number"}}},
+		{"x", []string{"Total: {{ x }} %"}, JSONSchema{Type: schemaTypes{"number"}}},
+		{"x", []string{"€{{x}}"}, JSONSchema{Type: schemaTypes{"number"}}},
+		{"x", []string{"Date: {{x}}"}, JSONSchema{Type: schemaTypes{"string"}, Format: "date"}},
+		{"x", []string{"{{x}} items"}, JSONSchema{Type: schemaTypes{"string", "number", "boolean"}}},
+		{"dueDate", nil, JSONSchema{Type: schemaTypes{"string"}, Format: "date"}},
+		{"unit_prices", nil, JSONSchema{Type: schemaTypes{"number"}}},
+		{"order_no", nil, JSONSchema{Type: schemaTypes{"number"}}},
+		// Words merely containing a hint stay strings
+		{"summary", nil, JSONSchema{Type: schemaTypes{"string", "number", "boolean"}}},
+		{"country", nil, JSONSchema{Type: schemaTypes{"string", "number", "boolean"}}},
+		{"account", nil, JSONSchema{Type: schemaTypes{"string", "number", "boolean"}}},
+		{"corporate", nil, JSONSchema{Type: schemaTypes{"string", "number", "boolean"}}},
+		{"costume", nil, JSONSchema{Type: schemaTypes{"string", "number", "boolean"}}},
+		{"candidate", nil, JSONSchema{Type: schemaTypes{"string", "number", "boolean"}}},
+		{"validated", nil, JSONSchema{Type: schemaTypes{"string", "number", "boolean"}}},
+		{"x", []string{"Update: {{x}}"}, JSONSchema{Type: schemaTypes{"string", "number", "boolean"}}},
+	}
+
+	for _, tt := range tests {
+		if got := inferValueSchema(tt.name, tt.contexts); !reflect.DeepEqual(*got, tt.want) {
+			t.Errorf("inferValueSchema(%q, %q) = %+v, want %+v", tt.name, tt.contexts, *got, tt.want)
+		}
+	}
+}
+
+func TestTemplateJSONSchemaValidate(t *testing.T) {
+	schema, err := ExtractTemplateSchema(templateDeck)
+	if err != nil {
+		t.Fatal(err)
+	}
+	js := schema.JSONSchema()
+
+	valid := `{
+		"active": true,
+		"customer": {"name": "Ann"},
+		"dept": "Sales",
+		"missing": 1,
+		"regions": [
+			{"name": "North", "showTotal": true, "total": 8, "products": [{"title": "Widget", "qty": 3}]},
+			{"name": "South", "products": []}
+		]
+	}`
+
+	tests := []struct {
+		name    string
+		payload string
+		want    []string // fields with errors
+	}{
+		{"valid nested payload", valid, nil},
+		{"boolean values", strings.Replace(valid, `"dept": "Sales"`, `"dept": false`, 1), nil},
+		{
+			"missing fields",
+			`{"customer": {}, "regions": [{"products": [{"title": "A"}]}]}`,
+			[]string{"active", "dept", "missing", "customer.name", "regions.0.name", "regions.0.products.0.qty"},
+		},
+		{
+			"mistyped fields",
+			strings.NewReplacer(`"qty": 3`, `"qty": "three"`, `"products": []`, `"products": {}`).Replace(valid),
+			[]string{"regions.0.products.0.qty", "regions.1.products"},
+		},
+		{"object for a value", strings.Replace(valid, `"missing": 1`, `"missing": {}`, 1), []string{"missing"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var data any
+			if err := json.Unmarshal([]byte(tt.payload), &data); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, e := range js.Validate(data) {
+				got = append(got, e.Field)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("errors on %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplateJSONSchemaNestedLoop(t *testing.T) {
+	schema, err := ExtractTemplateSchema(templateDeck)
+	if err != nil {
+		t.Fatal(err)
+	}
+	js := schema.JSONSchema()
+
+	if _, ok := js.Properties["products"]; ok {
+		t.Error("row loop inside a slide loop placed at the root")
+	}
+	regions := js.Properties["regions"]
+	if regions == nil || regions.Items == nil {
+		t.Fatal("regions loop missing")
+	}
+	products := regions.Items.Properties["products"]
+	if products == nil || products.Items == nil {
+		t.Fatal("products loop missing from the regions items")
+	}
+	if !contains(regions.Items.Required, "products") || contains(regions.Items.Required, "total") {
+		t.Errorf("regions items require %v, want products and not total", regions.Items.Required)
+	}
+	if got := products.Items.Required; !reflect.DeepEqual(got, []string{"qty", "title"}) {
+		t.Errorf("products items require %v", got)
+	}
+	if _, ok := regions.Items.Properties["showTotal"]; !ok {
+		t.Error("row condition inside a slide loop not placed in the loop items")
+	}
+}
+
+func TestChartSchemaValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    []string
+	}{
+		{"valid", `{"categories": ["Q1", 2, true], "series": [{"name": 2025, "values": [1, 2, 3]}]}`, nil},
+		{"missing series", `{"categories": ["Q1"]}`, []string{"series"}},
+		{"non-numeric values", `{"categories": ["Q1"], "series": [{"name": "A", "values": ["x"]}]}`, []string{"series.0.values.0"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var data any
+			if err := json.Unmarshal([]byte(tt.payload), &data); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, e := range chartSchema().Validate(data) {
+				got = append(got, e.Field)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("errors on %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseChartData(t *testing.T) {
+	var v any
+	if err := json.Unmarshal([]byte(`{"categories": ["Q1", 2, true], "series": [{"name": 2025, "values": [1, 2, 3]}, {"name": false, "values": [4, 5, 6]}]}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	data, err := parseChartData(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Q1", "2", "true"}; !reflect.DeepEqual(data.Categories, want) {
+		t.Errorf("categories = %v, want %v", data.Categories, want)
+	}
+	if data.Series[0].Name != "2025" || data.Series[1].Name != "false" {
+		t.Errorf("series names = %q, %q", data.Series[0].Name, data.Series[1].Name)
+	}
+}
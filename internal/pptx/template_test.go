@@ -0,0 +1,167 @@
+package pptx
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const templateDeck = "testdata/template.pptx"
+
+func TestFillParagraph(t *testing.T) {
+	data := map[string]any{
+		"name":     "Ann",
+		"customer": map[string]any{"name": "Ann & Bob"},
+		"lines":    []any{"first", "second"},
+		"total":    1234.5,
+		"html":     "<b>",
+	}
+	resolve := scope{data}.resolve
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			"single run",
+			`<a:p><a:r><a:t>Hi {{name}}!</a:t></a:r></a:p>`,
+			`<a:p><a:r><a:t>Hi Ann!</a:t></a:r></a:p>`,
+		},
+		{
+			"spaces inside the tag",
+			`<a:p><a:r><a:t>{{ name }}</a:t></a:r></a:p>`,
+			`<a:p><a:r><a:t>Ann</a:t></a:r></a:p>`,
+		},
+		{
+			"tag split across runs",
+			`<a:p><a:r><a:rPr b="1"/><a:t>Hello {{cust</a:t></a:r><a:r><a:rPr i="1"/><a:t>omer.na</a:t></a:r><a:r><a:t>me}}!</a:t></a:r></a:p>`,
+			`<a:p><a:r><a:rPr b="1"/><a:t>Hello Ann &amp; Bob</a:t></a:r><a:r><a:rPr i="1"/><a:t></a:t></a:r><a:r><a:t>!</a:t></a:r></a:p>`,
+		},
+		{
+			"braces split from the name",
+			`<a:p><a:r><a:t>{</a:t></a:r><a:r><a:t>{name}</a:t></a:r><a:r><a:t>}</a:t></a:r></a:p>`,
+			`<a:p><a:r><a:t>Ann</a:t></a:r><a:r><a:t></a:t></a:r><a:r><a:t></a:t></a:r></a:p>`,
+		},
+		{
+			"several tags",
+			`<a:p><a:r><a:t>{{lines.0}}, {{lines.1}}</a:t></a:r><a:r><a:t> = {{total}}</a:t></a:r></a:p>`,
+			`<a:p><a:r><a:t>first, second</a:t></a:r><a:r><a:t> = 1234.5</a:t></a:r></a:p>`,
+		},
+		{
+			"unknown tag kept",
+			`<a:p><a:r><a:t>{{missing}} {{name}}</a:t></a:r></a:p>`,
+			`<a:p><a:r><a:t>{{missing}} Ann</a:t></a:r></a:p>`,
+		},
+		{
+			"escaped text around the tag",
+			`<a:p><a:r><a:t>R&amp;D {{html}}</a:t></a:r></a:p>`,
+			`<a:p><a:r><a:t>R&amp;D &lt;b&gt;</a:t></a:r></a:p>`,
+		},
+		{
+			"no tags",
+			`<a:p><a:r><a:t>plain</a:t></a:r><a:endParaRPr/></a:p>`,
+			`<a:p><a:r><a:t>plain</a:t></a:r><a:endParaRPr/></a:p>`,
+		},
+		{
+			"empty paragraph",
+			`<a:p><a:endParaRPr/></a:p>`,
+			`<a:p><a:endParaRPr/></a:p>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(fillParagraph([]byte(tt.in), resolve)); got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLookupValue(t *testing.T) {
+	var data any
+	if err := json.Unmarshal([]byte(`{"a": {"b": [{"c": 1}, {"c": "two"}]}, "n": null}`), &data); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		want   any
+		wantOK bool
+	}{
+		{"a.b.0.c", 1.0, true},
+		{"a.b.1.c", "two", true},
+		{"n", nil, true},
+		{"a.b.2.c", nil, false},
+		{"a.b.x", nil, false},
+		{"a.missing", nil, false},
+		{"a.b.0.c.d", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := lookupValue(data, tt.name)
+			if ok != tt.wantOK || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lookupValue(%q) = %v, %v; want %v, %v", tt.name, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	tests := []struct {
+		in   any
+		want string
+	}{
+		{nil, ""},
+		{"text", "text"},
+		{42.0, "42"},
+		{0.25, "0.25"},
+		{json.Number("1e3"), "1e3"},
+		{true, "true"},
+		{false, "false"},
+	}
+
+	for _, tt := range tests {
+		if got := formatValue(tt.in); got != tt.want {
+			t.Errorf("formatValue(%#v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRenderTemplateValues(t *testing.T) {
+	data := map[string]any{
+		"customer": map[string]any{"name": "Ann & Bob"},
+		"dept":     "Sales",
+		"active":   true,
+	}
+	pkg := writeDeck(t, func(w io.Writer) error { return RenderTemplate(w, templateDeck, data) })
+
+	slides := slideTexts(t, pkg)
+	if len(slides) != 1 {
+		t.Fatalf("got %d slides, want 1", len(slides))
+	}
+	want := []string{"Hello Ann & Bob!", "R&D: Sales / {{missing}}", "Active: true"}
+	if !reflect.DeepEqual(slides[0], want) {
+		t.Errorf("slide text = %q, want %q", slides[0], want)
+	}
+
+	parts, _ := pkg.slideParts()
+	body, err := pkg.read(parts[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The value takes the formatting of the run the tag started in.
+	if !strings.Contains(string(body), `<a:rPr lang="en-US" b="1"/><a:t>Hello Ann &amp; Bob</a:t>`) {
+		t.Errorf("value not written into the first run of the tag:\n%s", body)
+	}
+}
+
+func TestRenderTemplateMissingFile(t *testing.T) {
+	if err := RenderTemplate(io.Discard, "testdata/missing.pptx", nil); err == nil {
+		t.Error("expected an error for a missing template")
+	}
+}
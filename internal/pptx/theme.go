@@ -0,0 +1,541 @@
+package pptx
+
+import (
+	"encoding/xml"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Theme is the color scheme and font pair of a deck's theme part.
+type Theme struct {
+	Name      string            `json:"name,omitempty"`
+	Colors    map[string]string `json:"colors"`               // dk1, lt1, dk2, lt2, accent1-6, hlink, folHlink -> #RRGGBB
+	MajorFont string            `json:"major_font,omitempty"` // headings (+mj-lt)
+	MinorFont string            `json:"minor_font,omitempty"` // body text (+mn-lt)
+}
+
+// ExtractTheme reads the theme of the first slide master of a PPTX.
+func ExtractTheme(pptxPath string) (*Theme, error) {
+	pkg, err := openPackage(pptxPath)
+	if err != nil {
+		return nil, err
+	}
+	defer pkg.Close()
+
+	part := pkg.relTarget(pkg.relTarget(presentationPart, relTypeSlideMaster), relTypeTheme)
+	if part == "" {
+		part = "ppt/theme/theme1.xml"
+	}
+	data, err := pkg.read(part)
+	if err != nil {
+		return nil, err
+	}
+	return parseTheme(data)
+}
+
+// colorXML is any DrawingML color element (srgbClr, schemeClr, sysClr,
+// prstClr) with its transforms (lumMod, lumOff, tint, shade, ...).
+type colorXML struct {
+	XMLName xml.Name
+	Val     string `xml:"val,attr"`
+	LastClr string `xml:"lastClr,attr"`
+	Mods    []struct {
+		XMLName xml.Name
+		Val     int `xml:"val,attr"`
+	} `xml:",any"`
+}
+
+// colorChoice holds the color child of a solidFill or fontRef.
+type colorChoice struct {
+	Colors []colorXML `xml:",any"`
+}
+
+func (c *colorChoice) color() *colorXML {
+	if c == nil {
+		return nil
+	}
+	for i := range c.Colors {
+		switch c.Colors[i].XMLName.Local {
+		case "srgbClr", "schemeClr", "sysClr", "prstClr":
+			return &c.Colors[i]
+		}
+	}
+	return nil
+}
+
+// runPropsXML is an a:rPr or a:defRPr.
+type runPropsXML struct {
+	Size  int    `xml:"sz,attr"`
+	Bold  string `xml:"b,attr"`
+	Latin *struct {
+		Typeface string `xml:"typeface,attr"`
+	} `xml:"latin"`
+	Fill  *colorChoice `xml:"solidFill"`
+	Hlink *struct {
+		ID     string `xml:"id,attr"`
+		Action string `xml:"action,attr"`
+	} `xml:"hlinkClick"`
+}
+
+// listStyleXML is a list of lvl1pPr..lvl9pPr (lstStyle, txStyles, defaultTextStyle).
+type listStyleXML struct {
+	Levels []struct {
+		XMLName xml.Name
+		DefRPr  *runPropsXML `xml:"defRPr"`
+	} `xml:",any"`
+}
+
+// level returns the default run properties of a 0-based outline level.
+func (l *listStyleXML) level(n int) *runPropsXML {
+	if l == nil {
+		return nil
+	}
+	name := fmt.Sprintf("lvl%dpPr", n+1)
+	for _, lvl := range l.Levels {
+		if lvl.XMLName.Local == name {
+			return lvl.DefRPr
+		}
+	}
+	return nil
+}
+
+// fontRefXML is the a:fontRef of a shape's p:style.
+type fontRefXML struct {
+	Idx string `xml:"idx,attr"` // major | minor | none
+	colorChoice
+}
+
+type clrMapXML struct {
+	Attrs []xml.Attr `xml:",any,attr"`
+}
+
+// masterShapeXML is a shape of a layout or master; placeholders pass their
+// list style and position on to the slide placeholders matching them.
+type masterShapeXML struct {
+	Ph *struct {
+		Type string `xml:"type,attr"`
+		Idx  string `xml:"idx,attr"`
+	} `xml:"nvSpPr>nvPr>ph"`
+	Xfrm *struct {
+		Off struct {
+			X int64 `xml:"x,attr"`
+			Y int64 `xml:"y,attr"`
+		} `xml:"off"`
+		Ext struct {
+			CX int64 `xml:"cx,attr"`
+			CY int64 `xml:"cy,attr"`
+		} `xml:"ext"`
+	} `xml:"spPr>xfrm"`
+	LstStyle *listStyleXML `xml:"txBody>lstStyle"`
+}
+
+// masterPartXML reads the text styles of a slide layout or master.
+type masterPartXML struct {
+	Shapes    []masterShapeXML `xml:"cSld>spTree>sp"`
+	ClrMap    *clrMapXML       `xml:"clrMap"`
+	ClrMapOvr struct {
+		Override *clrMapXML `xml:"overrideClrMapping"`
+	} `xml:"clrMapOvr"`
+	TxStyles struct {
+		Title *listStyleXML `xml:"titleStyle"`
+		Body  *listStyleXML `xml:"bodyStyle"`
+		Other *listStyleXML `xml:"otherStyle"`
+	} `xml:"txStyles"`
+}
+
+// placeholder finds the list style of the placeholder matching a slide
+// placeholder: by idx first, then by type.
+func (m *masterPartXML) placeholder(typ, idx string) *listStyleXML {
+	if sp := m.placeholderShape(typ, idx); sp != nil {
+		return sp.LstStyle
+	}
+	return nil
+}
+
+// placeholderBounds returns the position of the matching placeholder, nil if
+// it has none.
+func (m *masterPartXML) placeholderBounds(typ, idx string) *Rect {
+	sp := m.placeholderShape(typ, idx)
+	if sp == nil || sp.Xfrm == nil {
+		return nil
+	}
+	return &Rect{X: sp.Xfrm.Off.X, Y: sp.Xfrm.Off.Y, CX: sp.Xfrm.Ext.CX, CY: sp.Xfrm.Ext.CY}
+}
+
+func (m *masterPartXML) placeholderShape(typ, idx string) *masterShapeXML {
+	if m == nil {
+		return nil
+	}
+	if idx != "" {
+		for i, sp := range m.Shapes {
+			if sp.Ph != nil && sp.Ph.Idx == idx {
+				return &m.Shapes[i]
+			}
+		}
+	}
+	want := placeholderFamily(typ)
+	for i, sp := range m.Shapes {
+		if sp.Ph != nil && placeholderFamily(sp.Ph.Type) == want {
+			return &m.Shapes[i]
+		}
+	}
+	return nil
+}
+
+// placeholderFamily groups placeholder types the way masters define them.
+func placeholderFamily(typ string) string {
+	switch typ {
+	case "title", "ctrTitle":
+		return "title"
+	case "", "body", "obj", "subTitle":
+		return "body"
+	default:
+		return typ
+	}
+}
+
+type themeXML struct {
+	Name   string `xml:"name,attr"`
+	Scheme struct {
+		Entries []struct {
+			XMLName xml.Name
+			colorChoice
+		} `xml:",any"`
+	} `xml:"themeElements>clrScheme"`
+	Major struct {
+		Latin struct {
+			Typeface string `xml:"typeface,attr"`
+		} `xml:"latin"`
+	} `xml:"themeElements>fontScheme>majorFont"`
+	Minor struct {
+		Latin struct {
+			Typeface string `xml:"typeface,attr"`
+		} `xml:"latin"`
+	} `xml:"themeElements>fontScheme>minorFont"`
+}
+
+func parseTheme(data []byte) (*Theme, error) {
+	var tx themeXML
+	if err := xml.Unmarshal(data, &tx); err != nil {
+		return nil, err
+	}
+	t := &Theme{
+		Name:      tx.Name,
+		Colors:    make(map[string]string),
+		MajorFont: tx.Major.Latin.Typeface,
+		MinorFont: tx.Minor.Latin.Typeface,
+	}
+	for _, e := range tx.Scheme.Entries {
+		if c := e.color(); c != nil {
+			if hex := t.resolveColor(c, nil); hex != "" {
+				t.Colors[e.XMLName.Local] = hex
+			}
+		}
+	}
+	return t, nil
+}
+
+// defaultClrMap is the usual p:clrMap of a master.
+var defaultClrMap = map[string]string{
+	"bg1": "lt1", "tx1": "dk1", "bg2": "lt2", "tx2": "dk2",
+	"accent1": "accent1", "accent2": "accent2", "accent3": "accent3",
+	"accent4": "accent4", "accent5": "accent5", "accent6": "accent6",
+	"hlink": "hlink", "folHlink": "folHlink",
+}
+
+var presetColors = map[string]string{
+	"black": "000000", "white": "FFFFFF", "red": "FF0000", "green": "008000",
+	"blue": "0000FF", "yellow": "FFFF00", "gray": "808080", "orange": "FFA500",
+}
+
+// resolveColor turns a color element into #RRGGBB, mapping scheme colors
+// through clrMap onto the theme palette and applying the transforms.
+func (t *Theme) resolveColor(c *colorXML, clrMap map[string]string) string {
+	var hex string
+	switch c.XMLName.Local {
+	case "srgbClr":
+		hex = c.Val
+	case "sysClr":
+		hex = c.LastClr
+	case "prstClr":
+		hex = presetColors[c.Val]
+	case "schemeClr":
+		name := c.Val
+		if mapped, ok := clrMap[name]; ok {
+			name = mapped
+		} else if mapped, ok := defaultClrMap[name]; ok {
+			name = mapped
+		}
+		hex = strings.TrimPrefix(t.Colors[name], "#")
+	}
+	if len(hex) != 6 {
+		return ""
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return ""
+	}
+	r, g, b := float64(v>>16&0xFF)/255, float64(v>>8&0xFF)/255, float64(v&0xFF)/255
+
+	for _, m := range c.Mods {
+		f := float64(m.Val) / 100000
+		switch m.XMLName.Local {
+		case "lumMod", "lumOff":
+			h, s, l := rgbToHSL(r, g, b)
+			if m.XMLName.Local == "lumMod" {
+				l *= f
+			} else {
+				l += f
+			}
+			r, g, b = hslToRGB(h, s, math.Min(1, math.Max(0, l)))
+		case "tint": // toward white
+			r, g, b = 1-(1-r)*f, 1-(1-g)*f, 1-(1-b)*f
+		case "shade": // toward black
+			r, g, b = r*f, g*f, b*f
+		}
+	}
+	return fmt.Sprintf("#%02X%02X%02X", int(math.Round(r*255)), int(math.Round(g*255)), int(math.Round(b*255)))
+}
+
+func rgbToHSL(r, g, b float64) (h, s, l float64) {
+	max, min := math.Max(r, math.Max(g, b)), math.Min(r, math.Min(g, b))
+	l = (max + min) / 2
+	if max == min {
+		return 0, 0, l
+	}
+	d := max - min
+	if l > 0.5 {
+		s = d / (2 - max - min)
+	} else {
+		s = d / (max + min)
+	}
+	switch max {
+	case r:
+		h = (g - b) / d
+		if g < b {
+			h += 6
+		}
+	case g:
+		h = (b-r)/d + 2
+	default:
+		h = (r-g)/d + 4
+	}
+	return h / 6, s, l
+}
+
+func hslToRGB(h, s, l float64) (r, g, b float64) {
+	if s == 0 {
+		return l, l, l
+	}
+	hue := func(p, q, t float64) float64 {
+		if t < 0 {
+			t++
+		}
+		if t > 1 {
+			t--
+		}
+		switch {
+		case t < 1.0/6:
+			return p + (q-p)*6*t
+		case t < 0.5:
+			return q
+		case t < 2.0/3:
+			return p + (q-p)*(2.0/3-t)*6
+		}
+		return p
+	}
+	q := l * (1 + s)
+	if l >= 0.5 {
+		q = l + s - l*s
+	}
+	p := 2*l - q
+	return hue(p, q, h+1.0/3), hue(p, q, h), hue(p, q, h-1.0/3)
+}
+
+// styleResolver resolves the effective run styles of slides through the
+// placeholder -> layout -> master -> theme chain. Parsed parts are cached
+// for the slides of one package.
+type styleResolver struct {
+	pkg      *sourcePackage
+	parts    map[string]*masterPartXML
+	themes   map[string]*Theme
+	defaults *listStyleXML // presentation.xml defaultTextStyle
+}
+
+func newStyleResolver(pkg *sourcePackage) *styleResolver {
+	r := &styleResolver{pkg: pkg, parts: make(map[string]*masterPartXML), themes: make(map[string]*Theme)}
+	if data, err := pkg.read(presentationPart); err == nil {
+		var pres struct {
+			Default *listStyleXML `xml:"defaultTextStyle"`
+		}
+		if xml.Unmarshal(data, &pres) == nil {
+			r.defaults = pres.Default
+		}
+	}
+	return r
+}
+
+func (r *styleResolver) part(name string) *masterPartXML {
+	if name == "" {
+		return nil
+	}
+	if p, ok := r.parts[name]; ok {
+		return p
+	}
+	var p *masterPartXML
+	if data, err := r.pkg.read(name); err == nil {
+		p = &masterPartXML{}
+		if xml.Unmarshal(data, p) != nil {
+			p = nil
+		}
+	}
+	r.parts[name] = p
+	return p
+}
+
+func (r *styleResolver) theme(masterPart string) *Theme {
+	name := r.pkg.relTarget(masterPart, relTypeTheme)
+	if t, ok := r.themes[name]; ok {
+		return t
+	}
+	t := &Theme{Colors: map[string]string{}}
+	if data, err := r.pkg.read(name); err == nil {
+		if parsed, err := parseTheme(data); err == nil {
+			t = parsed
+		}
+	}
+	r.themes[name] = t
+	return t
+}
+
+// apply sets Font, Size and Color of every text run of the slide to the
+// effective values. Explicit run properties win; otherwise the shape's list
+// style, its p:style font reference, the matching layout and master
+// placeholders, the master text styles and the presentation defaults are
+// consulted in that order, falling back to the theme fonts and tx1.
+// Placeholders without a position get the bounds of their layout or master
+// placeholder.
+func (r *styleResolver) apply(slidePart string, slide *JSONSlide) {
+	layoutPart := r.pkg.relTarget(slidePart, relTypeSlideLayout)
+	masterPart := r.pkg.relTarget(layoutPart, relTypeSlideMaster)
+	layout, master := r.part(layoutPart), r.part(masterPart)
+	theme := r.theme(masterPart)
+
+	clrMap := map[string]string{}
+	for _, m := range []*masterPartXML{master, layout} {
+		var cm *clrMapXML
+		if m == master && m != nil {
+			cm = m.ClrMap
+		} else if m != nil {
+			cm = m.ClrMapOvr.Override
+		}
+		if cm != nil {
+			for _, a := range cm.Attrs {
+				clrMap[a.Name.Local] = a.Value
+			}
+		}
+	}
+
+	var walk func(shapes []Shape)
+	walk = func(shapes []Shape) {
+		for i := range shapes {
+			s := &shapes[i]
+			chain := []*listStyleXML{s.lstStyle}
+			if s.ph != nil && s.Bounds == nil {
+				// Placeholders without an xfrm sit where the layout (or
+				// master) places them.
+				if s.Bounds = layout.placeholderBounds(s.ph.typ, s.ph.idx); s.Bounds == nil {
+					s.Bounds = master.placeholderBounds(s.ph.typ, s.ph.idx)
+				}
+			}
+			if s.ph != nil {
+				chain = append(chain, layout.placeholder(s.ph.typ, s.ph.idx), master.placeholder(s.ph.typ, s.ph.idx))
+				if master != nil {
+					switch placeholderFamily(s.ph.typ) {
+					case "title":
+						chain = append(chain, master.TxStyles.Title)
+					case "body":
+						chain = append(chain, master.TxStyles.Body)
+					default:
+						chain = append(chain, master.TxStyles.Other)
+					}
+				}
+			}
+			chain = append(chain, r.defaults)
+
+			s.Runs = s.Runs[:0]
+			for pi := range s.Paragraphs {
+				p := &s.Paragraphs[pi]
+				for ri := range p.Runs {
+					r.resolveRun(&p.Runs[ri], p.Level, chain, s.fontRef, theme, clrMap)
+				}
+				s.Runs = append(s.Runs, p.Runs...)
+			}
+			walk(s.Children)
+		}
+	}
+	walk(slide.Shapes)
+}
+
+func (r *styleResolver) resolveRun(run *TextRun, level int, chain []*listStyleXML, fontRef *fontRefXML, theme *Theme, clrMap map[string]string) {
+	props := []*runPropsXML{run.props}
+	for _, ls := range chain {
+		props = append(props, ls.level(level))
+	}
+
+	var size int
+	var font, bold string
+	var color *colorXML
+	for i, p := range props {
+		if p != nil {
+			if size == 0 {
+				size = p.Size
+			}
+			if font == "" && p.Latin != nil {
+				font = p.Latin.Typeface
+			}
+			if bold == "" {
+				bold = p.Bold
+			}
+			if color == nil {
+				color = p.Fill.color()
+			}
+		}
+		// A shape's font reference ranks right after its own list style.
+		if i == 1 && fontRef != nil {
+			if font == "" && fontRef.Idx == "major" {
+				font = "+mj-lt"
+			} else if font == "" && fontRef.Idx == "minor" {
+				font = "+mn-lt"
+			}
+			if color == nil {
+				color = fontRef.color()
+			}
+		}
+	}
+
+	if size == 0 {
+		size = 1800
+	}
+	switch {
+	case font == "" || strings.HasPrefix(font, "+mn-"):
+		font = theme.MinorFont
+	case strings.HasPrefix(font, "+mj-"):
+		font = theme.MajorFont
+	}
+	switch {
+	case run.Link != "" && (run.props == nil || run.props.Fill == nil):
+		color = &colorXML{XMLName: xml.Name{Local: "schemeClr"}, Val: "hlink"}
+	case color == nil:
+		color = &colorXML{XMLName: xml.Name{Local: "schemeClr"}, Val: "tx1"}
+	}
+
+	run.Size = size / 100
+	run.Font = font
+	run.Bold = bold == "1" || bold == "true"
+	if hex := theme.resolveColor(color, clrMap); hex != "" {
+		run.Color = hex
+	}
+}
@@ -0,0 +1,206 @@
+package pptx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const (
+	nsA = `xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main"`
+	nsP = `xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships" ` + nsA
+)
+
+// themeXMLPart is an Office-like theme: accent1 4472C4, accent2 ED7D31.
+const themeXMLPart = `<a:theme ` + nsA + ` name="Office Theme"><a:themeElements><a:clrScheme name="Office">` +
+	`<a:dk1><a:sysClr val="windowText" lastClr="000000"/></a:dk1><a:lt1><a:sysClr val="window" lastClr="FFFFFF"/></a:lt1>` +
+	`<a:dk2><a:srgbClr val="44546A"/></a:dk2><a:lt2><a:srgbClr val="E7E6E6"/></a:lt2>` +
+	`<a:accent1><a:srgbClr val="4472C4"/></a:accent1><a:accent2><a:srgbClr val="ED7D31"/></a:accent2>` +
+	`<a:hlink><a:srgbClr val="0563C1"/></a:hlink></a:clrScheme>` +
+	`<a:fontScheme name="Office"><a:majorFont><a:latin typeface="Calibri Light"/></a:majorFont>` +
+	`<a:minorFont><a:latin typeface="Calibri"/></a:minorFont></a:fontScheme></a:themeElements></a:theme>`
+
+func TestParseTheme(t *testing.T) {
+	theme, err := parseTheme([]byte(themeXMLPart))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if theme.Name != "Office Theme" || theme.MajorFont != "Calibri Light" || theme.MinorFont != "Calibri" {
+		t.Errorf("theme = %+v", theme)
+	}
+	want := map[string]string{"dk1": "#000000", "lt1": "#FFFFFF", "dk2": "#44546A", "accent1": "#4472C4", "hlink": "#0563C1"}
+	for name, hex := range want {
+		if theme.Colors[name] != hex {
+			t.Errorf("color %s = %q, want %s", name, theme.Colors[name], hex)
+		}
+	}
+}
+
+func TestResolveColor(t *testing.T) {
+	theme, err := parseTheme([]byte(themeXMLPart))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		xml    string
+		clrMap map[string]string
+		want   string
+	}{
+		{"rgb", `<srgbClr val="123456"/>`, nil, "#123456"},
+		{"system color", `<sysClr val="windowText" lastClr="0A0B0C"/>`, nil, "#0A0B0C"},
+		{"preset color", `<prstClr val="red"/>`, nil, "#FF0000"},
+		{"scheme color", `<schemeClr val="accent1"/>`, nil, "#4472C4"},
+		{"darker 25%", `<schemeClr val="accent1"><lumMod val="75000"/></schemeClr>`, nil, "#2F5597"},
+		{"lighter 40%", `<schemeClr val="accent1"><lumMod val="60000"/><lumOff val="40000"/></schemeClr>`, nil, "#8FAADC"},
+		{"darker 50%", `<schemeClr val="accent2"><lumMod val="50000"/></schemeClr>`, nil, "#843C0B"},
+		{"lumOff clamps at white", `<srgbClr val="808080"><lumOff val="90000"/></srgbClr>`, nil, "#FFFFFF"},
+		{"tint", `<srgbClr val="000000"><tint val="25000"/></srgbClr>`, nil, "#BFBFBF"},
+		{"shade", `<srgbClr val="FFFFFF"><shade val="50000"/></srgbClr>`, nil, "#808080"},
+		{"default mapping", `<schemeClr val="tx1"/>`, nil, "#000000"},
+		{"clrMap override", `<schemeClr val="tx1"/>`, map[string]string{"tx1": "lt1"}, "#FFFFFF"},
+		{"unknown scheme color", `<schemeClr val="accent6"/>`, nil, ""},
+		{"invalid rgb", `<srgbClr val="12345"/>`, nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var c colorXML
+			if err := xml.Unmarshal([]byte(tt.xml), &c); err != nil {
+				t.Fatal(err)
+			}
+			if got := theme.resolveColor(&c, tt.clrMap); got != tt.want {
+				t.Errorf("resolveColor(%s) = %q, want %q", tt.xml, got, tt.want)
+			}
+		})
+	}
+}
+
+// writeStyledDeck writes a one-slide deck whose layout and master carry the
+// text styles, and returns its path.
+func writeStyledDeck(t *testing.T, slideShapes string) string {
+	t.Helper()
+	rel := func(id, typ, target string) string {
+		return `<Relationship Id="` + id + `" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/` + typ + `" Target="` + target + `"/>`
+	}
+	rels := func(items ...string) string {
+		s := `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`
+		for _, r := range items {
+			s += r
+		}
+		return s + `</Relationships>`
+	}
+	xfrm := func(x, y string) string {
+		return `<p:spPr><a:xfrm><a:off x="` + x + `" y="` + y + `"/><a:ext cx="100" cy="50"/></a:xfrm></p:spPr>`
+	}
+
+	parts := map[string]string{
+		"ppt/presentation.xml": `<p:presentation ` + nsP + `><p:sldIdLst><p:sldId id="256" r:id="rId1"/></p:sldIdLst>` +
+			`<p:defaultTextStyle><a:lvl1pPr><a:defRPr sz="1200"><a:solidFill><a:srgbClr val="00FF00"/></a:solidFill></a:defRPr></a:lvl1pPr></p:defaultTextStyle></p:presentation>`,
+		"ppt/_rels/presentation.xml.rels": rels(rel("rId1", "slide", "slides/slide1.xml")),
+		"ppt/slides/slide1.xml":           `<p:sld ` + nsP + `><p:cSld><p:spTree>` + slideShapes + `</p:spTree></p:cSld></p:sld>`,
+		"ppt/slides/_rels/slide1.xml.rels": rels(rel("rId1", "slideLayout", "../slideLayouts/slideLayout1.xml"),
+			`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink" Target="https://example.com/" TargetMode="External"/>`),
+		// The layout moves the title and maps tx1 to accent2
+		"ppt/slideLayouts/slideLayout1.xml": `<p:sldLayout ` + nsP + `><p:cSld><p:spTree>` +
+			`<p:sp><p:nvSpPr><p:cNvPr id="2" name="Title"/><p:cNvSpPr/><p:nvPr><p:ph type="title"/></p:nvPr></p:nvSpPr>` + xfrm("10", "20") + `</p:sp>` +
+			`</p:spTree></p:cSld><p:clrMapOvr><a:overrideClrMapping bg1="lt1" tx1="accent2"/></p:clrMapOvr></p:sldLayout>`,
+		"ppt/slideLayouts/_rels/slideLayout1.xml.rels": rels(rel("rId1", "slideMaster", "../slideMasters/slideMaster1.xml")),
+		"ppt/slideMasters/slideMaster1.xml": `<p:sldMaster ` + nsP + `><p:cSld><p:spTree>` +
+			`<p:sp><p:nvSpPr><p:cNvPr id="2" name="Title"/><p:cNvSpPr/><p:nvPr><p:ph type="title"/></p:nvPr></p:nvSpPr>` + xfrm("1", "2") + `</p:sp>` +
+			`<p:sp><p:nvSpPr><p:cNvPr id="3" name="Body"/><p:cNvSpPr/><p:nvPr><p:ph type="body" idx="1"/></p:nvPr></p:nvSpPr>` + xfrm("30", "40") +
+			`<p:txBody><a:lstStyle><a:lvl1pPr><a:defRPr b="1"/></a:lvl1pPr></a:lstStyle></p:txBody></p:sp>` +
+			`</p:spTree></p:cSld><p:clrMap bg1="lt1" tx1="dk1" bg2="lt2" tx2="dk2" accent1="accent1" accent2="accent2" hlink="hlink" folHlink="folHlink"/>` +
+			`<p:txStyles><p:titleStyle><a:lvl1pPr><a:defRPr sz="4400"><a:latin typeface="+mj-lt"/></a:defRPr></a:lvl1pPr></p:titleStyle>` +
+			`<p:bodyStyle><a:lvl1pPr><a:defRPr sz="2800"/></a:lvl1pPr><a:lvl2pPr><a:defRPr sz="2400"/></a:lvl2pPr></p:bodyStyle>` +
+			`<p:otherStyle><a:lvl1pPr><a:defRPr sz="1000"/></a:lvl1pPr></p:otherStyle></p:txStyles></p:sldMaster>`,
+		"ppt/slideMasters/_rels/slideMaster1.xml.rels": rels(rel("rId1", "theme", "../theme/theme1.xml")),
+		"ppt/theme/theme1.xml":                         themeXMLPart,
+	}
+
+	path := filepath.Join(t.TempDir(), "styled.pptx")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for name, body := range parts {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(body))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestStyleResolverApply(t *testing.T) {
+	sp := func(name, nvPr, spPr, body string) string {
+		return `<p:sp><p:nvSpPr><p:cNvPr id="9" name="` + name + `"/><p:cNvSpPr/><p:nvPr>` + nvPr + `</p:nvPr></p:nvSpPr>` +
+			spPr + `<p:txBody>` + body + `</p:txBody></p:sp>`
+	}
+	run := `<a:r><a:t>x</a:t></a:r>`
+	shapes := sp("Title", `<p:ph type="ctrTitle"/>`, "", `<a:p>`+run+`</a:p>`) +
+		sp("Content", `<p:ph idx="1"/>`, "", `<a:p>`+run+`</a:p><a:p><a:pPr lvl="1"/>`+run+`</a:p>`) +
+		sp("Box", "", `<p:spPr/><p:style><a:fontRef idx="major"><a:schemeClr val="accent1"/></a:fontRef></p:style>`, `<a:p>`+run+`</a:p>`) +
+		sp("Styled box", "", `<p:spPr/><p:style><a:fontRef idx="major"><a:schemeClr val="accent1"/></a:fontRef></p:style>`,
+			`<a:lstStyle><a:lvl1pPr><a:defRPr sz="900"><a:latin typeface="Arial"/><a:solidFill><a:srgbClr val="111111"/></a:solidFill></a:defRPr></a:lvl1pPr></a:lstStyle>`+
+				`<a:p><a:r><a:rPr sz="1100"/><a:t>x</a:t></a:r></a:p>`) +
+		sp("Link", `<p:ph type="body" idx="1"/>`, "", `<a:p><a:r><a:rPr><a:hlinkClick r:id="rId2"/></a:rPr><a:t>x</a:t></a:r></a:p>`)
+
+	slides, err := ExtractSlideContent(writeStyledDeck(t, shapes))
+	if err != nil {
+		t.Fatal(err)
+	}
+	slide := slides[1].Styles.(*JSONSlide)
+	if len(slide.Shapes) != 5 {
+		t.Fatalf("got %d shapes", len(slide.Shapes))
+	}
+	title, content, box, styled, link := slide.Shapes[0], slide.Shapes[1], slide.Shapes[2], slide.Shapes[3], slide.Shapes[4]
+
+	tests := []struct {
+		name string
+		run  TextRun
+		want TextRun
+	}{
+		// ctrTitle matches the title placeholders; the fill comes from the presentation defaults
+		{"title", title.Paragraphs[0].Runs[0], TextRun{Text: "x", Size: 44, Font: "Calibri Light", Color: "#00FF00"}},
+		// A typeless placeholder inherits the master body placeholder and bodyStyle
+		{"content level 1", content.Paragraphs[0].Runs[0], TextRun{Text: "x", Bold: true, Size: 28, Font: "Calibri", Color: "#00FF00"}},
+		// Without any fill, tx1 is accent2 through the layout's clrMapOvr
+		{"content level 2", content.Paragraphs[1].Runs[0], TextRun{Text: "x", Size: 24, Font: "Calibri", Color: "#ED7D31"}},
+		// The font reference ranks before the presentation defaults
+		{"font reference", box.Paragraphs[0].Runs[0], TextRun{Text: "x", Size: 12, Font: "Calibri Light", Color: "#4472C4"}},
+		// The shape's list style and the run's own properties rank before it
+		{"list style", styled.Paragraphs[0].Runs[0], TextRun{Text: "x", Size: 11, Font: "Arial", Color: "#111111"}},
+		{"hyperlink", link.Paragraphs[0].Runs[0], TextRun{Text: "x", Bold: true, Size: 28, Font: "Calibri", Color: "#0563C1", Link: "https://example.com/"}},
+	}
+	for _, tt := range tests {
+		got := tt.run
+		got.props, got.linkRel = nil, ""
+		if got != tt.want {
+			t.Errorf("%s: run = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+
+	// Placeholders without an xfrm take the layout's position, else the master's
+	if b := title.Bounds; b == nil || *b != (Rect{X: 10, Y: 20, CX: 100, CY: 50}) {
+		t.Errorf("title bounds = %+v", b)
+	}
+	if b := content.Bounds; b == nil || *b != (Rect{X: 30, Y: 40, CX: 100, CY: 50}) {
+		t.Errorf("content bounds = %+v", b)
+	}
+	if box.Bounds != nil {
+		t.Errorf("non-placeholder bounds = %+v", box.Bounds)
+	}
+}
@@ -0,0 +1,185 @@
+// Package renderer runs LibreOffice conversions in a bounded pool of worker
+// slots, each with its own user profile, a deadline per attempt and retries.
+package renderer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// pdfExportFilter is the LibreOffice (7.4+) PDF filter with JSON options.
+// Hidden slides are exported too so that page N is slide N.
+const pdfExportFilter = `pdf:impress_pdf_Export:{"ExportHiddenSlides":{"type":"boolean","value":"true"}}`
+
+// Config sizes a Pool. Zero values take the defaults below.
+type Config struct {
+	Workers    int           // concurrent soffice processes (default 2)
+	Timeout    time.Duration // per attempt (default 2 minutes)
+	Retries    int           // extra attempts after a failure (default 0)
+	ProfileDir string        // parent of the per-worker profiles (default <tmp>/slideforge/lo-profiles)
+	Binary     string        // default libreoffice
+}
+
+// Stats are the counters of a Pool, shown on the dashboard.
+type Stats struct {
+	Workers   int   `json:"workers"`
+	Queued    int64 `json:"queued"` // waiting for a free worker
+	Active    int64 `json:"active"`
+	Completed int64 `json:"completed"`
+	Failed    int64 `json:"failed"` // jobs that failed after all retries
+	Timeouts  int64 `json:"timeouts"`
+	Retries   int64 `json:"retries"`
+}
+
+// Pool limits LibreOffice to Workers concurrent processes. Every worker slot
+// owns a profile dir, so parallel conversions never share the profile lock; a
+// process is started per job and killed with its children at the deadline.
+type Pool struct {
+	cfg   Config
+	slots chan int
+
+	queued, active                       atomic.Int64
+	completed, failed, timeouts, retries atomic.Int64
+}
+
+// NewPool creates a pool with cfg.Workers free slots.
+func NewPool(cfg Config) *Pool {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 2
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 2 * time.Minute
+	}
+	if cfg.Retries < 0 {
+		cfg.Retries = 0
+	}
+	if cfg.ProfileDir == "" {
+		cfg.ProfileDir = filepath.Join(os.TempDir(), "slideforge", "lo-profiles")
+	}
+	if cfg.Binary == "" {
+		cfg.Binary = "libreoffice"
+	}
+	p := &Pool{cfg: cfg, slots: make(chan int, cfg.Workers)}
+	for i := 0; i < cfg.Workers; i++ {
+		p.slots <- i
+	}
+	return p
+}
+
+var defaultPool atomic.Pointer[Pool]
+
+// Default returns the pool set with SetDefault, or a pool with default
+// settings.
+func Default() *Pool {
+	if p := defaultPool.Load(); p != nil {
+		return p
+	}
+	defaultPool.CompareAndSwap(nil, NewPool(Config{}))
+	return defaultPool.Load()
+}
+
+// SetDefault replaces the default pool; call it once at startup.
+func SetDefault(p *Pool) {
+	defaultPool.Store(p)
+}
+
+// Stats returns a snapshot of the pool counters.
+func (p *Pool) Stats() Stats {
+	return Stats{
+		Workers:   p.cfg.Workers,
+		Queued:    p.queued.Load(),
+		Active:    p.active.Load(),
+		Completed: p.completed.Load(),
+		Failed:    p.failed.Load(),
+		Timeouts:  p.timeouts.Load(),
+		Retries:   p.retries.Load(),
+	}
+}
+
+// Available reports whether the LibreOffice binary of the pool is installed.
+func (p *Pool) Available() bool {
+	_, err := exec.LookPath(p.cfg.Binary)
+	return err == nil
+}
+
+// ConvertToPDF exports pptxPath as PDF into outDir and returns the PDF path.
+// It waits for a free worker (or ctx), then tries up to 1+Retries times.
+func (p *Pool) ConvertToPDF(ctx context.Context, pptxPath, outDir string) (string, error) {
+	p.queued.Add(1)
+	var slot int
+	select {
+	case slot = <-p.slots:
+		p.queued.Add(-1)
+	case <-ctx.Done():
+		p.queued.Add(-1)
+		return "", ctx.Err()
+	}
+	p.active.Add(1)
+	defer func() {
+		p.active.Add(-1)
+		p.slots <- slot
+	}()
+
+	var lastErr error
+	for attempt := 0; attempt <= p.cfg.Retries; attempt++ {
+		if attempt > 0 {
+			p.retries.Add(1)
+		}
+		pdf, err := p.convert(ctx, slot, pptxPath, outDir)
+		if err == nil {
+			p.completed.Add(1)
+			return pdf, nil
+		}
+		lastErr = err
+		if ctx.Err() != nil {
+			break // the caller gave up; do not retry
+		}
+		// A crashed or killed soffice may leave its profile locked or
+		// half-written: start the next attempt with a fresh one.
+		os.RemoveAll(p.profileDir(slot))
+	}
+	p.failed.Add(1)
+	return "", lastErr
+}
+
+func (p *Pool) profileDir(slot int) string {
+	return filepath.Join(p.cfg.ProfileDir, fmt.Sprintf("worker-%d", slot))
+}
+
+// convert runs one soffice process with the slot's profile and the deadline.
+func (p *Pool) convert(ctx context.Context, slot int, pptxPath, outDir string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.cfg.Timeout)
+	defer cancel()
+
+	profile, err := filepath.Abs(p.profileDir(slot))
+	if err != nil {
+		return "", err
+	}
+	cmd := exec.CommandContext(ctx, p.cfg.Binary,
+		"-env:UserInstallation=file://"+filepath.ToSlash(profile),
+		"--headless", "--norestore", "--nolockcheck",
+		"--convert-to", pdfExportFilter, "--outdir", outDir, pptxPath)
+	killProcessGroup(cmd)
+	cmd.WaitDelay = 5 * time.Second
+
+	out, err := cmd.CombinedOutput()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		p.timeouts.Add(1)
+		return "", fmt.Errorf("libreoffice timed out after %s converting %s", p.cfg.Timeout, filepath.Base(pptxPath))
+	}
+	if err != nil {
+		return "", fmt.Errorf("libreoffice conversion failed: %v: %s", err, out)
+	}
+
+	pdfs, _ := filepath.Glob(filepath.Join(outDir, "*.pdf"))
+	if len(pdfs) == 0 {
+		return "", fmt.Errorf("pdf file not found after converting %s: %s", filepath.Base(pptxPath), out)
+	}
+	return pdfs[0], nil
+}
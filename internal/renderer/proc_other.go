@@ -0,0 +1,8 @@
+//go:build !unix
+
+package renderer
+
+import "os/exec"
+
+// killProcessGroup keeps the default cancellation, which kills the process.
+func killProcessGroup(cmd *exec.Cmd) {}
@@ -0,0 +1,18 @@
+//go:build unix
+
+package renderer
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// killProcessGroup starts the command in its own process group and makes
+// context cancellation kill the whole group: the libreoffice wrapper forks
+// soffice.bin, which would otherwise survive its parent.
+func killProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
@@ -32,6 +32,7 @@ $(document).ready(function () {
     initGlobalEvents();
     initUserMenu();
     initTerminalObserver();
+    initIngestProgress();
 });
 
 function initTheme() {
@@ -121,3 +122,61 @@ function initTerminalObserver() {
         }, 500);
     };
 }
+
+// Share of a job's progress bar reached at the start of each stage; the
+// summarizing stage fills the rest slide by slide.
+const INGEST_STAGE_PROGRESS = { queued: 0, rendering: 10, extracting: 30, summarizing: 40, done: 100, failed: 100 };
+
+function initIngestProgress() {
+    const container = document.getElementById('ingest-progress');
+    if (!container || !window.EventSource) return;
+
+    const evtSource = new EventSource("/events/jobs");
+    // A (re)connected stream starts with every unfinished job; rows of jobs
+    // that ended while disconnected would otherwise stay forever
+    evtSource.addEventListener('open', () => {
+        container.querySelectorAll('.ingest-job:not(.done):not(.failed)').forEach(row => row.remove());
+    });
+    const update = (event) => renderIngestJob(container, event.type, JSON.parse(event.data));
+    ['progress', 'warning', 'done', 'failed'].forEach(type => evtSource.addEventListener(type, update));
+}
+
+function renderIngestJob(container, type, job) {
+    let row = document.getElementById('ingest-job-' + job.job_id);
+    if (!row) {
+        row = document.createElement('div');
+        row.id = 'ingest-job-' + job.job_id;
+        row.className = 'ingest-job';
+        row.innerHTML = `
+            <div class="ingest-job-header">
+                <span class="ingest-job-file"></span>
+                <small class="ingest-job-stage text-muted"></small>
+            </div>
+            <div class="ingest-job-bar"><div class="ingest-job-fill"></div></div>
+            <small class="ingest-job-error"></small>
+        `;
+        container.appendChild(row);
+    }
+    row.querySelector('.ingest-job-file').textContent = job.file;
+
+    if (type === 'warning' || type === 'failed') {
+        row.querySelector('.ingest-job-error').textContent = job.error || '';
+    }
+    if (type === 'warning') return;
+
+    let percent = INGEST_STAGE_PROGRESS[job.stage] || 0;
+    if (job.stage === 'summarizing' && job.slides) {
+        percent += Math.round(60 * (job.slide - 1) / job.slides);
+    }
+    row.querySelector('.ingest-job-fill').style.width = percent + '%';
+
+    let stage = container.dataset['stage' + job.stage.charAt(0).toUpperCase() + job.stage.slice(1)] || job.stage;
+    if (job.slides) stage += ` ${job.slide}/${job.slides}`;
+    if (job.ai_calls) stage += ` · AI ${job.ai_calls}`;
+    row.querySelector('.ingest-job-stage').textContent = stage;
+
+    if (type === 'done' || type === 'failed') {
+        row.classList.add(type);
+        setTimeout(() => row.remove(), type === 'done' ? 5000 : 30000);
+    }
+}
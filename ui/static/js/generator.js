@@ -1,6 +1,8 @@
 document.addEventListener('DOMContentLoaded', function () {
     initDragAndDrop();
     initSearch();
+    initExportMenu();
+    initJobEvents();
     updateSlideCounts();
 
     // Global Cleanup: Ensure "menu no buttons" rule is applied to any existing items
@@ -19,47 +21,7 @@ document.addEventListener('DOMContentLoaded', function () {
 let currentDragItems = [];
 
 function initDragAndDrop() {
-    // Source side - individual slides
-    const containers = document.querySelectorAll('.slides-container');
-    containers.forEach(container => {
-        new Sortable(container, {
-            group: {
-                name: 'slides',
-                pull: 'clone',
-                put: false
-            },
-            sort: false,
-            animation: 150,
-            onStart: function (evt) {
-                // Prepare list of items to drag
-                const item = evt.item;
-                currentDragItems = [];
-
-                if (item.classList.contains('selected')) {
-                    // Multi-drag: grab all selected items from ANY container (global selection)
-                    currentDragItems = Array.from(document.querySelectorAll('.slide-item.selected'));
-                } else {
-                    // Single drag
-                    currentDragItems = [item];
-                }
-
-                // Optional: visual feedback
-                currentDragItems.forEach(el => el.style.opacity = '0.5');
-            },
-            onEnd: function (evt) {
-                // Restore opacity
-                currentDragItems.forEach(el => el.style.opacity = '');
-            }
-        });
-    });
-
-    // Make PPT nodes draggable to add all slides at once
-    const pptNodes = document.querySelectorAll('.ppt-node');
-    pptNodes.forEach(node => {
-        node.addEventListener('dragstart', (e) => {
-            e.dataTransfer.setData('text/pptx-id', node.getAttribute('data-id'));
-        });
-    });
+    initSourceDragAndDrop();
 
     // Target side
     const target = document.getElementById('collection-target');
@@ -123,6 +85,51 @@ function initDragAndDrop() {
     });
 }
 
+// Also run after the library tree is refreshed
+function initSourceDragAndDrop() {
+    // Source side - individual slides
+    const containers = document.querySelectorAll('.slides-container');
+    containers.forEach(container => {
+        new Sortable(container, {
+            group: {
+                name: 'slides',
+                pull: 'clone',
+                put: false
+            },
+            sort: false,
+            animation: 150,
+            onStart: function (evt) {
+                // Prepare list of items to drag
+                const item = evt.item;
+                currentDragItems = [];
+
+                if (item.classList.contains('selected')) {
+                    // Multi-drag: grab all selected items from ANY container (global selection)
+                    currentDragItems = Array.from(document.querySelectorAll('.slide-item.selected'));
+                } else {
+                    // Single drag
+                    currentDragItems = [item];
+                }
+
+                // Optional: visual feedback
+                currentDragItems.forEach(el => el.style.opacity = '0.5');
+            },
+            onEnd: function (evt) {
+                // Restore opacity
+                currentDragItems.forEach(el => el.style.opacity = '');
+            }
+        });
+    });
+
+    // Make PPT nodes draggable to add all slides at once
+    const pptNodes = document.querySelectorAll('.ppt-node');
+    pptNodes.forEach(node => {
+        node.addEventListener('dragstart', (e) => {
+            e.dataTransfer.setData('text/pptx-id', node.getAttribute('data-id'));
+        });
+    });
+}
+
 function processAddedItem(item, sourceItem) {
     const target = document.getElementById('collection-target');
     const empty = target.querySelector('.collection-empty');
@@ -191,6 +198,47 @@ function initSearch() {
     });
 }
 
+// Refreshes the library when the ingest of a deck finishes.
+function initJobEvents() {
+    if (!document.getElementById('source-tree') || !window.EventSource) return;
+
+    let refreshTimer = null;
+    const refresh = () => {
+        // Several decks often finish together; refresh once
+        clearTimeout(refreshTimer);
+        refreshTimer = setTimeout(refreshSourceTree, 1000);
+    };
+    const evtSource = new EventSource('/events/jobs');
+    evtSource.addEventListener('done', refresh);
+    // A deck may have finished while the stream was reconnecting
+    let connected = false;
+    evtSource.addEventListener('open', () => {
+        if (connected) refresh();
+        connected = true;
+    });
+}
+
+// Replaces the library tree with a freshly rendered one, keeping the filter.
+function refreshSourceTree() {
+    fetch('/generator')
+        .then(resp => resp.text())
+        .then(html => {
+            const fresh = new DOMParser().parseFromString(html, 'text/html').getElementById('source-tree');
+            const tree = document.getElementById('source-tree');
+            if (!fresh || !tree) return;
+
+            tree.innerHTML = fresh.innerHTML;
+            initSourceDragAndDrop();
+            const searchInput = document.getElementById('ppt-search');
+            if (searchInput) {
+                searchInput.dispatchEvent(new Event('input'));
+            } else {
+                updateSlideCounts();
+            }
+        })
+        .catch(err => console.error('Library refresh failed:', err));
+}
+
 function toggleNode(el, e) {
     if (e) e.stopPropagation();
     el.classList.toggle('expanded');
@@ -248,7 +296,8 @@ function showSlideJson(item) {
         id: item.getAttribute('data-id'),
         path: item.getAttribute('data-path'),
         summary: item.getAttribute('data-summary'),
-        content: item.getAttribute('data-content')
+        content: item.getAttribute('data-content'),
+        notes: item.getAttribute('data-notes')
     };
 
     const modal = document.getElementById('meta-modal');
@@ -272,10 +321,21 @@ function showSlideJson(item) {
 function showMeta(item) {
     const summary = item.getAttribute('data-summary') || "No summary available.";
     const raw = item.getAttribute('data-content') || "No raw content extracted.";
+    const notes = item.getAttribute('data-notes');
 
     const rawBox = document.getElementById('meta-raw');
     document.getElementById('meta-summary').innerText = summary;
     rawBox.innerText = raw;
+    if (notes) {
+        // Speaker notes below the slide text
+        const notesBox = document.createElement('div');
+        notesBox.className = 'meta-notes';
+        notesBox.innerHTML = '<strong>Speaker Notes</strong>';
+        const notesText = document.createElement('div');
+        notesText.innerText = notes;
+        notesBox.appendChild(notesText);
+        rawBox.appendChild(notesBox);
+    }
     rawBox.style.maxHeight = '200px'; // Limit height for text metadata
 
     const modal = document.getElementById('meta-modal');
@@ -372,7 +432,8 @@ function previewSlide(path, title) {
     const img = document.getElementById('preview-img');
     const titleEl = document.getElementById('preview-title');
 
-    img.src = path;
+    // Large preview rendition; the server falls back to the original image
+    img.src = path + (path.includes('?') ? '&' : '?') + 'size=preview';
     titleEl.innerText = title;
 
     modal.style.display = 'block';
@@ -453,7 +514,7 @@ function updateSlideCounts() {
 
 function generateFromCollection() {
     const items = document.querySelectorAll('#collection-target .slide-item');
-    const slideIds = Array.from(items).map(i => i.getAttribute('data-id'));
+    const slideIds = Array.from(items).map(i => parseInt(i.getAttribute('data-id'), 10));
 
     if (slideIds.length === 0) {
         alert('Please collect at least one slide first!');
@@ -466,12 +527,70 @@ function generateFromCollection() {
     btn.innerHTML = '<i class="fas fa-spinner fa-spin"></i> Generating...';
     btn.disabled = true;
 
-    // Simulate backend call
-    setTimeout(() => {
-        alert('Deck generation logic initiated for ' + slideIds.length + ' slides.');
-        btn.innerHTML = originalHtml;
-        btn.disabled = false;
-    }, 1500);
+    fetch('/generate', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ slide_ids: slideIds })
+    })
+        .then(resp => downloadResponse(resp, 'slideforge.pptx'))
+        .catch(err => alert('Deck generation failed: ' + err.message))
+        .finally(() => {
+            btn.innerHTML = originalHtml;
+            btn.disabled = false;
+        });
+}
+
+// Downloads a file response under the name from its Content-Disposition.
+function downloadResponse(resp, fallbackName) {
+    if (!resp.ok) {
+        return resp.text().then(msg => { throw new Error(msg); });
+    }
+    const disposition = resp.headers.get('Content-Disposition') || '';
+    const match = disposition.match(/filename="?([^"]+)"?/);
+    const filename = match ? match[1] : fallbackName;
+    return resp.blob().then(blob => downloadBlob(blob, filename));
+}
+
+// Exports slides as a PDF: layout 'slides' (one per page) or 'handout'
+// (three per page with notes).
+function exportPDF(slideIds, layout) {
+    if (slideIds.length === 0) {
+        alert('Please collect at least one slide first!');
+        return;
+    }
+    fetch('/export/pdf', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ slide_ids: slideIds, layout: layout })
+    })
+        .then(resp => downloadResponse(resp, 'slideforge.pdf'))
+        .catch(err => alert('PDF export failed: ' + err.message));
+}
+
+// The slides a context-menu export covers: the whole collection when the
+// clicked slide is in it, else the selected source slides (or just the
+// clicked one).
+function exportTargetIds(item) {
+    let items;
+    if (item.parentElement.id === 'collection-target') {
+        items = document.querySelectorAll('#collection-target .slide-item');
+    } else if (item.classList.contains('selected')) {
+        items = document.querySelectorAll('#source-tree .slide-item.selected');
+    } else {
+        items = [item];
+    }
+    return Array.from(items).map(i => parseInt(i.getAttribute('data-id'), 10));
+}
+
+function downloadBlob(blob, filename) {
+    const url = URL.createObjectURL(blob);
+    const link = document.createElement('a');
+    link.href = url;
+    link.download = filename;
+    document.body.appendChild(link);
+    link.click();
+    link.remove();
+    setTimeout(() => URL.revokeObjectURL(url), 1000);
 }
 
 // Context Menu Logic
@@ -521,6 +640,24 @@ function showContextMenu(item, x, y) {
     menu.classList.add('active');
 }
 
+// Adds the PDF export actions to the context menu, styled like its other items.
+function initExportMenu() {
+    const menu = document.getElementById('context-menu');
+    const template = menu && menu.querySelector('[onclick^="handleContextAction"]');
+    if (!template) return;
+
+    [
+        ['pdf', 'fa-file-pdf', 'Export PDF'],
+        ['handout', 'fa-print', 'Export handout'],
+    ].forEach(([action, icon, label]) => {
+        const entry = template.cloneNode(false);
+        entry.setAttribute('onclick', `handleContextAction('${action}')`);
+        entry.style.display = '';
+        entry.innerHTML = `<i class="fas ${icon}"></i> ${label}`;
+        menu.appendChild(entry);
+    });
+}
+
 function closeContextMenu() {
     const menu = document.getElementById('context-menu');
     if (menu) menu.classList.remove('active');
@@ -543,6 +680,8 @@ function handleContextAction(action) {
         checkEmpty();
     } else if (action === 'json') {
         showSlideJson(contextTargetItem);
+    } else if (action === 'pdf' || action === 'handout') {
+        exportPDF(exportTargetIds(contextTargetItem), action === 'pdf' ? 'slides' : 'handout');
     } else if (action === 'remove') {
         if (contextTargetItem.parentElement.id === 'collection-target') {
             contextTargetItem.remove();
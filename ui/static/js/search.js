@@ -1,7 +1,9 @@
 let searchModes = [
     { value: 'fts', label: 'Full Text', title: 'Search using full text', icon: 'fa-magic', hasThreshold: false },
     { value: 'similarity', label: 'Similarity', title: 'Find slides with similar content', icon: 'fa-equals', hasThreshold: true },
-    { value: 'word_similarity', label: 'Word Similarity', title: 'Find slides with similar words', icon: 'fa-font', hasThreshold: true }
+    { value: 'word_similarity', label: 'Word Similarity', title: 'Find slides with similar words', icon: 'fa-font', hasThreshold: true },
+    { value: 'table', label: 'Table Header', title: 'Find slides with a table whose header contains the text', icon: 'fa-table', hasThreshold: false },
+    { value: 'link', label: 'Hyperlink', title: 'Find slides linking to a URL that contains the text', icon: 'fa-link', hasThreshold: false }
 ];
 
 let currentModeIdx = 0;